@@ -4,6 +4,7 @@ import (
 	"cerberus/blockchain/persaccntschannel"
 	"cerberus/services/crypto"
 	"cerberus/services/ipfs"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,7 +17,7 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
-func CreateAccount(firstName, lastName, email, phone string) ([]string, []string, error) {
+func (service *Service) CreateAccount(ctx context.Context, firstName, lastName, email, phone string) (*persaccntschannel.TxResult, []string, error) {
 
 	if firstName == "" {
 		return nil, "", errors.New("First name value cannot be an empty string")
@@ -58,11 +59,24 @@ func CreateAccount(firstName, lastName, email, phone string) ([]string, []string
 		ObjectType:  "person",
 		AccountData: accountData,
 		Documents:   documents,
+
+		IpfsLinkNames: make(map[string]string),
 	}
 
 	// create personAccount folder in ipfs
-	// linkReference := "/personAccounts/" + email
-	ipfsData, _, err := ipfs.CreateIpfsAccountDirectory(publicID, personAccountsIpfsHash)
+	// linkReference := "/personAccounts/" + publicID
+	personAccountsRoot, err := service.rootDirectories.Root(ipfs.PersonAccountsGroup)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the account is linked under a derived name so the DAG does not reveal the public ID
+	accountLinkName, err := ipfs.AccountLinkName(publicID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ipfsData, _, err := ipfs.CreateIpfsAccountDirectory(accountLinkName, personAccountsRoot)
 	if err != nil {
 		return nil, "", err
 	}
@@ -81,14 +95,35 @@ func CreateAccount(firstName, lastName, email, phone string) ([]string, []string
 		return nil, "", err
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, newAccountData, err := persAccntsChannelClient.CreateAccount(publicID, accountObjectAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	var response *persaccntschannel.TxResult
+	err = writeAccountRecord(publicID, accountObject, func() (err error) {
+		response, err = persAccntsChannelClient.CreateAccount(ledgerCtx, publicID, accountObjectAsBytes)
+		return err
+	})
 
 	if err != nil {
 		ipfs.DeleteDirectoryFromIpfs(ipfsData.ObjectHash, ipfsData.LinkObjectHash)
 		return nil, "", err
 	}
 
+	// link the own directory of the account under the registered personAccounts root
+	// the account is committed already - a failed link is queued and added with the next root update
+	if _, err = service.rootDirectories.AddAccount(ipfs.PersonAccountsGroup, publicID, ipfsData.ObjectHash); err != nil {
+		fmt.Println("Unable to link account " + publicID + " under the personAccounts root: " + err.Error())
+
+		if err = service.rootDirectories.QueueAccount(ipfs.PersonAccountsGroup, publicID, ipfsData.ObjectHash); err != nil {
+			fmt.Println("Unable to queue account " + publicID + " for the personAccounts root: " + err.Error())
+		}
+	}
+
 	return response, []string{string(accountObjectAsBytes), string(key)}, nil
 }
 
@@ -100,7 +135,7 @@ Selectors:
 - Phone
 - etc
 */
-func UpdateAccountBySelector(accountPublicID, key, selectorName, selectorValue string) ([]string, []string, error) {
+func (service *Service) UpdateAccountBySelector(ctx context.Context, accountPublicID, key, selectorName, selectorValue string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("Account Public ID value cannot be an empty string")
@@ -134,16 +169,23 @@ func UpdateAccountBySelector(accountPublicID, key, selectorName, selectorValue s
 		selectorName = "Phone"
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, newAccountData, err := persAccntsChannelClient.UpdateRecords("updateAccount", []string{accountPublicId, key, selectorName, strings.ToLower(selectorValue)})
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateAccount(ledgerCtx, accountPublicId, []byte(key), selectorName, strings.ToLower(selectorValue))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(newAccountData)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func UpdateAccountFirstName(accountPublicID, key, firstName string) ([]string, []string, error) {
+func (service *Service) UpdateAccountFirstName(ctx context.Context, accountPublicID, key, firstName string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("Account Public Id cannot be an empty string")
@@ -159,16 +201,23 @@ func UpdateAccountFirstName(accountPublicID, key, firstName string) ([]string, [
 
 	dataField := "FirstName"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, newAccountData, err := persAccntsChannelClient.UpdateRecords("updateAccount", []string{accountPublicID, key, dataField, strings.ToLower(firstName)})
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateAccount(ledgerCtx, accountPublicID, []byte(key), dataField, strings.ToLower(firstName))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(newAccountData)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func UpdateAccountLastName(accountPublicID, key, lastName string) ([]string, []string, error) {
+func (service *Service) UpdateAccountLastName(ctx context.Context, accountPublicID, key, lastName string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("Account Public Id cannot be an empty string")
@@ -184,16 +233,23 @@ func UpdateAccountLastName(accountPublicID, key, lastName string) ([]string, []s
 
 	dataField := "LastName"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, newAccountData, err := persAccntsChannelClient.UpdateRecords("updateAccount", []string{accountPublicID, key, dataField, strings.ToLower(lastName)})
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateAccount(ledgerCtx, accountPublicID, []byte(key), dataField, strings.ToLower(lastName))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(newAccountData)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func UpdateAccountPhone(accountPublicID, key, phone string) ([]string, []string, error) {
+func (service *Service) UpdateAccountPhone(ctx context.Context, accountPublicID, key, phone string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("Account Public Id cannot be an empty string")
@@ -209,16 +265,23 @@ func UpdateAccountPhone(accountPublicID, key, phone string) ([]string, []string,
 
 	dataField := "Phone"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, newAccountData, err := persAccntsChannelClient.UpdateRecords("updateAccount", []string{accountPublicID, key, dataField, phone})
+	persAccntsChannelClient, err := service.ledgerClient()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(newAccountData)}, response, nil
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateAccount(ledgerCtx, accountPublicID, []byte(key), dataField, phone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []string{string(response.Payload)}, response, nil
 }
 
-func UpdateAccountEmail(accountPublicID, key, email string) ([]string, []string, error) {
+func (service *Service) UpdateAccountEmail(ctx context.Context, accountPublicID, key, email string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("Account Public ID cannot be an empty string")
@@ -234,38 +297,67 @@ func UpdateAccountEmail(accountPublicID, key, email string) ([]string, []string,
 
 	dataField := "Email"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, newAccountData, err := persAccntsChannelClient.UpdateRecords("updateAccount", []string{accountPublicID, key, dataField, email})
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateAccount(ledgerCtx, accountPublicID, []byte(key), dataField, email)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(newAccountData)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func DeleteAccount(accountPublicID string) ([]string, error) {
+func (service *Service) DeleteAccount(ctx context.Context, accountPublicID string) (*persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, errors.New("Account Public ID cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, deletedRecord, err := persAccntsChannelClient.DeleteAccount(accountPublicID)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
 
+	// the record is read first - the delete returns the hash of the record only
+	accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
 	if err != nil {
 		return nil, err
 	}
 
 	record := &personAccount{}
-	if err = json.Unmarshal([]byte(deletedRecord), record); err != nil {}
+	if err = json.Unmarshal([]byte(accountRecords), record); err != nil {}
+		return nil, err
+	}
+
+	response, err := persAccntsChannelClient.DeleteAccount(ledgerCtx, accountPublicID)
+
+	if err != nil {
 		return nil, err
 	}
 
+	// the content of the account is garbage from now on
+	if err = ipfs.RemoveAccountReferences(accountPublicID); err != nil {
+		fmt.Println("Unable to remove the references of account " + accountPublicID + ": " + err.Error())
+	}
+
 	// delete records from ipfs
+	if _, err = service.rootDirectories.RemoveAccount(ipfs.PersonAccountsGroup, accountPublicID); err != nil {
+		return nil, err
+	}
+
 	ipfs.DeleteDirectoryFromIpfs(record.IpfsAccountData.ObjectHash, record.IpfsAccountData.LinkObjectHash)
 
 	for _, directory := range record.Documents {
-		_, response, err = DeleteDocument(accountPublicID, directory.DocumentData.DocumentName)
+		_, response, err = service.DeleteDocument(ctx, accountPublicID, directory.DocumentData.DocumentName)
 
 		if err != nil {
 			return nil, err
@@ -276,7 +368,7 @@ func DeleteAccount(accountPublicID string) ([]string, error) {
 	return response, nil
 }
 
-func CreateNewDocument(accountPublicID, key, documentName, holderName, countryIssue, filename string) ([]string, []string, string, error) {
+func (service *Service) CreateNewDocument(ctx context.Context, accountPublicID, key, documentName, holderName, countryIssue, filename string) ([]string, *persaccntschannel.TxResult, string, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, "", errors.New("Id value cannot be an empty string")
@@ -306,8 +398,15 @@ func CreateNewDocument(accountPublicID, key, documentName, holderName, countryIs
 	holderName = strings.ToLower(holderName)
 	countryIssue = strings.ToLower(countryIssue)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountRecords, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountPublicID)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -353,6 +452,10 @@ func CreateNewDocument(accountPublicID, key, documentName, holderName, countryIs
 
 	recordUpdate.Documents[documentName] = newDocument
 	recordUpdate.IpfsAccountData.LinkObjectHash = updatedAccountIpfsLinks
+
+	if err = addIpfsLinkNames(recordUpdate, documentName, newDocumentVersion.Name); err != nil {
+		return nil, nil, "", err
+	}
 	recordUpdate.AccountData.CreatedAt = getTime()
 
 	recordUpdateAsBytes, err := json.Marshal(recordUpdate)
@@ -366,15 +469,19 @@ func CreateNewDocument(accountPublicID, key, documentName, holderName, countryIs
 		return nil, nil, "", err
 	}
 
-	response, updatedAccount, err := persAccntsChannelClient.UpdateRecords("updateDocumentRecords", []string{[]string{accountPublicId, "", string(encrRecord)})
+	var response *persaccntschannel.TxResult
+	err = writeAccountRecord(accountPublicID, recordUpdate, func() (err error) {
+		response, err = persAccntsChannelClient.UpdateDocumentRecords(ledgerCtx, accountPublicId, encrRecord)
+		return err
+	})
 	if err != nil {
 		return nil, nil, "", err
 	}
 
-	return []string{string(updatedAccount)}, response, rsaLink, nil
+	return []string{string(response.Payload)}, response, rsaLink, nil
 }
 
-func CreateDocumentVersion(accountPublicID, key, documentName, filename, rsaLink string) ([]string, []string, error) {
+func (service *Service) CreateDocumentVersion(ctx context.Context, accountPublicID, key, documentName, filename, rsaLink string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("ID value cannot be an empty string")
@@ -398,8 +505,15 @@ func CreateDocumentVersion(accountPublicID, key, documentName, filename, rsaLink
 
 	documentName = strings.ToLower(documentName)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountRecords, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountPublicID)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -437,6 +551,10 @@ func CreateDocumentVersion(accountPublicID, key, documentName, filename, rsaLink
 	// add new version to the record
 	recordUpdate.Documents[documentName].IpfsDocumentVersionsData[newDocumentVersion.Name] = newDocumentVersion
 
+	if err = addIpfsLinkNames(recordUpdate, documentName, newDocumentVersion.Name); err != nil {
+		return nil, nil, err
+	}
+
 	recordUpdateAsBytes, err := json.Marshal(recordUpdate)
 	if err != nil {
 		return nil, nil, err
@@ -448,15 +566,19 @@ func CreateDocumentVersion(accountPublicID, key, documentName, filename, rsaLink
 		return nil, nil, "", err
 	}
 
-	response, updatedAccount, err := persAccntsChannelClient.UpdateRecords("updateDocumentRecords", []string{[]string{accountPublicId, "", string(encrRecord)})
+	var response *persaccntschannel.TxResult
+	err = writeAccountRecord(accountPublicID, recordUpdate, func() (err error) {
+		response, err = persAccntsChannelClient.UpdateDocumentRecords(ledgerCtx, accountPublicId, encrRecord)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(updatedAccount)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func UpdateDocumentCountryIssue(accountPublicID, key, documentName, countryIssueUpdate string) ([]string, []string, error) {
+func (service *Service) UpdateDocumentCountryIssue(ctx context.Context, accountPublicID, key, documentName, countryIssueUpdate string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("ID value cannot be an empty string")
@@ -474,8 +596,15 @@ func UpdateDocumentCountryIssue(accountPublicID, key, documentName, countryIssue
 		return nil, nil, errors.New("Country issue update value cannot be an empty string")
 	}
 
-	persAccntsChanelClient := persaccntschannel.CerberusClient{}
-	accountRecords, err := persAccntsChanelClient.QueryAccountData("getAccountRecords", accountPublicID)
+	persAccntsChanelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	accountRecords, err := persAccntsChanelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -508,15 +637,15 @@ func UpdateDocumentCountryIssue(accountPublicID, key, documentName, countryIssue
 		return nil, "", err
 	}
 
-	response, updatedRecord, err := persAccntsChanelClient.UpdateRecords("updateDocumentRecords", []string{[]string{accountPublicId, "", string(encrRecord)})
+	response, err := persAccntsChanelClient.UpdateDocumentRecords(ledgerCtx, accountPublicId, encrRecord)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(updatedRecord)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func UpdateDocumentHolderName(accountPublicID, key, documentName, personNameUpdate string) ([]string, []string, error) {
+func (service *Service) UpdateDocumentHolderName(ctx context.Context, accountPublicID, key, documentName, personNameUpdate string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("ID value cannot be an empty string")
@@ -537,8 +666,15 @@ func UpdateDocumentHolderName(accountPublicID, key, documentName, personNameUpda
 	documentName = strings.ToLower(documentName)
 	personNameUpdate = strings.ToLower(personNameUpdate)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountRecords, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountPublicID)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -571,15 +707,15 @@ func UpdateDocumentHolderName(accountPublicID, key, documentName, personNameUpda
 		return nil, "", err
 	}
 
-	response, updatedRecord, err := persAccntsChannelClient.UpdateRecords("updateDocumentRecords", []string{[]string{accountPublicId, "", string(encrRecord)})
+	response, err := persAccntsChannelClient.UpdateDocumentRecords(ledgerCtx, accountPublicId, encrRecord)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(updatedRecord)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func DeleteDocument(accountPublicID, key, documentName string) ([]string, []string, error) {
+func (service *Service) DeleteDocument(ctx context.Context, accountPublicID, key, documentName string) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicID == "" {
 		return nil, nil, errors.New("Account ID value cannot be an empty string")
@@ -595,8 +731,15 @@ func DeleteDocument(accountPublicID, key, documentName string) ([]string, []stri
 
 	documentName = strings.ToLower(documentName)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountRecords, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountPublicID)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+	defer cancel()
+
+	accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -621,6 +764,7 @@ func DeleteDocument(accountPublicID, key, documentName string) ([]string, []stri
 
 	documentToDelete := documents[documentName]
 
+	removeIpfsDocumentLinkNames(recordUpdate, documentName)
 	delete(recordUpdate.Documents, documentName)
 
 	recordUpdateAsBytes, err := json.Marshal(recordUpdate)
@@ -633,7 +777,11 @@ func DeleteDocument(accountPublicID, key, documentName string) ([]string, []stri
 		return nil, "", err
 	}
 
-	response, updatedRecord, err := persAccntsChannelClient.UpdateRecords("updateDocumentRecords", []string{accountPublicID, "", string(encrRecord)})
+	var response *persaccntschannel.TxResult
+	err = writeAccountRecord(accountPublicID, recordUpdate, func() (err error) {
+		response, err = persAccntsChannelClient.UpdateDocumentRecords(ledgerCtx, accountPublicID, encrRecord)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -644,7 +792,10 @@ func DeleteDocument(accountPublicID, key, documentName string) ([]string, []stri
 	for _, version := range documentToDelete.IpfsDocumentVersionsData {
 		ipfs.DeleteDocumentObjectFromIpfs(version.IpfsData)
 
-		ipfsTempDocumentPath := filepath.Join(personAccountsIpfsTempPath, accountPublicID, documentName)
+		ipfsTempDocumentPath, err := ipfs.DocumentIpfsTempPath(ipfs.PersonAccountsGroup, accountPublicID, documentName)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		if err = crypto.DeleteCipherKeyFile(ipfsTempDocumentPath, strconv.Itoa(version.Name)); err != nil {
 			return nil, nil, err
@@ -656,15 +807,14 @@ func DeleteDocument(accountPublicID, key, documentName string) ([]string, []stri
 	}
 
 	// delete folder from ipfs temp directory
-	documentIpfsTempPath := filepath.Join(personAccountsIpfsTempPath)
-	if _, err = ipfs.DeleteDocumentIpfsTempDirectory(documentIpfsTempPath, accountPublicId, documentName); err != nil {
+	if _, err = ipfs.DeleteDocumentIpfsTempDirectory(ipfs.PersonAccountsGroup, accountPublicID, documentName); err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(updatedRecord)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
-func DeleteDocumentVersion(accountPublicId, key, documentName string, documentVersion int) ([]string, []string, error) {
+func (service *Service) DeleteDocumentVersion(ctx context.Context, accountPublicId, key, documentName string, documentVersion int) ([]string, *persaccntschannel.TxResult, error) {
 
 	if accountPublicId == "" {
 		return nil, nil, errors.New("Account Id value cannot be an empty string")
@@ -684,8 +834,15 @@ func DeleteDocumentVersion(accountPublicId, key, documentName string, documentVe
 
 	documentName = strings.ToLower(documentName)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountRecords, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountPublicId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicId)
+	defer cancel()
+
+	accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicId)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -714,6 +871,7 @@ func DeleteDocumentVersion(accountPublicId, key, documentName string, documentVe
 
 	documentVersionToDelete := documents[documentName].IpfsDocumentVersionsData[documentVersion]
 
+	removeIpfsVersionLinkName(recordUpdate, documentName, documentVersion)
 	delete(recordUpdate.Documents[documentName].IpfsDocumentVersionsData, documentVersion)
 	recordUpdate.Documents[documentName].UpdatedAt = getTime()
 
@@ -727,7 +885,11 @@ func DeleteDocumentVersion(accountPublicId, key, documentName string, documentVe
 		return nil, "", err
 	}
 
-	response, updatedRecord, err := persAccntsChannelClient.UpdateRecords("updateDocumentRecords", []string{accountPublicId, "", string(encrRecord)})
+	var response *persaccntschannel.TxResult
+	err = writeAccountRecord(accountPublicId, recordUpdate, func() (err error) {
+		response, err = persAccntsChannelClient.UpdateDocumentRecords(ledgerCtx, accountPublicId, encrRecord)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -736,12 +898,16 @@ func DeleteDocumentVersion(accountPublicId, key, documentName string, documentVe
 	ipfs.DeleteDocumentObjectFromIpfs(documentVersionToDelete.IpfsData)
 
 	// delete cipher key
-	ipfsTempDocumentPath := filepath.Join(personAccountsIpfsTempPath, accountPublicId, documentName)
+	ipfsTempDocumentPath, err := ipfs.DocumentIpfsTempPath(ipfs.PersonAccountsGroup, accountPublicId, documentName)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if err = crypto.DeleteCipherKeyFile(ipfsTempDocumentPath, strconv.Itoa(documentVersion)); err != nil {
 		return nil, nil, err
 	}
 
-	return []string{string(updatedRecord)}, response, nil
+	return []string{string(response.Payload)}, response, nil
 }
 
 //
@@ -750,7 +916,7 @@ func createNewDocumentVersion(newVersion int, filename, accountPublicId, documen
 	nextDocumentVersionString := strconv.Itoa(newVersion)
 
 	// get Ipfs temporary document directory
-	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(personAccountsIpfsTempPath, accountPublicId, documentName)
+	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(ipfs.PersonAccountsGroup, accountPublicId, documentName)
 	if err != nil {
 		return nil, "", err
 	}
@@ -759,7 +925,13 @@ func createNewDocumentVersion(newVersion int, filename, accountPublicId, documen
 	// temporary solution for the current implementation - keys are supposed to be
 	// sent to the client
 	rsaPath := filepath.Join(ipfsTempDocumentPath, nextDocumentVersionString, "rsa")
-	rsaLink, err := crypto.GenerateRSAKeyPair(rsaPath)
+	rsaKeyPair, err := crypto.GenerateRSAKeyPair()
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	// written through the temporary store so the account quota applies
+	rsaLink, err := ipfs.SaveDocumentKeyFile(ipfs.PersonAccountsGroup, accountPublicId, documentName, rsaKeyPair, nextDocumentVersionString, "rsa", crypto.RSAKeyFile)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
@@ -768,20 +940,31 @@ func createNewDocumentVersion(newVersion int, filename, accountPublicId, documen
 	// create 32-bit key for the document data encryption and encrypt the document data with it 
 	// return signed key with the public key
 	key := crypto.Key32byt()
-	encryptedDocument, cipherKey, err := crypto.EncryptDocument(filename, key, rsaPath, rsaLink)
+	encryptedDocument, cipherKey, err := crypto.EncryptDocument(filename, key, rsaPath, rsaLink, crypto.DefaultPaddingPolicy)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
 
 	// save encrypted cipher key to a file - temporary solution
 	// /ipfs/accountID/documentName/version/cipher
-	if err = crypto.SaveCipherKey(cipherKey, ipfsTempDocumentPath, newDocumentVersionString); err != nil {
+	if _, err = ipfs.SaveDocumentKeyFile(ipfs.PersonAccountsGroup, accountPublicId, documentName, cipherKey, newDocumentVersionString, crypto.CipherKeyFile); err != nil {
 		return nil, nil, "", "", err
 	}
 
-	documentReference := filepath.Join(documentName, nextDocumentVersionString)
+	// link names are derived - the mapping is kept in the encrypted record
+	documentLinkName, err := ipfs.DocumentLinkName(accountPublicId, documentName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	versionLinkName, err := ipfs.DocumentVersionLinkName(accountPublicId, documentName, nextDocumentVersionString)
+	if err != nil {
+		return nil, "", err
+	}
+
+	documentReference := filepath.Join(documentLinkName, versionLinkName)
 
-	documentVersionIpfsData, updatedDirectoryLinks, err := ipfs.UploadFileToIpfs(encryptedDocument, nextDocumentVersionString, documentReference, parentDirHash, parentDirObjectLinkHash)
+	documentVersionIpfsData, updatedDirectoryLinks, err := ipfs.UploadFileToIpfs(encryptedDocument, versionLinkName, documentReference, parentDirHash, parentDirObjectLinkHash)
 	if err != nil {
 		return nil, "", err
 	}
@@ -791,6 +974,7 @@ func createNewDocumentVersion(newVersion int, filename, accountPublicId, documen
 		Id:        bson.NewObjectId().Hex(),
 		Name:      newVersion,
 		IpfsData:  documentVersionIpfsData,
+		Padding:   crypto.DefaultPaddingPolicy,
 		CreatedAt: getTime(),
 	}
 
@@ -799,18 +983,29 @@ func createNewDocumentVersion(newVersion int, filename, accountPublicId, documen
 
 func createFirstDocumentVersion(filename, documentName, accountPublicId, accountHash, accountObjectLinkHash string) (*ipfs.IpfsDirectoryData, *documentVersion, string, string, error) {
 
+	// create document first version
+	newDocumentVersionString := strconv.Itoa(1)
+
+	// link names are derived - the mapping is kept in the encrypted record
+	documentLinkName, err := ipfs.DocumentLinkName(accountPublicId, documentName)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	versionLinkName, err := ipfs.DocumentVersionLinkName(accountPublicId, documentName, newDocumentVersionString)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
 	// create new document directory in Ipfs network
 	directoryName := documentName
-	documentDirIpfsData, updatedAccountIpfsLinks, _, err := ipfs.CreateIpfsDocumentDirectory(directoryName, accountHash, accountObjectLinkHash)
+	documentDirIpfsData, updatedAccountIpfsLinks, _, err := ipfs.CreateIpfsDocumentDirectory(documentLinkName, accountHash, accountObjectLinkHash)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
 
-	// create document first version
-	newDocumentVersionString := strconv.Itoa(1)
-
 	// create Ipfs temporary document directory for saving rsa data on the server
-	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(personAccountsIpfsTempPath, accountPublicId, directoryName)
+	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(ipfs.PersonAccountsGroup, accountPublicId, directoryName)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
@@ -819,7 +1014,13 @@ func createFirstDocumentVersion(filename, documentName, accountPublicId, account
 	// temporary solution for the current implementation - keys are supposed to be
 	// sent to the client
 	rsaPath := filepath.Join(ipfsTempDocumentPath, newDocumentVersionString, "rsa")
-	rsaLink, err := crypto.GenerateRSAKeyPair(rsaPath)
+	rsaKeyPair, err := crypto.GenerateRSAKeyPair()
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	// written through the temporary store so the account quota applies
+	rsaLink, err := ipfs.SaveDocumentKeyFile(ipfs.PersonAccountsGroup, accountPublicId, directoryName, rsaKeyPair, newDocumentVersionString, "rsa", crypto.RSAKeyFile)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
@@ -828,19 +1029,19 @@ func createFirstDocumentVersion(filename, documentName, accountPublicId, account
 	// documentName is used as a passphrase for the document encryption
 	// rsaPath - filename of rsa keys
 	key := crypto.Key32byt(documentName)
-	encryptedDocument, cipherKey, err := crypto.EncryptDocument(filename, key, rsaPath, rsaLink)
+	encryptedDocument, cipherKey, err := crypto.EncryptDocument(filename, key, rsaPath, rsaLink, crypto.DefaultPaddingPolicy)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
 
 	// save cipherKey
 	// save encrypted cipher key to a file - temporary solution
-	if err = crypto.SaveCipherKey(cipherKey, ipfsTempDocumentPath, newDocumentVersionString); err != nil {
+	if _, err = ipfs.SaveDocumentKeyFile(ipfs.PersonAccountsGroup, accountPublicId, directoryName, cipherKey, newDocumentVersionString, crypto.CipherKeyFile); err != nil {
 		return nil, nil, "", "", err
 	}
 
-	documentReference := filepath.Join(documentName, newDocumentVersionString)
-	documentVersionIpfsData, updatedDirectoryIpfsLinks, err := ipfs.UploadFileToIpfs(encryptedDocument, newDocumentVersionString, documentReference, documentDirIpfsData.ObjectHash, documentDirIpfsData.LinkObjectHash)
+	documentReference := filepath.Join(documentLinkName, versionLinkName)
+	documentVersionIpfsData, updatedDirectoryIpfsLinks, err := ipfs.UploadFileToIpfs(encryptedDocument, versionLinkName, documentReference, documentDirIpfsData.ObjectHash, documentDirIpfsData.LinkObjectHash)
 
 	if err != nil {
 		return nil, nil, "", "", err
@@ -851,6 +1052,7 @@ func createFirstDocumentVersion(filename, documentName, accountPublicId, account
 		Id:        bson.NewObjectId().Hex(),
 		Name:      1,
 		IpfsData:  documentVersionIpfsData,
+		Padding:   crypto.DefaultPaddingPolicy,
 		CreatedAt: getTime(),
 	}
 
@@ -1,18 +1,19 @@
 package person
 
 import (
-	"cerberus/blockchain/persaccntschannel"
 	"cerberus/services/crypto"
 	"cerberus/services/ipfs"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strconv"
 	"strings"
 )
 
-func GetAccountById(accountId, key string) (string, error) {
+func (service *Service) GetAccountById(ctx context.Context, accountId, key string) (string, error) {
 
 	if accountId == "" {
 		return "", errors.New("Account Id value cannot be an empty string")
@@ -22,8 +23,15 @@ func GetAccountById(accountId, key string) (string, error) {
 		return "", errors.New(" Key value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountId)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountId)
 	if err != nil {
 		return "", err
 	}
@@ -43,7 +51,7 @@ func GetAccountById(accountId, key string) (string, error) {
 }
 
 // only for administration use
-func GetAccountsByEmail(email string) (string, error) {
+func (service *Service) GetAccountsByEmail(ctx context.Context, email string) (string, error) {
 
 	if email == "" {
 		return "", errors.New("Email value cannot be an empty string")
@@ -51,8 +59,15 @@ func GetAccountsByEmail(email string) (string, error) {
 
 	selectorKey := "email"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryRecords(selectorKey, email)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryRecords(ledgerCtx, selectorKey, email)
 	if err != nil {
 		return "", err
 	}
@@ -61,7 +76,7 @@ func GetAccountsByEmail(email string) (string, error) {
 }
 
 // only for administration use
-func GetAccountsByFirstName(firstName string) (string, error) {
+func (service *Service) GetAccountsByFirstName(ctx context.Context, firstName string) (string, error) {
 
 	if firstName == "" {
 		return "", errors.New("First name value cannot be an empty string")
@@ -69,8 +84,15 @@ func GetAccountsByFirstName(firstName string) (string, error) {
 
 	selectorKey := "firstName"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryRecords(selectorKey, firstName)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryRecords(ledgerCtx, selectorKey, firstName)
 	if err != nil {
 		return "", err
 	}
@@ -79,7 +101,7 @@ func GetAccountsByFirstName(firstName string) (string, error) {
 }
 
 // only for administration use
-func GetAccountsByLastName(lastName string) (string, error) {
+func (service *Service) GetAccountsByLastName(ctx context.Context, lastName string) (string, error) {
 
 	if lastName == "" {
 		return "", errors.New("Last name value cannot be an empty string")
@@ -87,8 +109,15 @@ func GetAccountsByLastName(lastName string) (string, error) {
 
 	selectorKey := "lastName"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryRecords(selectorKey, lastName)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryRecords(ledgerCtx, selectorKey, lastName)
 	if err != nil {
 		return "", err
 	}
@@ -96,7 +125,7 @@ func GetAccountsByLastName(lastName string) (string, error) {
 	return string(accountData), nil
 }
 
-func GetAccountHistory(accountId, key string) (string, error) {
+func (service *Service) GetAccountHistory(ctx context.Context, accountId, key string) (string, error) {
 
 	if accountId == "" {
 		return "", errors.New("Account Id value cannot be an empty string")
@@ -106,8 +135,15 @@ func GetAccountHistory(accountId, key string) (string, error) {
 		return "", errors.New(" Key value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryAccountData("getAccountHistory", accountId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountId)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountHistory", accountId)
 	if err != nil {
 		return "", err
 	}
@@ -134,7 +170,7 @@ func GetAccountHistory(accountId, key string) (string, error) {
 - firstName
 - lastName
 */
-func GetAccountsBySelector(selectorKey, selectorValue string) (string, error) {
+func (service *Service) GetAccountsBySelector(ctx context.Context, selectorKey, selectorValue string) (string, error) {
 
 	if selectorKey == "" {
 		return "", errors.New("Selector key value cannot be an empty string")
@@ -144,8 +180,15 @@ func GetAccountsBySelector(selectorKey, selectorValue string) (string, error) {
 		return "", errors.New("Selector value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryRecords(selectorKey, selectorValue)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryRecords(ledgerCtx, selectorKey, selectorValue)
 	if err != nil {
 		return "", err
 	}
@@ -153,7 +196,7 @@ func GetAccountsBySelector(selectorKey, selectorValue string) (string, error) {
 	return string(accountData), nil
 }
 
-func GetAccountDocument(accountId, key documentName string) (string, error) {
+func (service *Service) GetAccountDocument(ctx context.Context, accountId, key documentName string) (string, error) {
 
 	if accountId == "" {
 		return "", errors.New("Account Id value cannot be an empty string")
@@ -167,8 +210,15 @@ func GetAccountDocument(accountId, key documentName string) (string, error) {
 		return "", errors.New(" Key value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountId)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountId)
 	if err != nil {
 		return "", err
 	}
@@ -198,7 +248,10 @@ func GetAccountDocument(accountId, key documentName string) (string, error) {
 	return string(documentDataAsBytes), nil
 }
 
-func GetAccountDocumentVersion(accountId, key, documentName, documentVersion string) ([]string, error) {
+// GetAccountDocumentVersion returns the version record, with exportFile also the path of the decrypted document
+// exported to the temporary directory - the plaintext stays on disk until it is removed, prefer ExportAccountDocumentVersion
+// which writes the document to a writer and never to disk
+func (service *Service) GetAccountDocumentVersion(ctx context.Context, accountId, key, documentName, documentVersion string, exportFile bool) ([]string, error) {
 
 	if accountId == "" {
 		return nil, errors.New("Account Id value cannot be an empty string")
@@ -218,8 +271,15 @@ func GetAccountDocumentVersion(accountId, key, documentName, documentVersion str
 
 	documentName = strings.ToLower(documentName)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountId)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountId)
 	if err != nil {
 		return nil, err
 	}
@@ -251,8 +311,17 @@ func GetAccountDocumentVersion(accountId, key, documentName, documentVersion str
 		return nil, errors.New("Document version " + documentVersion + " for document " + documentName + " does not exist")
 	}
 
+	versionAsBytes, err := json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exportFile {
+		return []string{string(versionAsBytes)}, nil
+	}
+
 	// get content from ipfs
-	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(personAccountsIpfsTempPath, record.PublicId, documentName)
+	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(ipfs.PersonAccountsGroup, record.PublicId, documentName)
 	if err != nil {
 		return nil, err
 	}
@@ -267,12 +336,7 @@ func GetAccountDocumentVersion(accountId, key, documentName, documentVersion str
 		return nil, err
 	}
 
-	filename, err := ipfs.ExportFileFromIpfs(version.IpfsData.ObjectHash, strconv.Itoa(version.Name), ipfsTempDocumentPath, cipherKey)
-	if err != nil {
-		return nil, err
-	}
-
-	versionAsBytes, err := json.Marshal(version)
+	filename, err := ipfs.ExportFileFromIpfs(version.IpfsData.ObjectHash, version.IpfsData.Reference, strconv.Itoa(version.Name), ipfsTempDocumentPath, cipherKey, version.Padding)
 	if err != nil {
 		return nil, err
 	}
@@ -280,7 +344,8 @@ func GetAccountDocumentVersion(accountId, key, documentName, documentVersion str
 	return []string{string(versionAsBytes), filename}, nil
 }
 
-func GetAccountDocumentVersions(accountId, key documentName string) ([]string, error) {
+// GetAccountDocumentVersions returns the version records of the document, the content is read through ExportAccountDocumentVersion
+func (service *Service) GetAccountDocumentVersions(ctx context.Context, accountId, key documentName string) ([]string, error) {
 
 	if accountId == "" {
 		return nil, errors.New("Account Id value cannot be an empty string")
@@ -296,8 +361,15 @@ func GetAccountDocumentVersions(accountId, key documentName string) ([]string, e
 
 	documentName = strings.ToLower(documentName)
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	accountData, err := persAccntsChannelClient.QueryAccountData("getAccountRecords", accountId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountId)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountId)
 	if err != nil {
 		return nil, err
 	}
@@ -317,27 +389,8 @@ func GetAccountDocumentVersions(accountId, key documentName string) ([]string, e
 		return nil, errors.New("Document with name " + documentName + " does not exist")
 	}
 
-	// get content from ipfs
-	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(personAccountsIpfsTempPath, record.PublicId, documentName)
-	if err != nil {
-		return nil, err
-	}
-
 	var versions []string
-	for versionNumber, version := range record.Documents[documentName].IpfsDocumentVersionsData {
-
-		// get cipher key
-		cipherKeyPath := filepath.Join(ipfsTempDocumentPath, strconv.Itoa(versionNumber), "cipher")
-
-		cipherKey, err := crypto.ReadCipherKey(cipherKeyPath)
-		if err != nil {
-			return nil, err
-		}
-
-		_, err = ipfs.ExportFileFromIpfs(version.IpfsData.ObjectHash, strconv.Itoa(version.Name), ipfsTempDocumentPath, cipherKey)
-		if err != nil {
-			return nil, err
-		}
+	for _, version := range record.Documents[documentName].IpfsDocumentVersionsData {
 
 		versionAsBytes, err := json.Marshal(version)
 		if err != nil {
@@ -349,3 +402,81 @@ func GetAccountDocumentVersions(accountId, key documentName string) ([]string, e
 
 	return versions, nil
 }
+
+// ExportAccountDocumentVersion writes the decrypted document version to w - an http response for example
+// the decrypted document is never written to disk
+func (service *Service) ExportAccountDocumentVersion(ctx context.Context, accountId, key, documentName, documentVersion string, w io.Writer) error {
+
+	if accountId == "" {
+		return errors.New("Account Id value cannot be an empty string")
+	}
+
+	if documentName == "" {
+		return errors.New("Document name value cannot be an empty value")
+	}
+
+	if documentVersion == "" {
+		return errors.New("Document version value cannot be an empty string")
+	}
+
+	if key == "" {
+		return errors.New(" Key value cannot be an empty string")
+	}
+
+	documentName = strings.ToLower(documentName)
+
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, accountId)
+	defer cancel()
+
+	accountData, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountId)
+	if err != nil {
+		return err
+	}
+
+	// Decrypt account data from the Database using the account key
+	decrRecord, err := crypto.DecrAESGCM([]byte(accountData), []byte(key))
+	if err != nil {
+		return err
+	}
+
+	record := &personAccount{}
+	if err = json.Unmarshal(decrRecord, record); err != nil {
+		return err
+	}
+
+	if _, ok := record.Documents[documentName]; !ok {
+		return errors.New("Document with name " + documentName + " does not exist")
+	}
+
+	ver, err := strconv.Atoi(documentVersion)
+	if err != nil {
+		return err
+	}
+
+	version, ok := record.Documents[documentName].IpfsDocumentVersionsData[ver]
+	if !ok {
+		return errors.New("Document version " + documentVersion + " for document " + documentName + " does not exist")
+	}
+
+	ipfsTempDocumentPath, err := ipfs.GetDocumentIpfsTempDirectory(ipfs.PersonAccountsGroup, record.PublicId, documentName)
+	if err != nil {
+		return err
+	}
+
+	// get cipher key
+	cipherKeyPath := filepath.Join(ipfsTempDocumentPath, strconv.Itoa(version.Name))
+
+	cipherKey, err := crypto.ReadCipherKey(cipherKeyPath)
+	if err != nil {
+		return err
+	}
+
+	rsaKeyFile := filepath.Join(ipfsTempDocumentPath, strconv.Itoa(version.Name), "rsa", "rsa_key.pem")
+
+	return ipfs.ExportFileToWriter(version.IpfsData.ObjectHash, version.IpfsData.Reference, rsaKeyFile, cipherKey, version.Padding, w)
+}
@@ -0,0 +1,78 @@
+package person
+
+import (
+	"cerberus/services/ipfs"
+	"context"
+	"errors"
+	"io"
+)
+
+// ExportAccountCAR writes the account ipfs subtree as a CAR archive for backup or migration
+func (service *Service) ExportAccountCAR(ctx context.Context, accountPublicID, key string, w io.Writer) (string, error) {
+
+	if accountPublicID == "" {
+		return "", errors.New("Account Public ID cannot be an empty string")
+	}
+
+	if key == "" {
+		return "", errors.New("Key value cannot be an empty string")
+	}
+
+	return ipfs.ExportAccountCAR(accountPublicID, service.accountTreeResolver(ctx, key), w)
+}
+
+// ImportAccountCAR imports an archive created by ExportAccountCAR into the local ipfs node
+// every archived object must match the account record on the ledger
+func (service *Service) ImportAccountCAR(ctx context.Context, key string, reader io.Reader) (string, error) {
+
+	if key == "" {
+		return "", errors.New("Key value cannot be an empty string")
+	}
+
+	archive, err := ipfs.ImportAccountCAR(reader)
+	if err != nil {
+		return "", err
+	}
+
+	tree, err := service.accountTreeResolver(ctx, key)(archive.PublicID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = archive.Verify(tree); err != nil {
+		return "", err
+	}
+
+	return archive.Root, nil
+}
+
+func (service *Service) accountTreeResolver(ctx context.Context, key string) ipfs.AccountTreeResolver {
+
+	return func(accountPublicID string) (*ipfs.AccountTree, error) {
+
+		persAccntsChannelClient, err := service.ledgerClient()
+		if err != nil {
+			return nil, err
+		}
+
+		ledgerCtx, cancel := ledgerContext(ctx)
+		defer cancel()
+
+		accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
+		if err != nil {
+			return nil, err
+		}
+
+		if accountRecords == "" {
+			return nil, errors.New("Account with public ID " + accountPublicID + " does not exist")
+		}
+
+		// Decrypt account data from the Database using the account key
+		record, err := decryptAccountRecord([]byte(accountRecords), key)
+		if err != nil {
+			return nil, err
+		}
+
+		return getAccountTree(record), nil
+	}
+}
@@ -0,0 +1,178 @@
+package person
+
+import (
+	"cerberus/blockchain/persaccntschannel"
+	"cerberus/config"
+	"cerberus/services/ipfs"
+	"context"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// one person accounts channel client is shared by every call
+// the sdk stays open until CloseLedgerClient
+var ledgerClientMutex sync.Mutex
+var ledgerClient *persaccntschannel.CerberusClient
+var ledgerConfig = persaccntschannel.DefaultConfig()
+
+// every ledger call is abandoned after this period
+var ledgerRequestTimeout = 30 * time.Second
+
+// Configure applies the loaded configuration to the ledger client and ipfs - call it once on startup
+func Configure(cfg *config.Config) error {
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	err := ipfs.Configure(ipfs.Settings{
+		ApiEndpoint:       cfg.Ipfs.ApiEndpoint,
+		Replicas:          cfg.Ipfs.Replicas,
+		Cluster:           cfg.Ipfs.Cluster,
+		ReplicationQuorum: cfg.Ipfs.ReplicationQuorum,
+		LinkKey:           []byte(cfg.Ipfs.LinkKey),
+		ContentCacheBytes: cfg.Ipfs.ContentCacheBytes,
+		ContentCacheTTL:   cfg.Ipfs.ContentCacheTTL.Duration(),
+		TempRoot:          cfg.Storage.TempRoot,
+		TempStoreOptions: ipfs.TempStoreOptions{
+			DefaultTTL:    cfg.Storage.TempTTL.Duration(),
+			SweepInterval: cfg.Storage.TempSweepInterval.Duration(),
+			AccountQuota:  cfg.Storage.TempAccountQuota,
+		},
+		UploadJournalPath:           cfg.Storage.UploadJournalPath,
+		GarbageCollectorJournalPath: cfg.Storage.GarbageCollectorJournalPath,
+		RootJournalPath:             cfg.Storage.RootJournalPath,
+		ReferenceIndexPath:          cfg.Storage.ReferenceIndexPath,
+		ReplicationJournalPath:      cfg.Storage.ReplicationJournalPath,
+	})
+	if err != nil {
+		return err
+	}
+
+	// optional - publish the group roots under keys from the local node keystore
+	defaultService.rootDirectories.PublishWithKey(ipfs.PersonAccountsGroup, cfg.Ipfs.PersonAccountsIpnsKey)
+	defaultService.rootDirectories.PublishWithKey(ipfs.InstitutionAccountsGroup, cfg.Ipfs.InstitutionAccountsIpnsKey)
+
+	// account identities -> wallet and CA
+	var wallet persaccntschannel.Wallet
+	var certificateAuthority persaccntschannel.CertificateAuthority
+
+	if cfg.Blockchain.Identities.Enabled {
+		if cfg.Blockchain.Identities.WalletPath != "" {
+			if wallet, err = persaccntschannel.NewFileWallet(cfg.Blockchain.Identities.WalletPath); err != nil {
+				return err
+			}
+		}
+
+		if cfg.Blockchain.Identities.CA == "standIn" {
+			if certificateAuthority, err = newStandInCA(cfg.Blockchain.Identities); err != nil {
+				return err
+			}
+		}
+	}
+
+	ledgerClientMutex.Lock()
+	defer ledgerClientMutex.Unlock()
+
+	// the next call opens a client with the new configuration
+	if ledgerClient != nil {
+		ledgerClient.Close()
+		ledgerClient = nil
+	}
+
+	ledgerConfig = persaccntschannel.Config{
+		ConfigFile:   cfg.Blockchain.SdkConfigFile,
+		ChannelID:    cfg.Blockchain.ChannelID,
+		ChaincodeID:  cfg.Blockchain.ChaincodeID,
+		Org:          cfg.Blockchain.Org,
+		User:         cfg.Blockchain.User,
+		Discovery:    cfg.Blockchain.Discovery,
+		Peers:        cfg.Blockchain.Peers,
+		QueryPeers:   cfg.Blockchain.QueryPeers,
+		PrivatePeers: cfg.Blockchain.PrivatePeers,
+		Retry: persaccntschannel.RetryPolicy{
+			Attempts:       cfg.Blockchain.Retry.Attempts,
+			InitialBackoff: cfg.Blockchain.Retry.InitialBackoff.Duration(),
+			MaxBackoff:     cfg.Blockchain.Retry.MaxBackoff.Duration(),
+			BackoffFactor:  cfg.Blockchain.Retry.BackoffFactor,
+		},
+		AccountIdentities:    cfg.Blockchain.Identities.Enabled,
+		CAName:               cfg.Blockchain.Identities.CAName,
+		Affiliation:          cfg.Blockchain.Identities.Affiliation,
+		CertificateAuthority: certificateAuthority,
+		Wallet:               wallet,
+	}
+
+	ledgerRequestTimeout = cfg.Blockchain.RequestTimeout.Duration()
+
+	return nil
+}
+
+// newStandInCA loads the CA key pair of the org the stand-in CA issues the account identities from
+func newStandInCA(identities config.IdentitiesConfig) (*persaccntschannel.StandInCA, error) {
+
+	certificatePEM, err := ioutil.ReadFile(identities.StandInCACert)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := ioutil.ReadFile(identities.StandInCAKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return persaccntschannel.NewStandInCA(identities.MSPID, certificatePEM, keyPEM)
+}
+
+// getLedgerClient returns the shared client, the client is created on first use
+// a failed connection is retried on the next call
+func getLedgerClient() (*persaccntschannel.CerberusClient, error) {
+
+	ledgerClientMutex.Lock()
+	defer ledgerClientMutex.Unlock()
+
+	if ledgerClient != nil {
+		return ledgerClient, nil
+	}
+
+	client, err := persaccntschannel.New(ledgerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerClient = client
+
+	return ledgerClient, nil
+}
+
+// CloseLedgerClient releases the shared client - call it on shutdown
+func CloseLedgerClient() {
+
+	ledgerClientMutex.Lock()
+	defer ledgerClientMutex.Unlock()
+
+	if ledgerClient != nil {
+		ledgerClient.Close()
+		ledgerClient = nil
+	}
+}
+
+// ledgerContext returns the context for one ledger call of the caller's request
+// the call ends at the request timeout or when ctx is done, whichever comes first
+func ledgerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+
+	ledgerClientMutex.Lock()
+	timeout := ledgerRequestTimeout
+	ledgerClientMutex.Unlock()
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// accountLedgerContext returns the context for one ledger call signed by the account
+func accountLedgerContext(ctx context.Context, accountPublicID string) (context.Context, context.CancelFunc) {
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+
+	return persaccntschannel.WithActingAccount(ledgerCtx, accountPublicID), cancel
+}
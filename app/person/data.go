@@ -1,8 +1,8 @@
 package person
 
 import (
+	"cerberus/services/crypto"
 	"cerberus/services/ipfs"
-	"os"
 )
 
 type documentShareableData struct {
@@ -33,6 +33,7 @@ type accountDataRequest struct {
 	CreatedAt         string            `json:"createdAt"`
 	UpdatedAt         string            `json:"updatedAt"`
 	Status            string            `json:"status"`
+	ExpiresAt         string            `json:"expiresAt,omitempty"`
 }
 
 type documentDataRequest struct {
@@ -48,12 +49,14 @@ type documentDataRequest struct {
 	CreatedAt         string            `json:"createdAt"`
 	UpdatedAt         string            `json:"updatedAt"`
 	Status            string            `json:"status"`
+	ExpiresAt         string            `json:"expiresAt,omitempty"`
 }
 
 type documentVersion struct {
 	Id        string                        `json:"id"`
 	Name      int                           `json:"name"`
 	IpfsData  *ipfs.IpfsDocumentVersionData `json:"ipfsData"`
+	Padding   crypto.PaddingPolicy          `json:"padding"`
 	CreatedAt string                        `json:"createdAt"`
 	UpdateAt  string                        `json:"updatedAt"`
 }
@@ -92,7 +95,8 @@ type personAccount struct {
 	AccountData     *accountData                  `json:"accountData"`
 	IpfsAccountData *ipfs.IpfsDirectoryData       `json:"ipfsAccountData"`
 	Documents       map[string]*documentDirectory `json:"documents"`
-}
 
-var personAccountsIpfsHash = "QmNMEveW869ERwNWCk4YSmuU5bR3j4AonmYoXaruTD51rA"
-var personAccountsIpfsTempPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/personAccounts"
+	// derived ipfs link name -> documentName or documentName/version
+	// kept only inside the encrypted record
+	IpfsLinkNames map[string]string `json:"ipfsLinkNames"`
+}
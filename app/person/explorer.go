@@ -0,0 +1,38 @@
+package person
+
+import (
+	"cerberus/blockchain/persaccntschannel"
+	"context"
+	"errors"
+)
+
+// LedgerExplorer is implemented by ledgers which keep blocks with signed transactions
+type LedgerExplorer interface {
+	GetTransactionProof(ctx context.Context, txID string) (*persaccntschannel.TransactionProof, error)
+}
+
+var _ LedgerExplorer = (*persaccntschannel.CerberusClient)(nil)
+
+// GetTransactionProof shows when and in which block a transaction - e.g. an accepted request - was committed,
+// who submitted it and which peers endorsed it
+func (service *Service) GetTransactionProof(ctx context.Context, txID string) (*persaccntschannel.TransactionProof, error) {
+
+	if txID == "" {
+		return nil, errors.New("Transaction ID cannot be an empty string")
+	}
+
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	explorer, ok := persAccntsChannelClient.(LedgerExplorer)
+	if !ok {
+		return nil, errors.New("Ledger does not keep transaction proofs")
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	return explorer.GetTransactionProof(ledgerCtx, txID)
+}
@@ -0,0 +1,181 @@
+package person
+
+import (
+	"cerberus/blockchain/persaccntschannel"
+	"cerberus/services/crypto"
+	"cerberus/services/ipfs"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AccountKeys returns the key of an account record - the key CreateAccount returned for the account
+type AccountKeys func(accountPublicID string) (string, error)
+
+// CollectIpfsGarbage removes pinned content which this deployment added and no account record of its org points to
+// content left behind by failed account, document or version creation is removed this way
+// the references come from the reference index the account operations keep, no account record is read -
+// while an account update is unconfirmed it fails with ipfs.ErrReferenceIndexIncomplete, ResolveIpfsReferences settles it
+func (service *Service) CollectIpfsGarbage(ctx context.Context, options *ipfs.GarbageCollectorOptions) (*ipfs.GarbageCollectionReport, error) {
+
+	if options == nil {
+		options = ipfs.NewGarbageCollectorOptions()
+	}
+
+	roots, err := service.getGroupRoots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	options.ProtectedObjects = append(options.ProtectedObjects, roots...)
+
+	return ipfs.CollectGarbage(options)
+}
+
+// ResolveIpfsReferences reads the records of the accounts with unconfirmed updates from the ledger
+// and records their references in the index, only these accounts - all of them accounts of this org - need a key
+func (service *Service) ResolveIpfsReferences(ctx context.Context, keys AccountKeys) error {
+
+	if keys == nil {
+		return errors.New("Account keys are required to read the account records")
+	}
+
+	pending, err := ipfs.PendingAccountReferences()
+	if err != nil {
+		return err
+	}
+
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return err
+	}
+
+	for _, accountPublicID := range pending {
+
+		ledgerCtx, cancel := accountLedgerContext(ctx, accountPublicID)
+		accountRecords, err := persAccntsChannelClient.QueryAccountData(ledgerCtx, "getAccountRecords", accountPublicID)
+		cancel()
+
+		if errors.Is(err, persaccntschannel.ErrNotFound) {
+			if err = ipfs.ResolvePendingAccount(accountPublicID, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		record, err := readAccountRecord(accountPublicID, []byte(accountRecords), keys)
+		if err != nil {
+			return errors.New("Account record " + accountPublicID + " cannot be read: " + err.Error())
+		}
+
+		if err = ipfs.ResolvePendingAccount(accountPublicID, accountReferenceTree(accountPublicID, record)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeAccountRecord runs the ledger write of the account record between the reference index updates
+// the account stays pending unless the write is committed or the chaincode refused it -
+// a write which failed otherwise may have reached the ledger
+func writeAccountRecord(accountPublicID string, record *personAccount, write func() error) error {
+
+	if err := ipfs.BeginAccountReferences(accountPublicID); err != nil {
+		return err
+	}
+
+	if err := write(); err != nil {
+		chaincodeErr := &persaccntschannel.ChaincodeError{}
+		if errors.As(err, &chaincodeErr) {
+			if abortErr := ipfs.AbortAccountReferences(accountPublicID); abortErr != nil {
+				fmt.Println("Unable to abort the references of account " + accountPublicID + ": " + abortErr.Error())
+			}
+		}
+
+		return err
+	}
+
+	// the record is committed - a failed index update keeps the account pending until ResolveIpfsReferences
+	if err := ipfs.CommitAccountReferences(accountReferenceTree(accountPublicID, record)); err != nil {
+		fmt.Println("Unable to commit the references of account " + accountPublicID + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// accountReferenceTree returns the tree of the record under the public ID it is stored with
+func accountReferenceTree(accountPublicID string, record *personAccount) *ipfs.AccountTree {
+
+	tree := getAccountTree(record)
+	tree.PublicID = accountPublicID
+
+	return tree
+}
+
+func readAccountRecord(accountPublicID string, ciphertext []byte, keys AccountKeys) (*personAccount, error) {
+
+	key, err := keys(accountPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		return nil, errors.New("No key for account " + accountPublicID)
+	}
+
+	return decryptAccountRecord(ciphertext, key)
+}
+
+// decryptAccountRecord decrypts an account record read from the ledger with the account key
+func decryptAccountRecord(ciphertext []byte, key string) (*personAccount, error) {
+
+	decrRecord, err := crypto.DecrAESGCM(ciphertext, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &personAccount{}
+	if err = json.Unmarshal(decrRecord, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func getAccountTree(record *personAccount) *ipfs.AccountTree {
+
+	tree := &ipfs.AccountTree{
+		PublicID:  record.PublicId,
+		Account:   record.IpfsAccountData,
+		Documents: make(map[string]*ipfs.IpfsDirectoryData),
+	}
+
+	for documentName, document := range record.Documents {
+
+		if document == nil {
+			continue
+		}
+
+		// documents are keyed by their link name so archives do not reveal document names
+		linkName := documentName
+		if document.IpfsDocumentDirectoryData != nil && document.IpfsDocumentDirectoryData.Reference != "" {
+			linkName = document.IpfsDocumentDirectoryData.Reference
+		}
+
+		tree.Documents[linkName] = document.IpfsDocumentDirectoryData
+
+		for _, version := range document.IpfsDocumentVersionsData {
+			if version != nil {
+				tree.Versions = append(tree.Versions, version.IpfsData)
+			}
+		}
+	}
+
+	return tree
+}
@@ -0,0 +1,85 @@
+package person
+
+import (
+	"cerberus/blockchain/persaccntschannel"
+	"cerberus/services/ipfs"
+	"context"
+)
+
+// LedgerClient is the part of the person accounts channel used by the app layer
+// persaccntschannel.CerberusClient talks to Fabric, MemoryLedger runs the chaincode in memory
+type LedgerClient interface {
+	CreateAccount(ctx context.Context, publicID string, accountObject []byte) (*persaccntschannel.TxResult, error)
+	DeleteAccount(ctx context.Context, publicID string) (*persaccntschannel.TxResult, error)
+	UpdateAccount(ctx context.Context, publicID string, passphrase []byte, dataField, value string) (*persaccntschannel.TxResult, error)
+	UpdateDocumentRecords(ctx context.Context, publicID string, record []byte) (*persaccntschannel.TxResult, error)
+	UpdateRecords(ctx context.Context, updateType string, updateArgs []string) (*persaccntschannel.TxResult, error)
+
+	QueryRecords(ctx context.Context, selectorKey, selectorValue string) (string, error)
+	QueryAccounts(ctx context.Context, pageSize int, bookmark string) (string, error)
+	QueryAccountData(ctx context.Context, queryType, publicID string) (string, error)
+
+	CreateAccountDataRequest(ctx context.Context, newRequest, requestData []byte) (*persaccntschannel.TxResult, error)
+	CreateDocumentDataRequest(ctx context.Context, newRequest, requestData []byte) (*persaccntschannel.TxResult, error)
+	AcceptRequest(ctx context.Context, requestType, requestPublicID, recipientPublicID string, acceptedData []byte) (*persaccntschannel.TxResult, error)
+	RejectRequest(ctx context.Context, requestType, requestPublicID, recipientPublicID string) (*persaccntschannel.TxResult, error)
+	UpdateRequest(ctx context.Context, requestType, requestPublicID, requesterPublicID, recipientPublicID string, updatedData []byte) (*persaccntschannel.TxResult, error)
+	SweepExpiredRequests(ctx context.Context, maxRequests int) (*persaccntschannel.SweepResult, error)
+
+	QueryRequestData(ctx context.Context, idType, id string) (string, error)
+	QueryRequests(ctx context.Context, queryType, requestType, selectorKey, selectorValue string) (string, error)
+	QueryAcceptedData(ctx context.Context, requestID string) (string, error)
+
+	QueryRootDirectory(ctx context.Context, group string) (string, error)
+	UpdateRootDirectory(ctx context.Context, group, previousRoot, newRoot string) (*persaccntschannel.TxResult, error)
+
+	// operations of the batch in one transaction
+	ExecuteBatch(ctx context.Context, batch *persaccntschannel.Batch) (*persaccntschannel.BatchResult, error)
+}
+
+var _ LedgerClient = (*persaccntschannel.CerberusClient)(nil)
+var _ LedgerClient = (*MemoryLedger)(nil)
+
+// Service runs the account, document and request flows against one ledger
+type Service struct {
+	ledger LedgerClient
+
+	// group root directories are registered on the ledger and updated with every account
+	rootRegistry    *ledgerRootRegistry
+	rootDirectories *ipfs.RootDirectories
+}
+
+// NewService returns a service on the ledger, nil uses the shared Fabric client set up by Configure
+func NewService(ledger LedgerClient) *Service {
+
+	service := &Service{ledger: ledger}
+	service.rootRegistry = &ledgerRootRegistry{service: service}
+	service.rootDirectories = ipfs.NewRootDirectories(service.rootRegistry)
+
+	return service
+}
+
+// the service on the shared Fabric client - roots are published under IPNS keys set by Configure
+var defaultService = NewService(nil)
+
+// DefaultService returns the service on the shared Fabric client
+func DefaultService() *Service {
+
+	return defaultService
+}
+
+// ledgerClient returns the injected ledger or the shared Fabric client
+func (service *Service) ledgerClient() (LedgerClient, error) {
+
+	if service.ledger != nil {
+		return service.ledger, nil
+	}
+
+	client, err := getLedgerClient()
+	if err != nil {
+		// no typed nil in the interface
+		return nil, err
+	}
+
+	return client, nil
+}
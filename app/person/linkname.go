@@ -0,0 +1,55 @@
+package person
+
+import (
+	"cerberus/services/ipfs"
+	"strconv"
+)
+
+// documents and versions are linked in ipfs under derived names
+// the record keeps the way back to the plain names
+
+func addIpfsLinkNames(record *personAccount, documentName string, version int) error {
+
+	documentLinkName, err := ipfs.DocumentLinkName(record.PublicId, documentName)
+	if err != nil {
+		return err
+	}
+
+	versionLinkName, err := ipfs.DocumentVersionLinkName(record.PublicId, documentName, strconv.Itoa(version))
+	if err != nil {
+		return err
+	}
+
+	if record.IpfsLinkNames == nil {
+		record.IpfsLinkNames = make(map[string]string)
+	}
+
+	record.IpfsLinkNames[documentLinkName] = documentName
+	record.IpfsLinkNames[versionLinkName] = documentName + "/" + strconv.Itoa(version)
+
+	return nil
+}
+
+func removeIpfsVersionLinkName(record *personAccount, documentName string, version int) {
+
+	versionLinkName, err := ipfs.DocumentVersionLinkName(record.PublicId, documentName, strconv.Itoa(version))
+	if err != nil {
+		return
+	}
+
+	delete(record.IpfsLinkNames, versionLinkName)
+}
+
+func removeIpfsDocumentLinkNames(record *personAccount, documentName string) {
+
+	for version := range record.Documents[documentName].IpfsDocumentVersionsData {
+		removeIpfsVersionLinkName(record, documentName, version)
+	}
+
+	documentLinkName, err := ipfs.DocumentLinkName(record.PublicId, documentName)
+	if err != nil {
+		return
+	}
+
+	delete(record.IpfsLinkNames, documentLinkName)
+}
@@ -0,0 +1,432 @@
+package person
+
+import (
+	"cerberus/blockchain/persaccntschannel"
+	personcc "cerberus/chaincode/person"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// MSP of the memory peer - the chaincode keeps its account records in the collection of this org
+const memoryMSPID = persaccntschannel.SipherOrg + "MSP"
+
+// MemoryLedger runs the person accounts chaincode on the shim MockStub - for local runs without Fabric
+// callers sign with identities issued by a CA of the ledger, the acting account of the context or the service identity
+// the memory peer holds every private data collection, every invoke is committed in its own block
+type MemoryLedger struct {
+	mutex       sync.Mutex
+	chaincode   *personcc.CerberusPersonAccounts
+	stub        *shim.MockStub
+	history     map[string][]*queryresult.KeyModification
+	txNumber    uint64
+	blockNumber uint64
+
+	// acting account -> serialized identity, "" for the service identity
+	ca       *persaccntschannel.StandInCA
+	creators map[string][]byte
+}
+
+func NewMemoryLedger() (*MemoryLedger, error) {
+
+	certificatePEM, keyPEM, err := newMemoryCAKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := persaccntschannel.NewStandInCA(memoryMSPID, certificatePEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	chaincode := new(personcc.CerberusPersonAccounts)
+
+	return &MemoryLedger{
+		chaincode: chaincode,
+		stub:      shim.NewMockStub(persaccntschannel.PersonAccountsChannelChainCode, chaincode),
+		history:   make(map[string][]*queryresult.KeyModification),
+		ca:        ca,
+		creators:  make(map[string][]byte),
+	}, nil
+}
+
+// newMemoryCAKeyPair returns a self-signed CA the identities of the callers are issued from
+func newMemoryCAKeyPair() ([]byte, []byte, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca.memory.cerberus.dev", Organization: []string{persaccntschannel.SipherOrg}},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certificatePEM, keyPEM, nil
+}
+
+// creator returns the serialized identity of the acting account, the service identity without one
+func (ledger *MemoryLedger) creator(accountPublicID string) ([]byte, error) {
+
+	if creator, ok := ledger.creators[accountPublicID]; ok {
+		return creator, nil
+	}
+
+	enrollmentID := "service"
+	attributes := map[string]string{persaccntschannel.RoleAttribute: persaccntschannel.AdminRole}
+
+	if accountPublicID != "" {
+		enrollmentID = "account-" + accountPublicID
+		attributes = map[string]string{persaccntschannel.AccountAttribute: accountPublicID}
+	}
+
+	secret, err := ledger.ca.Register(enrollmentID, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ledger.ca.Enroll(enrollmentID, secret); err != nil {
+		return nil, err
+	}
+
+	identity, err := ledger.ca.SigningIdentity(enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := identity.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.creators[accountPublicID] = creator
+
+	return creator, nil
+}
+
+// newStub starts a transaction of the acting account of ctx
+func (ledger *MemoryLedger) newStub(ctx context.Context, transientMap map[string][]byte, function string, args []string) (*memoryStub, error) {
+
+	accountPublicID, _ := persaccntschannel.ActingAccount(ctx)
+
+	creator, err := ledger.creator(accountPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.txNumber++
+	hash := sha256.Sum256([]byte(strconv.FormatUint(ledger.txNumber, 10) + time.Now().UTC().Format(time.RFC3339Nano)))
+
+	stub := &memoryStub{
+		MockStub:      ledger.stub,
+		ledger:        ledger,
+		function:      function,
+		args:          args,
+		creator:       creator,
+		transient:     transientMap,
+		writes:        make(map[string][]byte),
+		privateWrites: make(map[string]map[string][]byte),
+	}
+
+	stub.MockTransactionStart(hex.EncodeToString(hash[:]))
+
+	return stub, nil
+}
+
+// invoke runs the chaincode function and commits its writes in a new block
+// a failed function commits nothing and returns a typed chaincode error
+func (ledger *MemoryLedger) invoke(ctx context.Context, transientMap map[string][]byte, function string, args ...string) (*persaccntschannel.TxResult, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ledger.mutex.Lock()
+	defer ledger.mutex.Unlock()
+
+	stub, err := ledger.newStub(ctx, transientMap, function, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stub.MockTransactionEnd(stub.TxID)
+
+	response := ledger.chaincode.Invoke(stub)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return nil, persaccntschannel.NewChaincodeError(response.Status, response.Message)
+	}
+
+	if err = stub.commit(); err != nil {
+		return nil, err
+	}
+
+	ledger.blockNumber++
+
+	return &persaccntschannel.TxResult{
+		TxID:            stub.TxID,
+		ChaincodeStatus: response.Status,
+		ValidationCode:  pb.TxValidationCode_VALID.String(),
+		BlockNumber:     ledger.blockNumber,
+		Payload:         response.Payload,
+	}, nil
+}
+
+// query runs the chaincode function without committing
+func (ledger *MemoryLedger) query(ctx context.Context, function string, args ...string) ([]byte, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ledger.mutex.Lock()
+	defer ledger.mutex.Unlock()
+
+	stub, err := ledger.newStub(ctx, nil, function, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stub.MockTransactionEnd(stub.TxID)
+
+	response := ledger.chaincode.Invoke(stub)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return nil, persaccntschannel.NewChaincodeError(response.Status, response.Message)
+	}
+
+	return response.Payload, nil
+}
+
+// LedgerClient -> memory ledger, the requests of persaccntschannel
+
+func (ledger *MemoryLedger) CreateAccount(ctx context.Context, publicID string, accountObject []byte) (*persaccntschannel.TxResult, error) {
+
+	transientMap := map[string][]byte{persaccntschannel.RecordTransientKey(publicID): accountObject}
+
+	return ledger.invoke(ctx, transientMap, "createAccount", publicID)
+}
+
+func (ledger *MemoryLedger) DeleteAccount(ctx context.Context, publicID string) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "deleteAccount", publicID)
+}
+
+func (ledger *MemoryLedger) UpdateAccount(ctx context.Context, publicID string, passphrase []byte, dataField, value string) (*persaccntschannel.TxResult, error) {
+
+	transientMap := map[string][]byte{
+		persaccntschannel.PassphraseTransientKey(publicID): passphrase,
+		persaccntschannel.ValueTransientKey(publicID):      []byte(value),
+	}
+
+	return ledger.invoke(ctx, transientMap, "updateRecords", "updateAccount", publicID, dataField)
+}
+
+func (ledger *MemoryLedger) UpdateDocumentRecords(ctx context.Context, publicID string, record []byte) (*persaccntschannel.TxResult, error) {
+
+	transientMap := map[string][]byte{persaccntschannel.RecordTransientKey(publicID): record}
+
+	return ledger.invoke(ctx, transientMap, "updateRecords", "updateDocumentRecords", publicID)
+}
+
+func (ledger *MemoryLedger) UpdateRecords(ctx context.Context, updateType string, updateArgs []string) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "updateRecords", append([]string{updateType}, updateArgs...)...)
+}
+
+func (ledger *MemoryLedger) ExecuteBatch(ctx context.Context, batch *persaccntschannel.Batch) (*persaccntschannel.BatchResult, error) {
+
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	operationsAsBytes, err := json.Marshal(batch.Operations())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ledger.invoke(ctx, batch.TransientMap(), "batchInvoke", string(operationsAsBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := persaccntschannel.ParseBatchResults(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &persaccntschannel.BatchResult{TxResult: result, Operations: results}, nil
+}
+
+func (ledger *MemoryLedger) QueryRecords(ctx context.Context, selectorKey, selectorValue string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryRecords", selectorKey, selectorValue)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No records with " + selectorKey + ":" + selectorValue + " exist.")
+		return "", nil
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) QueryAccounts(ctx context.Context, pageSize int, bookmark string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryAccounts", strconv.Itoa(pageSize), bookmark)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) QueryAccountData(ctx context.Context, queryType, publicID string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryAccountData", queryType, publicID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No records with id: " + publicID + " exist.")
+		return "", nil
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) CreateAccountDataRequest(ctx context.Context, newRequest, requestData []byte) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "createRequest", "accountData", string(newRequest), string(requestData))
+}
+
+func (ledger *MemoryLedger) CreateDocumentDataRequest(ctx context.Context, newRequest, requestData []byte) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "createRequest", "documentData", string(newRequest), string(requestData))
+}
+
+func (ledger *MemoryLedger) AcceptRequest(ctx context.Context, requestType, requestPublicID, recipientPublicID string, acceptedData []byte) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "acceptRequest", requestType, requestPublicID, recipientPublicID, string(acceptedData))
+}
+
+func (ledger *MemoryLedger) RejectRequest(ctx context.Context, requestType, requestPublicID, recipientPublicID string) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "rejectRequest", requestType, requestPublicID, recipientPublicID)
+}
+
+func (ledger *MemoryLedger) UpdateRequest(ctx context.Context, requestType, requestPublicID, requesterPublicID, recipientPublicID string, updatedData []byte) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "updateRequest", requestType, requestPublicID, requesterPublicID, recipientPublicID, string(updatedData))
+}
+
+func (ledger *MemoryLedger) SweepExpiredRequests(ctx context.Context, maxRequests int) (*persaccntschannel.SweepResult, error) {
+
+	var args []string
+	if maxRequests > 0 {
+		args = append(args, strconv.Itoa(maxRequests))
+	}
+
+	result, err := ledger.invoke(ctx, nil, "sweepExpiredRequests", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return persaccntschannel.ParseSweepResult(result)
+}
+
+func (ledger *MemoryLedger) QueryRequestData(ctx context.Context, idType, id string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryRequestData", idType, id)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No records with id: " + id + " exist.")
+		return "", nil
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) QueryRequests(ctx context.Context, queryType, requestType, selectorKey, selectorValue string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryRequests", queryType, requestType, selectorKey, selectorValue)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No records with " + selectorKey + ":" + selectorValue + " exist.")
+		return "", nil
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) QueryAcceptedData(ctx context.Context, requestID string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryAcceptedData", requestID)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) QueryRootDirectory(ctx context.Context, group string) (string, error) {
+
+	payload, err := ledger.query(ctx, "queryRootDirectory", group)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No root directory for group " + group + " exists.")
+		return "", nil
+	}
+
+	return string(payload), nil
+}
+
+func (ledger *MemoryLedger) UpdateRootDirectory(ctx context.Context, group, previousRoot, newRoot string) (*persaccntschannel.TxResult, error) {
+
+	return ledger.invoke(ctx, nil, "updateRootDirectory", group, previousRoot, newRoot)
+}
@@ -0,0 +1,505 @@
+package person
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// memoryStub runs one transaction of the chaincode on the MockStub of the memory ledger
+// writes are buffered until the chaincode succeeds - reads see the committed state, like the simulation on a peer
+// the parts the MockStub leaves out are added: the creator, the transient map, rich queries and the key history
+type memoryStub struct {
+	*shim.MockStub
+
+	ledger    *MemoryLedger
+	function  string
+	args      []string
+	creator   []byte
+	transient map[string][]byte
+
+	// key -> value, nil value deletes the key
+	writes     map[string][]byte
+	writeOrder []string
+
+	// collection -> key -> value, nil value deletes the key
+	privateWrites map[string]map[string][]byte
+}
+
+func (stub *memoryStub) GetArgs() [][]byte {
+
+	args := [][]byte{[]byte(stub.function)}
+	for _, arg := range stub.args {
+		args = append(args, []byte(arg))
+	}
+
+	return args
+}
+
+func (stub *memoryStub) GetStringArgs() []string {
+
+	return append([]string{stub.function}, stub.args...)
+}
+
+func (stub *memoryStub) GetFunctionAndParameters() (string, []string) {
+
+	return stub.function, append([]string(nil), stub.args...)
+}
+
+func (stub *memoryStub) GetCreator() ([]byte, error) {
+
+	return stub.creator, nil
+}
+
+func (stub *memoryStub) GetTransient() (map[string][]byte, error) {
+
+	return stub.transient, nil
+}
+
+func (stub *memoryStub) PutState(key string, value []byte) error {
+
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+
+	if value == nil {
+		value = []byte{}
+	}
+
+	stub.write(key, value)
+
+	return nil
+}
+
+func (stub *memoryStub) DelState(key string) error {
+
+	stub.write(key, nil)
+
+	return nil
+}
+
+func (stub *memoryStub) write(key string, value []byte) {
+
+	if _, ok := stub.writes[key]; !ok {
+		stub.writeOrder = append(stub.writeOrder, key)
+	}
+
+	stub.writes[key] = value
+}
+
+func (stub *memoryStub) PutPrivateData(collection string, key string, value []byte) error {
+
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	if value == nil {
+		value = []byte{}
+	}
+
+	stub.writePrivate(collection, key, value)
+
+	return nil
+}
+
+func (stub *memoryStub) DelPrivateData(collection string, key string) error {
+
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	stub.writePrivate(collection, key, nil)
+
+	return nil
+}
+
+func (stub *memoryStub) writePrivate(collection, key string, value []byte) {
+
+	if stub.privateWrites[collection] == nil {
+		stub.privateWrites[collection] = make(map[string][]byte)
+	}
+
+	stub.privateWrites[collection][key] = value
+}
+
+// SetEvent - the memory ledger does not deliver events
+func (stub *memoryStub) SetEvent(name string, payload []byte) error {
+
+	if name == "" {
+		return errors.New("event name can not be nil string")
+	}
+
+	return nil
+}
+
+func (stub *memoryStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+
+	results, _, err := queryRecords(stub.Name, stub.State, query, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryStateIterator{results: results}, nil
+}
+
+// GetQueryResultWithPagination - the bookmark is the key of the last returned record
+func (stub *memoryStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+
+	results, lastKey, err := queryRecords(stub.Name, stub.State, query, int(pageSize), bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(results)), Bookmark: lastKey}
+
+	return &memoryStateIterator{results: results}, metadata, nil
+}
+
+func (stub *memoryStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+
+	results, _, err := queryRecords(stub.Name, stub.PvtState[collection], query, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryStateIterator{results: results}, nil
+}
+
+func (stub *memoryStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+
+	history := append([]*queryresult.KeyModification(nil), stub.ledger.history[key]...)
+
+	return &memoryHistoryIterator{history: history}, nil
+}
+
+// commit applies the buffered writes to the MockStub and adds them to the key history
+func (stub *memoryStub) commit() error {
+
+	for _, key := range stub.writeOrder {
+		value := stub.writes[key]
+
+		var err error
+		if value == nil {
+			err = stub.MockStub.DelState(key)
+		} else {
+			err = stub.MockStub.PutState(key, value)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		stub.ledger.history[key] = append(stub.ledger.history[key], &queryresult.KeyModification{
+			TxId:      stub.TxID,
+			Value:     value,
+			Timestamp: stub.TxTimestamp,
+			IsDelete:  value == nil,
+		})
+	}
+
+	for collection, writes := range stub.privateWrites {
+		if stub.PvtState[collection] == nil {
+			stub.PvtState[collection] = make(map[string][]byte)
+		}
+
+		for key, value := range writes {
+			if value == nil {
+				delete(stub.PvtState[collection], key)
+			} else {
+				stub.PvtState[collection][key] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+type memoryStateIterator struct {
+	results []*queryresult.KV
+	next    int
+}
+
+func (iterator *memoryStateIterator) HasNext() bool {
+
+	return iterator.next < len(iterator.results)
+}
+
+func (iterator *memoryStateIterator) Next() (*queryresult.KV, error) {
+
+	if !iterator.HasNext() {
+		return nil, errors.New("No more query results")
+	}
+
+	iterator.next++
+
+	return iterator.results[iterator.next-1], nil
+}
+
+func (iterator *memoryStateIterator) Close() error {
+
+	return nil
+}
+
+type memoryHistoryIterator struct {
+	history []*queryresult.KeyModification
+	next    int
+}
+
+func (iterator *memoryHistoryIterator) HasNext() bool {
+
+	return iterator.next < len(iterator.history)
+}
+
+func (iterator *memoryHistoryIterator) Next() (*queryresult.KeyModification, error) {
+
+	if !iterator.HasNext() {
+		return nil, errors.New("No more history entries")
+	}
+
+	iterator.next++
+
+	return iterator.history[iterator.next-1], nil
+}
+
+func (iterator *memoryHistoryIterator) Close() error {
+
+	return nil
+}
+
+// rich queries
+
+type memoryQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Fields   []string               `json:"fields"`
+}
+
+// queryRecords returns the records matching a CouchDB query in key order, one page after the bookmark
+// 0 page size returns every record, the returned bookmark is the key of the last record
+func queryRecords(namespace string, records map[string][]byte, queryString string, pageSize int, bookmark string) ([]*queryresult.KV, string, error) {
+
+	query := &memoryQuery{}
+	if err := json.Unmarshal([]byte(queryString), query); err != nil {
+		return nil, "", errors.New("Invalid query: " + err.Error())
+	}
+
+	if query.Selector == nil {
+		return nil, "", errors.New("Invalid query: selector is required")
+	}
+
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		if key > bookmark {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var results []*queryresult.KV
+	lastKey := bookmark
+
+	for _, key := range keys {
+		if pageSize > 0 && len(results) == pageSize {
+			break
+		}
+
+		// only JSON records are indexed by CouchDB
+		var document map[string]interface{}
+		if err := json.Unmarshal(records[key], &document); err != nil {
+			continue
+		}
+
+		matched, err := matchSelector(document, query.Selector)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if !matched {
+			continue
+		}
+
+		value := records[key]
+		if len(query.Fields) > 0 {
+			if value, err = projectFields(document, query.Fields); err != nil {
+				return nil, "", err
+			}
+		}
+
+		results = append(results, &queryresult.KV{Namespace: namespace, Key: key, Value: value})
+		lastKey = key
+	}
+
+	return results, lastKey, nil
+}
+
+// matchSelector covers the CouchDB selectors of the chaincode:
+// equality, nested fields, $eq, $ne, $lt, $lte, $gt, $gte, $exists, $or and $and
+func matchSelector(document map[string]interface{}, selector map[string]interface{}) (bool, error) {
+
+	for field, condition := range selector {
+		var matched bool
+		var err error
+
+		switch field {
+		case "$or", "$and":
+			matched, err = matchCombination(document, field, condition)
+
+		default:
+			value, exists := document[field]
+			matched, err = matchCondition(value, exists, condition)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchCombination(document map[string]interface{}, operator string, condition interface{}) (bool, error) {
+
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, errors.New("Invalid query: " + operator + " takes a list of selectors")
+	}
+
+	for _, item := range selectors {
+		selector, ok := item.(map[string]interface{})
+		if !ok {
+			return false, errors.New("Invalid query: " + operator + " takes a list of selectors")
+		}
+
+		matched, err := matchSelector(document, selector)
+		if err != nil {
+			return false, err
+		}
+
+		// $or -> first match, $and -> first mismatch
+		if matched == (operator == "$or") {
+			return matched, nil
+		}
+	}
+
+	return operator == "$and", nil
+}
+
+func matchCondition(value interface{}, exists bool, condition interface{}) (bool, error) {
+
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return exists && reflect.DeepEqual(value, condition), nil
+	}
+
+	for operator, argument := range operators {
+
+		// nested field
+		if !strings.HasPrefix(operator, "$") {
+			nested, ok := value.(map[string]interface{})
+			if !exists || !ok {
+				return false, nil
+			}
+
+			matched, err := matchSelector(nested, map[string]interface{}{operator: argument})
+			if err != nil || !matched {
+				return false, err
+			}
+
+			continue
+		}
+
+		if operator == "$exists" {
+			expected, ok := argument.(bool)
+			if !ok {
+				return false, errors.New("Invalid query: $exists takes a boolean")
+			}
+
+			if exists != expected {
+				return false, nil
+			}
+
+			continue
+		}
+
+		if !exists {
+			return false, nil
+		}
+
+		var matched bool
+
+		switch operator {
+		case "$eq":
+			matched = reflect.DeepEqual(value, argument)
+
+		case "$ne":
+			matched = !reflect.DeepEqual(value, argument)
+
+		case "$lt", "$lte", "$gt", "$gte":
+			order, comparable := compareValues(value, argument)
+			matched = comparable && ((operator == "$lt" && order < 0) ||
+				(operator == "$lte" && order <= 0) ||
+				(operator == "$gt" && order > 0) ||
+				(operator == "$gte" && order >= 0))
+
+		default:
+			return false, errors.New("Invalid query: unknown operator " + operator)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// compareValues orders two strings or two numbers, values of other types are not comparable
+func compareValues(value, argument interface{}) (int, bool) {
+
+	switch typedValue := value.(type) {
+	case string:
+		typedArgument, ok := argument.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(typedValue, typedArgument), true
+
+	case float64:
+		typedArgument, ok := argument.(float64)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case typedValue < typedArgument:
+			return -1, true
+		case typedValue > typedArgument:
+			return 1, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// projectFields keeps the listed top level fields of the document
+func projectFields(document map[string]interface{}, fields []string) ([]byte, error) {
+
+	projection := make(map[string]interface{})
+
+	for _, field := range fields {
+		if value, ok := document[field]; ok {
+			projection[field] = value
+		}
+	}
+
+	return json.Marshal(projection)
+}
@@ -1,9 +1,9 @@
 package person
 
 import (
-	"cerberus/blockchain/instaccntschannel"
 	"cerberus/blockchain/persaccntschannel"
 	"cerberus/services/crypto"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,11 +11,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/mgo.v2/bson"
 )
 
-func CreateAccountDataRequest(requesterPublicId, recipientPublicId string, args []string) (string, []byte, error) {
+func (service *Service) CreateAccountDataRequest(ctx context.Context, requesterPublicId, recipientPublicId string, args []string) (string, []byte, error) {
 
 	if requesterPublicId == "" {
 		return "", nil, errors.New("Requester ID value cannot be an empty string")
@@ -81,21 +82,28 @@ func CreateAccountDataRequest(requesterPublicId, recipientPublicId string, args
 	}
 
 	// call chanicode function that sends the request to the data holder
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	_, requestRecord, err := persAccntsChannelClient.CreateAccountDataRequest(newRequestAsBytes, requestDataAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, requesterPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.CreateAccountDataRequest(ledgerCtx, newRequestAsBytes, requestDataAsBytes)
 	if err != nil {
 		return "", nil, err
 	}
 
 	request := &accountDataRequest{}
-	if err = json.Unmarshal([]byte(requestRecord), request); err != nil {
+	if err = response.Decode(request); err != nil {
 		return "", nil, err
 	}
 
-	return request.PublicId, requestRecord, nil
+	return request.PublicId, response.Payload, nil
 }
 
-func CreateDocumentDataRequest(requesterPublicId, recipientPublicId, documentName string, args []string, documentCopy bool) (string, []byte, error) {
+func (service *Service) CreateDocumentDataRequest(ctx context.Context, requesterPublicId, recipientPublicId, documentName string, args []string, documentCopy bool) (string, []byte, error) {
 
 	if requesterPublicId == "" {
 		return "", nil, errors.New("Requester ID value cannot be an empty string")
@@ -167,22 +175,29 @@ func CreateDocumentDataRequest(requesterPublicId, recipientPublicId, documentNam
 	}
 
 	// call chanicode function that sends the request to the data holder
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	_, requestRecord, err := persAccntsChannelClient.CreateDocumentDataRequest(newRequestAsBytes, requestDataAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, requesterPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.CreateDocumentDataRequest(ledgerCtx, newRequestAsBytes, requestDataAsBytes)
 
 	if err != nil {
 		return "", nil, err
 	}
 
 	request := &documentDataRequest{}
-	if err = json.Unmarshal(requestRecord, request); err != nil {
+	if err = response.Decode(request); err != nil {
 		return "", nil, err
 	}
 
-	return request.PublicId, requestRecord, nil
+	return request.PublicId, response.Payload, nil
 }
 
-func AcceptAccountDataRequest(recipientPublicId, requestPublicId string, args []string) ([]string, []string, error) {
+func (service *Service) AcceptAccountDataRequest(ctx context.Context, recipientPublicId, requestPublicId string, args []string) (*persaccntschannel.TxResult, []string, error) {
 
 	if recipientPublicId == "" {
 		return nil, nil, errors.New("Account Id value cannot be an empty string")
@@ -205,7 +220,7 @@ func AcceptAccountDataRequest(recipientPublicId, requestPublicId string, args []
 	}
 
 	// get request from blockchain
-	requestData, err := GetRequestObject("publicId", requestPublicId)
+	requestData, err := service.GetRequestObject(ctx, "publicId", requestPublicId)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -225,16 +240,23 @@ func AcceptAccountDataRequest(recipientPublicId, requestPublicId string, args []
 	}
 
 	// send request
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, record, err := persAccntsChannelClient.AcceptRequest("accountData", requestPublicId, recipientPublicId, acceptedFieldsAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, recipientPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.AcceptRequest(ledgerCtx, "accountData", requestPublicId, recipientPublicId, acceptedFieldsAsBytes)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return response, []string{string(record)}, nil
+	return response, []string{string(response.Payload)}, nil
 }
 
-func AcceptDocumentDataRequest(recipientPublicId, requestPublicId string, documentCopy string, args []string) ([]string, []string, []string, error) {
+func (service *Service) AcceptDocumentDataRequest(ctx context.Context, recipientPublicId, requestPublicId string, documentCopy string, args []string) (*persaccntschannel.TxResult, []string, []string, error) {
 
 	if recipientPublicId == "" {
 		return nil, nil, nil, errors.New("Account Id value cannot be an empty string")
@@ -257,7 +279,7 @@ func AcceptDocumentDataRequest(recipientPublicId, requestPublicId string, docume
 	}
 
 	// get request from blockchain
-	requestData, err := GetRequestObject("publicId", requestPublicId)
+	requestData, err := service.GetRequestObject(ctx, "publicId", requestPublicId)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -281,7 +303,8 @@ func AcceptDocumentDataRequest(recipientPublicId, requestPublicId string, docume
 
 	if request.DocumentCopy == true {
 		if documentCopy != "" {
-			documentCopyData, err = GetAccountDocumentVersion(recipientPublicId, request.DocumentName, documentCopy)
+			// the copy is handed over as a file - the export is asked for explicitly
+			documentCopyData, err = service.GetAccountDocumentVersion(ctx, recipientPublicId, request.DocumentName, documentCopy, true)
 
 			if err != nil {
 				return nil, nil, nil, err
@@ -290,16 +313,23 @@ func AcceptDocumentDataRequest(recipientPublicId, requestPublicId string, docume
 	}
 
 	// send request
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, record, err := persAccntsChannelClient.AcceptRequest("documentData", requestPublicId, recipientPublicId, acceptedFieldsAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	return response, []string{string(record)}, documentCopyData, nil
+	ledgerCtx, cancel := accountLedgerContext(ctx, recipientPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.AcceptRequest(ledgerCtx, "documentData", requestPublicId, recipientPublicId, acceptedFieldsAsBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return response, []string{string(response.Payload)}, documentCopyData, nil
 }
 
-func RejectAccountDataRequest(recipientPublicId, requestPublicId string) ([]string, []string, error) {
+func (service *Service) RejectAccountDataRequest(ctx context.Context, recipientPublicId, requestPublicId string) (*persaccntschannel.TxResult, []string, error) {
 
 	if recipientPublicId == "" {
 		return nil, nil, errors.New("Account Id value cannot be an empty string")
@@ -310,16 +340,23 @@ func RejectAccountDataRequest(recipientPublicId, requestPublicId string) ([]stri
 	}
 
 	// send request
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, record, err := persAccntsChannelClient.RejectRequest("accountData", requestPublicId, recipientPublicId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, recipientPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.RejectRequest(ledgerCtx, "accountData", requestPublicId, recipientPublicId)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return response, []string{string(record)}, nil
+	return response, []string{string(response.Payload)}, nil
 }
 
-func RejectDocumentDataRequest(recipientPublicId, requestPublicId string) ([]string, []string, error) {
+func (service *Service) RejectDocumentDataRequest(ctx context.Context, recipientPublicId, requestPublicId string) (*persaccntschannel.TxResult, []string, error) {
 
 	if recipientPublicId == "" {
 		return nil, nil, errors.New("Account Id value cannot be an empty string")
@@ -330,16 +367,38 @@ func RejectDocumentDataRequest(recipientPublicId, requestPublicId string) ([]str
 	}
 
 	// send request
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	response, record, err := persAccntsChannelClient.RejectRequest("documentData", requestPublicId, recipientPublicId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, recipientPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.RejectRequest(ledgerCtx, "documentData", requestPublicId, recipientPublicId)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return response, []string{string(record)}, nil
+	return response, []string{string(response.Payload)}, nil
+}
+
+// only for administration use
+// marks pending requests past their expiry as expired, a result with More set leaves requests for the next sweep
+func (service *Service) SweepExpiredRequests(ctx context.Context, maxRequests int) (*persaccntschannel.SweepResult, error) {
+
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	return persAccntsChannelClient.SweepExpiredRequests(ledgerCtx, maxRequests)
 }
 
-func UpdateAccountDataRequest(requesterPublicId, recipientPublicId, requestPublicId string, args []string) (string, []byte, error) {
+func (service *Service) UpdateAccountDataRequest(ctx context.Context, requesterPublicId, recipientPublicId, requestPublicId string, args []string) (string, []byte, error) {
 
 	if requesterPublicId == "" {
 		return "", nil, errors.New("Requester ID value cannot be an empty string")
@@ -359,7 +418,7 @@ func UpdateAccountDataRequest(requesterPublicId, recipientPublicId, requestPubli
 		return "", nil, errors.New("Recipient and requester ids cannot be identical")
 	}
 
-	requestData, err := GetRequestObject("publicId", requestPublicId)
+	requestData, err := service.GetRequestObject(ctx, "publicId", requestPublicId)
 	if err != nil {
 		return "", nil, err
 	}
@@ -369,12 +428,13 @@ func UpdateAccountDataRequest(requesterPublicId, recipientPublicId, requestPubli
 		return "", nil, err
 	}
 
-	if accountRequest.Status != "pending" {
+	// requests past their expiry are refused by the chaincode -> new request
+	if accountRequest.Status != "pending" || requestExpired(accountRequest.ExpiresAt) {
 
 		fmt.Println("Request status is " + accountRequest.Status)
 		fmt.Println("Creating new request")
 
-		publicId, requestRecord, err := CreateAccountDataRequest(requesterPublicId, recipientPublicId, args)
+		publicId, requestRecord, err := service.CreateAccountDataRequest(ctx, requesterPublicId, recipientPublicId, args)
 
 		if err != nil {
 			return "", nil, err
@@ -410,12 +470,21 @@ func UpdateAccountDataRequest(requesterPublicId, recipientPublicId, requestPubli
 	}
 
 	// send updates to ledger
-	instAccntsChannelClient := instaccntschannel.CerberusClient{}
-	_, updateRecord, err := instAccntsChannelClient.UpdateRequest("accountData", requestPublicId, requesterPublicId, recipientPublicId, updateDataAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ledgerCtx, cancel := accountLedgerContext(ctx, requesterPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateRequest(ledgerCtx, "accountData", requestPublicId, requesterPublicId, recipientPublicId, updateDataAsBytes)
 	if err != nil {
 		return "", nil, err
 	}
 
+	updateRecord := response.Payload
+
 	request := &accountDataRequest{}
 	if err = json.Unmarshal([]byte(updateRecord), request); err != nil {
 		return "", nil, err
@@ -425,7 +494,7 @@ func UpdateAccountDataRequest(requesterPublicId, recipientPublicId, requestPubli
 	return request.PublicId, updateRecord, nil
 }
 
-func UpdateDocumentDataRequest(requesterPublicId, recipientPublicId, requestPublicId, documentName string, args []string, documentCopy bool) (string, []byte, error) {
+func (service *Service) UpdateDocumentDataRequest(ctx context.Context, requesterPublicId, recipientPublicId, requestPublicId, documentName string, args []string, documentCopy bool) (string, []byte, error) {
 
 	if requesterPublicId == "" {
 		return "", nil, errors.New("Requester ID value cannot be an empty string")
@@ -444,7 +513,7 @@ func UpdateDocumentDataRequest(requesterPublicId, recipientPublicId, requestPubl
 		return "", nil, errors.New("Recipient and requester ids cannot be identical")
 	}
 
-	requestData, err := GetRequestObject("publicId", requestPublicId)
+	requestData, err := service.GetRequestObject(ctx, "publicId", requestPublicId)
 	if err != nil {
 		return "", nil, err
 	}
@@ -454,12 +523,13 @@ func UpdateDocumentDataRequest(requesterPublicId, recipientPublicId, requestPubl
 		return "", nil, err
 	}
 
-	if documentRequest.Status != "pending" {
+	// requests past their expiry are refused by the chaincode -> new request
+	if documentRequest.Status != "pending" || requestExpired(documentRequest.ExpiresAt) {
 
 		fmt.Println("Request status is " + documentRequest.Status)
 		fmt.Println("Creating new request")
 
-		publicId, requestRecord, err := CreateDocumentDataRequest(requesterPublicId, recipientPublicId, documentName, args, documentCopy)
+		publicId, requestRecord, err := service.CreateDocumentDataRequest(ctx, requesterPublicId, recipientPublicId, documentName, args, documentCopy)
 
 		if err != nil {
 			return "", nil, err
@@ -501,14 +571,22 @@ func UpdateDocumentDataRequest(requesterPublicId, recipientPublicId, requestPubl
 	}
 
 	// send updates to ledger
-	instAccntsChannelClient := instaccntschannel.CerberusClient{}
-	_, updateRecord, err := instAccntsChannelClient.UpdateRequest("accountData", requestPublicId, requesterPublicId, recipientPublicId, updateDataAsBytes)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", nil, err
+	}
 
+	ledgerCtx, cancel := accountLedgerContext(ctx, requesterPublicId)
+	defer cancel()
+
+	response, err := persAccntsChannelClient.UpdateRequest(ledgerCtx, "documentData", requestPublicId, requesterPublicId, recipientPublicId, updateDataAsBytes)
 	if err != nil {
 		return "", nil, err
 	}
 
-	request := &accountDataRequest{}
+	updateRecord := response.Payload
+
+	request := &documentDataRequest{}
 	if err = json.Unmarshal([]byte(updateRecord), request); err != nil {
 		return "", nil, err
 	}
@@ -559,3 +637,14 @@ func storeRequestedData(data map[string]string) string {
 
 	return content
 }
+
+// requestExpired - the chaincode reports the expiry of every request it returns and decides on the transaction timestamp
+func requestExpired(expiresAt string) bool {
+
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return false
+	}
+
+	return !time.Now().Before(expiry)
+}
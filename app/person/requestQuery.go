@@ -1,7 +1,7 @@
 package person
 
 import (
-	"cerberus/blockchain/persaccntschannel"
+	"context"
 	"encoding/json"
 	"errors"
 )
@@ -14,7 +14,7 @@ selectorKeys:
 - status
 - requestType
 */
-func GetRequestsObjectsBySelector(requestType, selectorKey, selectorValue string) ([]string, error) {
+func (service *Service) GetRequestsObjectsBySelector(ctx context.Context, requestType, selectorKey, selectorValue string) ([]string, error) {
 
 	if requestType == "" {
 		return nil, errors.New("Request type value cannot be an empty string")
@@ -28,8 +28,15 @@ func GetRequestsObjectsBySelector(requestType, selectorKey, selectorValue string
 		return nil, errors.New("Selector value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestsData, err := persAccntsChannelClient.QueryRequests("objects", requestType, selectorKey, selectorValue)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestsData, err := persAccntsChannelClient.QueryRequests(ledgerCtx, "objects", requestType, selectorKey, selectorValue)
 
 	if err != nil {
 		return nil, err
@@ -38,7 +45,7 @@ func GetRequestsObjectsBySelector(requestType, selectorKey, selectorValue string
 	return []string{string(requestsData)}, nil
 }
 
-func GetRequestsPublicIdsBySelector(requestType, selectorKey, selectorValue string) ([]string, error) {
+func (service *Service) GetRequestsPublicIdsBySelector(ctx context.Context, requestType, selectorKey, selectorValue string) ([]string, error) {
 
 	if requestType == "" {
 		return nil, errors.New("Request type value cannot be an empty string")
@@ -52,8 +59,15 @@ func GetRequestsPublicIdsBySelector(requestType, selectorKey, selectorValue stri
 		return nil, errors.New("Selector value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestsData, err := persAccntsChannelClient.QueryRequests("publicIds", requestType, selectorKey, selectorValue)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestsData, err := persAccntsChannelClient.QueryRequests(ledgerCtx, "publicIds", requestType, selectorKey, selectorValue)
 
 	if err != nil {
 		return nil, err
@@ -65,7 +79,7 @@ func GetRequestsPublicIdsBySelector(requestType, selectorKey, selectorValue stri
 // queryType:
 // requestIds
 // objects
-func GetRequestsByRecipient(queryType, requestType, recipientPublicId string) ([]string, error) {
+func (service *Service) GetRequestsByRecipient(ctx context.Context, queryType, requestType, recipientPublicId string) ([]string, error) {
 
 	if queryType == "" {
 		return nil, errors.New("Query type value cannot be an empty string")
@@ -81,8 +95,15 @@ func GetRequestsByRecipient(queryType, requestType, recipientPublicId string) ([
 
 	selectorKey := "recipientPublicId"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestsData, err := persAccntsChannelClient.QueryRequests(queryType, requestType, selectorKey, recipientPublicId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestsData, err := persAccntsChannelClient.QueryRequests(ledgerCtx, queryType, requestType, selectorKey, recipientPublicId)
 
 	if err != nil {
 		return nil, err
@@ -91,7 +112,7 @@ func GetRequestsByRecipient(queryType, requestType, recipientPublicId string) ([
 	return []string{string(requestsData)}, nil
 }
 
-func GetRequestsByRequester(queryType, requestType, requesterPublicId string) ([]string, error) {
+func (service *Service) GetRequestsByRequester(ctx context.Context, queryType, requestType, requesterPublicId string) ([]string, error) {
 
 	if queryType == "" {
 		return nil, errors.New("Query type value cannot be an empty string")
@@ -107,8 +128,15 @@ func GetRequestsByRequester(queryType, requestType, requesterPublicId string) ([
 
 	selectorKey := "requesterPublicId"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestsData, err := persAccntsChannelClient.QueryRequests(queryType, requestType, selectorKey, requesterPublicId)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestsData, err := persAccntsChannelClient.QueryRequests(ledgerCtx, queryType, requestType, selectorKey, requesterPublicId)
 
 	if err != nil {
 		return nil, err
@@ -117,7 +145,7 @@ func GetRequestsByRequester(queryType, requestType, requesterPublicId string) ([
 	return []string{string(requestsData)}, nil
 }
 
-func GetRequestsByDocumentName(queryType, documentName string) ([]string, error) {
+func (service *Service) GetRequestsByDocumentName(ctx context.Context, queryType, documentName string) ([]string, error) {
 
 	if queryType == "" {
 		return nil, errors.New("Query type value cannot be an empty string")
@@ -130,8 +158,15 @@ func GetRequestsByDocumentName(queryType, documentName string) ([]string, error)
 	requestType := "documentData"
 	selectorKey := "documentName"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestsData, err := persAccntsChannelClient.QueryRequests(queryType, requestType, selectorKey, documentName)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestsData, err := persAccntsChannelClient.QueryRequests(ledgerCtx, queryType, requestType, selectorKey, documentName)
 
 	if err != nil {
 		return nil, err
@@ -140,7 +175,7 @@ func GetRequestsByDocumentName(queryType, documentName string) ([]string, error)
 	return []string{string(requestsData)}, nil
 }
 
-func GetRequestsByStatus(queryType, requestType, status string) ([]string, error) {
+func (service *Service) GetRequestsByStatus(ctx context.Context, queryType, requestType, status string) ([]string, error) {
 
 	if queryType == "" {
 		return nil, errors.New("Query type value cannot be an empty string")
@@ -156,8 +191,15 @@ func GetRequestsByStatus(queryType, requestType, status string) ([]string, error
 
 	selectorKey := "status"
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestsData, err := persAccntsChannelClient.QueryRequests(queryType, requestType, selectorKey, status)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestsData, err := persAccntsChannelClient.QueryRequests(ledgerCtx, queryType, requestType, selectorKey, status)
 
 	if err != nil {
 		return nil, err
@@ -166,7 +208,7 @@ func GetRequestsByStatus(queryType, requestType, status string) ([]string, error
 	return []string{string(requestsData)}, nil
 }
 
-func GetRequestsByRecipientAndType(recipientId string) ([]string, error) {
+func (service *Service) GetRequestsByRecipientAndType(ctx context.Context, recipientId string) ([]string, error) {
 
 	// do we need this?
 
@@ -176,7 +218,7 @@ func GetRequestsByRecipientAndType(recipientId string) ([]string, error) {
 // idTypes:
 // requestId
 // publicId
-func GetRequestObject(idType, id string) (string, error) {
+func (service *Service) GetRequestObject(ctx context.Context, idType, id string) (string, error) {
 
 	if idType == "" {
 		return "", errors.New("Id type value cannot be an empty string")
@@ -186,8 +228,15 @@ func GetRequestObject(idType, id string) (string, error) {
 		return "", errors.New("Request Id value cannot be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestData, err := persAccntsChannelClient.QueryRequestData(idType, id)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestData, err := persAccntsChannelClient.QueryRequestData(ledgerCtx, idType, id)
 
 	if err != nil {
 		return "", err
@@ -196,11 +245,36 @@ func GetRequestObject(idType, id string) (string, error) {
 	return string(requestData), nil
 }
 
+// the values accepted by the recipient are kept in the private data collection of its org
+// the request object keeps their hash only - acceptedDataHash
+func (service *Service) GetAcceptedData(ctx context.Context, requestPublicId string) (string, error) {
+
+	if requestPublicId == "" {
+		return "", errors.New("Request Id value cannot be an empty string")
+	}
+
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	acceptedData, err := persAccntsChannelClient.QueryAcceptedData(ledgerCtx, requestPublicId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return acceptedData, nil
+}
+
 // Types:
 // accountData
 // documentData
 // general
-func GetRequestPublicId(id, requestType string) (string, error) {
+func (service *Service) GetRequestPublicId(ctx context.Context, id, requestType string) (string, error) {
 
 	if id == "" {
 		return "", errors.New("Request Id value cannot be an empty string")
@@ -210,8 +284,15 @@ func GetRequestPublicId(id, requestType string) (string, error) {
 		return "", errors.New("Request type value canont be an empty string")
 	}
 
-	persAccntsChannelClient := persaccntschannel.CerberusClient{}
-	requestData, err := persAccntsChannelClient.QueryRequestData("requestId", id)
+	persAccntsChannelClient, err := service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	requestData, err := persAccntsChannelClient.QueryRequestData(ledgerCtx, "requestId", id)
 
 	if err != nil {
 		return "", err
@@ -0,0 +1,106 @@
+package person
+
+import (
+	"cerberus/blockchain/persaccntschannel"
+	"cerberus/services/ipfs"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type rootDirectory struct {
+	ObjectType        string `json:"docType"`
+	Group             string `json:"group"`
+	ContentIdentifier string `json:"contentIdentifier"`
+	UpdatedAt         string `json:"updatedAt"`
+}
+
+// ledgerRootRegistry keeps the group root directories on the ledger of the service
+// ipfs.RootDirectories calls it without a caller context
+type ledgerRootRegistry struct {
+	service *Service
+}
+
+func (registry *ledgerRootRegistry) GetRoot(group string) (string, error) {
+
+	return registry.getRoot(context.Background(), group)
+}
+
+func (registry *ledgerRootRegistry) getRoot(ctx context.Context, group string) (string, error) {
+
+	persAccntsChannelClient, err := registry.service.ledgerClient()
+	if err != nil {
+		return "", err
+	}
+
+	ledgerCtx, cancel := ledgerContext(ctx)
+	defer cancel()
+
+	rootData, err := persAccntsChannelClient.QueryRootDirectory(ledgerCtx, group)
+	if err != nil {
+		return "", err
+	}
+
+	if rootData == "" {
+		return "", nil
+	}
+
+	root := &rootDirectory{}
+	if err = json.Unmarshal([]byte(rootData), root); err != nil {
+		return "", err
+	}
+
+	return root.ContentIdentifier, nil
+}
+
+func (registry *ledgerRootRegistry) SwapRoot(group, previousRoot, newRoot string) error {
+
+	persAccntsChannelClient, err := registry.service.ledgerClient()
+	if err != nil {
+		return err
+	}
+
+	ledgerCtx, cancel := ledgerContext(context.Background())
+	defer cancel()
+
+	_, err = persAccntsChannelClient.UpdateRootDirectory(ledgerCtx, group, previousRoot, newRoot)
+
+	if errors.Is(err, persaccntschannel.ErrConflict) || (err != nil && strings.Contains(err.Error(), "MVCC_READ_CONFLICT")) {
+		return ipfs.ErrRootConflict
+	}
+
+	return err
+}
+
+// RepairRootDirectories links the accounts queued by failed root updates under the group roots
+func (service *Service) RepairRootDirectories() error {
+
+	for _, group := range []string{ipfs.PersonAccountsGroup, ipfs.InstitutionAccountsGroup} {
+
+		if _, err := service.rootDirectories.RepairPending(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) getGroupRoots(ctx context.Context) ([]string, error) {
+
+	var roots []string
+
+	for _, group := range []string{ipfs.PersonAccountsGroup, ipfs.InstitutionAccountsGroup} {
+
+		root, err := service.rootRegistry.getRoot(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+
+		if root != "" {
+			roots = append(roots, root)
+		}
+	}
+
+	return roots, nil
+}
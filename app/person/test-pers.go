@@ -1,12 +1,15 @@
 package person
 
 import (
+	"context"
 	"fmt"
 	"os"
 )
 
 func TestPers() {
 
+	service := DefaultService()
+
 	//directory, err := ipfs.CreateGroupAccountsIpfsDirectory("personAccounts")
 	//fmt.Println(directory)
 	//fmt.Println(err)
@@ -23,65 +26,65 @@ func TestPers() {
 	id3 := "d5d4b155b92b611ce6f2704d06482844"
 	fmt.Println(id3)
 
-	//response, record, err := CreateAccount("anna", "AngeLOVA", "angeloWWA@gmail.COM", "123456")
-	//data, response, err := UpdateAccountBySelector(id1, "Phone", "newName")
-	//data, response, err := UpdateAccountFirstName(id2, "MyNENAMEEEE")
-	//data, response, err := UpdateAccountLastName(id2, "myNELASTNAMEEE")
-	//data, response, err := UpdateAccountPhone(id1, "123")
+	//response, record, err := service.CreateAccount("anna", "AngeLOVA", "angeloWWA@gmail.COM", "123456")
+	//data, response, err := service.UpdateAccountBySelector(id1, "Phone", "newName")
+	//data, response, err := service.UpdateAccountFirstName(id2, "MyNENAMEEEE")
+	//data, response, err := service.UpdateAccountLastName(id2, "myNELASTNAMEEE")
+	//data, response, err := service.UpdateAccountPhone(id1, "123")
 
 	//fmt.Println(response)
 	//fmt.Println(data)
 	//fmt.Println(record)
 	//fmt.Println(err)
 
-	//result, err := DeleteAccount(id3)
+	//result, err := service.DeleteAccount(id3)
 
 	//fmt.Println(result)
 	//fmt.Println(err)
 
-	//record, err := GetAccountById(id2)
-	//record, err := GetAccountsByEmail("angelowwa@gmail.co")
-	//record, err := GetAccountsByFirstName("newname")
-	//record, err := GetAccountsByLastName("angelova")
-	//record, err := GetAccountHistory(id1)
-	//record, err := GetAccountsBySelector("email", "angelowwa@gmail.com")
+	//record, err := service.GetAccountById(id2)
+	//record, err := service.GetAccountsByEmail("angelowwa@gmail.co")
+	//record, err := service.GetAccountsByFirstName("newname")
+	//record, err := service.GetAccountsByLastName("angelova")
+	//record, err := service.GetAccountHistory(id1)
+	//record, err := service.GetAccountsBySelector("email", "angelowwa@gmail.com")
 
 	//fmt.Println(record)
 	//fmt.Println(err)
 
 	//rsaLink := "/hdd/server/go/src/cerberus/ipfs/personAccounts/2da281036a6febef53279395d5ecb59f/newdocument2/rsa/rsa_key.pem"
-	//record, response, rsaLink, err := CreateNewDocument(id1, "newDocuMENT", "anna", "bulgaria", filename)
-	//record, response, err := CreateDocumentVersion(id1, "newdocumenT2", filename, rsaLink)
+	//record, response, rsaLink, err := service.CreateNewDocument(id1, "newDocuMENT", "anna", "bulgaria", filename)
+	//record, response, err := service.CreateDocumentVersion(id1, "newdocumenT2", filename, rsaLink)
 
 	//fmt.Println(record)
 	//fmt.Println(response)
 	//fmt.Println(rsaLink)
 	//fmt.Println(err)
 
-	//record, response, err = UpdateDocumentHolderName(id1, "newDocument2", "newHoldeName")
-	//record, response, err := UpdateDocumentCountryIssue(id1, "newdocument2", "newcountryIssue")
-	//record, response, err := DeleteDocumentVersion(id1, "newdocument2", 3)
-	//record, response, err := DeleteDocument(id1, "newdocument2")
+	//record, response, err = service.UpdateDocumentHolderName(id1, "newDocument2", "newHoldeName")
+	//record, response, err := service.UpdateDocumentCountryIssue(id1, "newdocument2", "newcountryIssue")
+	//record, response, err := service.DeleteDocumentVersion(id1, "newdocument2", 3)
+	//record, response, err := service.DeleteDocument(id1, "newdocument2")
 
 	//fmt.Println(record)
 	//fmt.Println(response)
 	//fmt.Println(err)
 
-	//result, err := GetAccountDocument(id1, "newdocument2")
-	//result, err := GetAccountDocumentVersion(id1, "newdocument2", "5")
-	//result, err := GetAccountDocumentVersions(id1, "newdocument2")
+	//result, err := service.GetAccountDocument(id1, "newdocument2")
+	//result, err := service.GetAccountDocumentVersion(id1, "newdocument2", "5")
+	//result, err := service.GetAccountDocumentVersions(id1, "newdocument2")
 
 	//fmt.Println(result)
 	//fmt.Println(err)
 
 	// *********************************
 
-	//publicId, result, err := CreateAccountDataRequest(id2, id1, []string{"firstName", "phone"})
-	//response, record, err := RejectAccountDataRequest(id1, "e42ccff1bd28a50040e8ba531f6ddb78")
-	//response, record, err := AcceptAccountDataRequest(id1, "ef4c06f241f4b9013cbd01eb09043bb4", []string{"firstName", "lastName"})
+	//publicId, result, err := service.CreateAccountDataRequest(id2, id1, []string{"firstName", "phone"})
+	//response, record, err := service.RejectAccountDataRequest(id1, "e42ccff1bd28a50040e8ba531f6ddb78")
+	//response, record, err := service.AcceptAccountDataRequest(id1, "ef4c06f241f4b9013cbd01eb09043bb4", []string{"firstName", "lastName"})
 
-	//publicId, record, err := UpdateAccountDataRequest(id2, id1, "ef4c06f241f4b9013cbd01eb09043bb4", []string{"firstName"})
-	//publicId, record, err := UpdateDocumentDataRequest(id2, id1, "4c53b2fd546bad2348d4c62d1b1c5dca", "newdocument", []string{"documentName"}, false)
+	//publicId, record, err := service.UpdateAccountDataRequest(id2, id1, "ef4c06f241f4b9013cbd01eb09043bb4", []string{"firstName"})
+	//publicId, record, err := service.UpdateDocumentDataRequest(id2, id1, "4c53b2fd546bad2348d4c62d1b1c5dca", "newdocument", []string{"documentName"}, false)
 
 	//fmt.Println(publicId)
 	//fmt.Println(string(result))
@@ -91,8 +94,8 @@ func TestPers() {
 	//fmt.Println(response)
 	//fmt.Println(record)
 
-	//id, record, err := CreateDocumentDataRequest(id2, id1, "newdocument", []string{"holder", "countryIssue", "documentName"}, false)
-	response, record, _, err := AcceptDocumentDataRequest(id1, "413f6155ff15e1fbd30470dcdfb053b4", "1", []string{"holder", "countryIssue", "documentName"})
+	//id, record, err := service.CreateDocumentDataRequest(id2, id1, "newdocument", []string{"holder", "countryIssue", "documentName"}, false)
+	response, record, _, err := service.AcceptDocumentDataRequest(context.Background(), id1, "413f6155ff15e1fbd30470dcdfb053b4", "1", []string{"holder", "countryIssue", "documentName"})
 
 	//fmt.Println(id)
 	//fmt.Println(string(record))
@@ -101,20 +104,20 @@ func TestPers() {
 	fmt.Println(record)
 	fmt.Println(err)
 
-	//response, record, err := RejectDocumentDataRequest(id1, "4c53b2fd546bad2348d4c62d1b1c5dca")
+	//response, record, err := service.RejectDocumentDataRequest(id1, "4c53b2fd546bad2348d4c62d1b1c5dca")
 
 	//fmt.Println(response)
 	//fmt.Println(record)
 	//fmt.Println(err)
 
 	// ***************************************
-	//data, err := GetRequestsObjectsBySelector("any", "status", "rejected")
-	//data, err := GetRequestsPublicIdsBySelector("documentData", "status", "rejected")
-	//data, err := GetRequestsByRecipient("objects", "documentData", id2)
-	//data, err := GetRequestsByRequester("publicIds", "any", id1)
-	//data, err := GetRequestsByDocumentName("publicIds", "newdocument")
-	//data, err := GetRequestsByStatus("objects", "any", "pending")
-	//data, err := GetRequestObject("publicId", "4c53b2fd546bad2348d4c62d1b1c5dca")
+	//data, err := service.GetRequestsObjectsBySelector("any", "status", "rejected")
+	//data, err := service.GetRequestsPublicIdsBySelector("documentData", "status", "rejected")
+	//data, err := service.GetRequestsByRecipient("objects", "documentData", id2)
+	//data, err := service.GetRequestsByRequester("publicIds", "any", id1)
+	//data, err := service.GetRequestsByDocumentName("publicIds", "newdocument")
+	//data, err := service.GetRequestsByStatus("objects", "any", "pending")
+	//data, err := service.GetRequestObject("publicId", "4c53b2fd546bad2348d4c62d1b1c5dca")
 
 	//fmt.Println(data)
 	//fmt.Println(err)
@@ -0,0 +1,95 @@
+package channelclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+)
+
+// operations accepted by the chaincode in one batch
+const BatchMaxOperations = 100
+
+// BatchOperation is one invoke of a batch
+type BatchOperation struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+// BatchOperationResult is the chaincode response of one operation
+type BatchOperationResult struct {
+	Function string `json:"function"`
+	Payload  string `json:"payload"`
+}
+
+// BatchResult describes a committed batch
+type BatchResult struct {
+	*TxResult
+
+	// in the order of the operations
+	Operations []BatchOperationResult
+}
+
+// ValidateBatch checks the batch size before sending
+func ValidateBatch(operations []BatchOperation) error {
+
+	if len(operations) == 0 {
+		return errors.New("Batch must contain at least 1 operation")
+	}
+
+	if len(operations) > BatchMaxOperations {
+		return errors.New("Batch contains too many operations")
+	}
+
+	return nil
+}
+
+// ParseBatchResults decodes the payload of a batchInvoke response
+func ParseBatchResults(result *TxResult) ([]BatchOperationResult, error) {
+
+	var results []BatchOperationResult
+	if err := result.Decode(&results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ExecuteBatch applies the operations in one transaction through the batchInvoke function of the chaincode
+// the secrets of all operations go in transientMap, the error of the first failing operation fails the batch
+func (client *Client) ExecuteBatch(ctx context.Context, operations []BatchOperation, transientMap map[string][]byte) (*BatchResult, error) {
+
+	if err := ValidateBatch(operations); err != nil {
+		return nil, err
+	}
+
+	operationsAsBytes, err := json.Marshal(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	// request -> prepare
+	request := channel.Request{
+		Fcn:          "batchInvoke",
+		Args:         [][]byte{operationsAsBytes},
+		TransientMap: transientMap,
+	}
+
+	result, err := client.Execute(ctx, request)
+	if err != nil {
+		// an invalidated batch keeps its transaction result
+		if result != nil {
+			return &BatchResult{TxResult: result}, err
+		}
+
+		return nil, err
+	}
+
+	results, err := ParseBatchResults(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchResult{TxResult: result, Operations: results}, nil
+}
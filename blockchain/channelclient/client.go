@@ -0,0 +1,361 @@
+// Package channelclient is the Fabric client of a Cerberus channel and its chaincode
+// the channel packages - persaccntschannel, instaccntschannel - configure it and add the chaincode functions
+package channelclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/pkg/errors"
+)
+
+// ErrClientClosed is returned by every call made after Close
+var ErrClientClosed = errors.New("Channel client is closed")
+
+// ErrRequestTimeout and ErrRequestCanceled wrap errors of calls whose context ended
+var ErrRequestTimeout = errors.New("Channel request timed out")
+var ErrRequestCanceled = errors.New("Channel request was canceled")
+
+// Config of the channel, the chaincode and the identity the client signs with
+// User is the service identity of Org, its certificate carries RoleAttribute with AdminRole
+type Config struct {
+	ConfigFile  string
+	ChannelID   string
+	ChaincodeID string
+	Org         string
+	User        string
+
+	// endorsers are picked by service discovery from the endorsement policy
+	// Peers endorse when discovery is disabled or fails
+	Discovery bool
+	Peers     []string
+
+	// queries are spread over these peers, Peers when empty
+	QueryPeers []string
+
+	// private records are read on peers of the org holding the collection, QueryPeers when empty
+	PrivatePeers []string
+
+	Retry RetryPolicy
+
+	// every account signs its own transactions with an identity enrolled at EnrollAccount
+	// CertificateAuthority nil uses the Fabric CA of Org, Wallet nil keeps enrollments in memory
+	AccountIdentities    bool
+	CAName               string
+	Affiliation          string
+	CertificateAuthority CertificateAuthority
+	Wallet               Wallet
+}
+
+// Client keeps the sdk, the channel client and the ledger client open until Close
+// a single client is safe for concurrent use
+type Client struct {
+	config Config
+
+	// round robin position of the query peers
+	nextQueryPeer   uint32
+	nextPrivatePeer uint32
+
+	mutex         sync.RWMutex
+	sdk           *fabsdk.FabricSDK
+	channelCtx    contextApi.ChannelProvider
+	channelClient *channel.Client
+	ledgerClient  *ledger.Client
+	identities    *accountIdentities
+	initialized   bool
+	closed        bool
+}
+
+func New(config Config) (*Client, error) {
+
+	if config.ConfigFile == "" {
+		return nil, errors.New("Fabric sdk config file cannot be an empty string")
+	}
+
+	if config.ChannelID == "" {
+		return nil, errors.New("Channel ID cannot be an empty string")
+	}
+
+	if config.ChaincodeID == "" {
+		return nil, errors.New("Chaincode ID cannot be an empty string")
+	}
+
+	if len(config.Peers) == 0 {
+		return nil, errors.New("At least one peer is required")
+	}
+
+	if config.Retry.Attempts < 0 {
+		return nil, errors.New("Retry attempts cannot be negative")
+	}
+
+	client := &Client{config: config}
+
+	if err := client.setup(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// ChannelID returns the configured channel
+func (client *Client) ChannelID() string {
+
+	return client.config.ChannelID
+}
+
+// ChaincodeID returns the configured chaincode
+func (client *Client) ChaincodeID() string {
+
+	return client.config.ChaincodeID
+}
+
+// Close releases the sdk, calls made afterwards return ErrClientClosed
+func (client *Client) Close() {
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.closed {
+		return
+	}
+
+	if client.sdk != nil {
+		client.sdk.Close()
+	}
+
+	client.sdk = nil
+	client.channelCtx = nil
+	client.channelClient = nil
+	client.ledgerClient = nil
+	client.identities = nil
+	client.initialized = false
+	client.closed = true
+}
+
+// Execute endorses and commits the request, an empty ChaincodeID calls the configured chaincode
+func (client *Client) Execute(ctx context.Context, request channel.Request) (*TxResult, error) {
+
+	// channel client -> get
+	channelClient, err := client.channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ChaincodeID == "" {
+		request.ChaincodeID = client.config.ChaincodeID
+	}
+
+	response, err := client.execute(ctx, channelClient, request)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	return client.newTxResult(ctx, response)
+}
+
+// Query evaluates the request on a query peer and returns the chaincode response
+// an empty ChaincodeID calls the configured chaincode
+func (client *Client) Query(ctx context.Context, request channel.Request) ([]byte, error) {
+
+	// channel client -> get
+	channelClient, err := client.channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ChaincodeID == "" {
+		request.ChaincodeID = client.config.ChaincodeID
+	}
+
+	response, err := client.query(ctx, channelClient, request, false)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	return response.Payload, nil
+}
+
+// QueryPrivate evaluates a request reading private data collections on the private peers
+// peers which do not hold the private records are skipped
+func (client *Client) QueryPrivate(ctx context.Context, request channel.Request) ([]byte, error) {
+
+	// channel client -> get
+	channelClient, err := client.channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ChaincodeID == "" {
+		request.ChaincodeID = client.config.ChaincodeID
+	}
+
+	response, err := client.query(ctx, channelClient, request, true)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	return response.Payload, nil
+}
+
+// EnrollAccount enrolls the identity of the account when account identities are on
+// the returned context signs as the account
+func (client *Client) EnrollAccount(ctx context.Context, accountPublicID string) (context.Context, error) {
+
+	identities := client.accountIdentities()
+	if identities == nil {
+		return ctx, nil
+	}
+
+	if err := identities.enroll(accountPublicID); err != nil {
+		return ctx, err
+	}
+
+	return WithActingAccount(ctx, accountPublicID), nil
+}
+
+// RevokeAccount ends the identity of a deleted account, nothing to do when account identities are off
+func (client *Client) RevokeAccount(accountPublicID string) error {
+
+	identities := client.accountIdentities()
+	if identities == nil {
+		return nil
+	}
+
+	return identities.revoke(accountPublicID)
+}
+
+// channel returns the channel client signing as the acting account of ctx
+// the client of the configured user signs when no account acts or account identities are off
+func (client *Client) channel(ctx context.Context) (*channel.Client, error) {
+
+	client.mutex.RLock()
+
+	if client.closed {
+		client.mutex.RUnlock()
+		return nil, ErrClientClosed
+	}
+
+	if !client.initialized {
+		client.mutex.RUnlock()
+		return nil, errors.New("Channel client is not initialized, use New")
+	}
+
+	channelClient := client.channelClient
+	identities := client.identities
+
+	client.mutex.RUnlock()
+
+	accountPublicID, ok := ActingAccount(ctx)
+	if !ok || identities == nil {
+		return channelClient, nil
+	}
+
+	return identities.channelClient(accountPublicID)
+}
+
+// accountIdentities returns nil when account identities are off
+func (client *Client) accountIdentities() *accountIdentities {
+
+	client.mutex.RLock()
+	defer client.mutex.RUnlock()
+
+	return client.identities
+}
+
+// channelContext returns the channel provider event clients are created from
+func (client *Client) channelContext() (contextApi.ChannelProvider, error) {
+
+	client.mutex.RLock()
+	defer client.mutex.RUnlock()
+
+	if client.closed {
+		return nil, ErrClientClosed
+	}
+
+	if !client.initialized {
+		return nil, errors.New("Channel client is not initialized, use New")
+	}
+
+	return client.channelCtx, nil
+}
+
+func (client *Client) setup() error {
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	// sdk instance -> already open
+	if client.initialized {
+		return nil
+	}
+
+	if client.closed {
+		return ErrClientClosed
+	}
+
+	// sdk instance -> create
+	sdkInstance, err := fabsdk.New(config.FromFile(client.config.ConfigFile))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	channelCtx := sdkInstance.ChannelContext(client.config.ChannelID, fabsdk.WithUser(client.config.User), fabsdk.WithOrg(client.config.Org))
+
+	// instantiate channel
+	channelClient, err := channel.New(channelCtx)
+	if err != nil {
+		fmt.Println(err)
+		sdkInstance.Close()
+		return err
+	}
+
+	// ledger client -> block lookups for transaction results
+	ledgerClient, err := ledger.New(channelCtx)
+	if err != nil {
+		fmt.Println(err)
+		sdkInstance.Close()
+		return err
+	}
+
+	// account identities -> CA and wallet
+	var identities *accountIdentities
+	if client.config.AccountIdentities {
+		identities, err = newAccountIdentities(sdkInstance, client.config)
+		if err != nil {
+			fmt.Println(err)
+			sdkInstance.Close()
+			return err
+		}
+	}
+
+	client.sdk = sdkInstance
+	client.channelCtx = channelCtx
+	client.channelClient = channelClient
+	client.ledgerClient = ledgerClient
+	client.identities = identities
+	client.initialized = true
+
+	return nil
+}
+
+// requestError tells a timeout or a cancellation apart from errors returned by the peers
+// chaincode failures become a *ChaincodeError
+func requestError(ctx context.Context, err error) error {
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("%w: %v", ErrRequestTimeout, err)
+
+	case context.Canceled:
+		return fmt.Errorf("%w: %v", ErrRequestCanceled, err)
+	}
+
+	return chaincodeError(err)
+}
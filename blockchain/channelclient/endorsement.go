@@ -0,0 +1,189 @@
+package channelclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// RetryPolicy of a single call - transient endorsement and ordering failures are retried with a growing backoff
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	BackoffFactor  float64
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+
+	return RetryPolicy{
+		Attempts:       3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		BackoffFactor:  2,
+	}
+}
+
+func (policy RetryPolicy) options() retry.Opts {
+
+	return retry.Opts{
+		Attempts:       policy.Attempts,
+		InitialBackoff: policy.InitialBackoff,
+		MaxBackoff:     policy.MaxBackoff,
+		BackoffFactor:  policy.BackoffFactor,
+		RetryableCodes: retry.ChannelClientRetryableCodes,
+	}
+}
+
+// execute sends the transaction to the endorsers picked by service discovery from the endorsement policy
+// the static peers endorse when discovery is disabled or the endorsement through discovery failed
+// a transaction that may have reached the orderer is never sent again
+func (client *Client) execute(ctx context.Context, channelClient *channel.Client, request channel.Request) (channel.Response, error) {
+
+	if client.config.Discovery {
+		response, err := channelClient.Execute(request, client.requestOptions(ctx, fab.Execute)...)
+		if err == nil || !endorsementFailed(ctx, response, err) {
+			return response, err
+		}
+
+		fmt.Println("Discovery based endorsement failed, using the static peers: " + err.Error())
+	}
+
+	options := append(client.requestOptions(ctx, fab.Execute), channel.WithTargetEndpoints(client.config.Peers...))
+
+	return channelClient.Execute(request, options...)
+}
+
+// query asks one query peer at a time, rotating the first peer between calls
+// the next peer is asked when a peer cannot be reached
+// private queries go to the private peers and also move on when the peer does not hold the private record
+func (client *Client) query(ctx context.Context, channelClient *channel.Client, request channel.Request, private bool) (channel.Response, error) {
+
+	peers := client.queryPeers()
+	if private {
+		peers = client.privatePeers()
+	}
+
+	var response channel.Response
+	var err error
+
+	for _, peer := range peers {
+		options := append(client.requestOptions(ctx, fab.Query), channel.WithTargetEndpoints(peer))
+
+		response, err = channelClient.Query(request, options...)
+		if err == nil {
+			return response, nil
+		}
+
+		if !fallbackAllowed(ctx, err) && !(private && privateFallbackAllowed(ctx, err)) {
+			return response, err
+		}
+
+		fmt.Println("Query on peer " + peer + " failed: " + err.Error())
+	}
+
+	return response, err
+}
+
+// queryPeers returns the query peers starting with the next peer in turn
+func (client *Client) queryPeers() []string {
+
+	peers := client.config.QueryPeers
+	if len(peers) == 0 {
+		peers = client.config.Peers
+	}
+
+	return rotatePeers(peers, &client.nextQueryPeer)
+}
+
+// privatePeers returns the private peers starting with the next peer in turn
+func (client *Client) privatePeers() []string {
+
+	peers := client.config.PrivatePeers
+	if len(peers) == 0 {
+		return client.queryPeers()
+	}
+
+	return rotatePeers(peers, &client.nextPrivatePeer)
+}
+
+func rotatePeers(peers []string, next *uint32) []string {
+
+	first := int(atomic.AddUint32(next, 1)-1) % len(peers)
+
+	rotated := make([]string, 0, len(peers))
+	rotated = append(rotated, peers[first:]...)
+	rotated = append(rotated, peers[:first]...)
+
+	return rotated
+}
+
+// requestOptions maps the context and the retry policy onto the sdk request options
+// the remaining time of the context deadline becomes the sdk timeout
+func (client *Client) requestOptions(ctx context.Context, timeoutType fab.TimeoutType) []channel.RequestOption {
+
+	options := []channel.RequestOption{
+		channel.WithParentContext(ctx),
+		channel.WithRetry(client.config.Retry.options()),
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		options = append(options, channel.WithTimeout(timeoutType, time.Until(deadline)))
+	}
+
+	return options
+}
+
+// fallbackAllowed - other peers are not tried for chaincode errors or once the context ended
+func fallbackAllowed(ctx context.Context, err error) bool {
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var chaincodeErr *ChaincodeError
+	return !errors.As(chaincodeError(err), &chaincodeErr)
+}
+
+// endorsementFailed - the transaction failed before it was sent to the orderer:
+// discovery found no endorsers, the endorsers could not be reached or did not endorse, or the endorsements differ
+// ordering, commit and event failures - timeouts included - are not, the transaction may still be committed
+func endorsementFailed(ctx context.Context, response channel.Response, err error) bool {
+
+	if !fallbackAllowed(ctx, err) {
+		return false
+	}
+
+	sdkStatus, ok := status.FromError(err)
+	if !ok {
+		// untyped errors come from the endorser selection, unless the proposal has been endorsed
+		return len(response.Responses) == 0
+	}
+
+	switch sdkStatus.Group {
+	case status.DiscoveryServerStatus, status.EndorserClientStatus, status.EndorserServerStatus:
+		return true
+
+	case status.ClientStatus:
+		return sdkStatus.Code == status.NoPeersFound.ToInt32() || sdkStatus.Code == status.EndorsementMismatch.ToInt32()
+	}
+
+	return false
+}
+
+// privateFallbackAllowed - a peer of another org does not hold the private record, a peer of the holder org may
+func privateFallbackAllowed(ctx context.Context, err error) bool {
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return errors.Is(chaincodeError(err), ErrPrivateDataUnavailable)
+}
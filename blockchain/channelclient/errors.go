@@ -0,0 +1,84 @@
+package channelclient
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+)
+
+// kinds of chaincode errors - test with errors.Is
+var (
+	ErrNotFound        = errors.New("Ledger record does not exist")
+	ErrAlreadyExists   = errors.New("Ledger record already exists")
+	ErrInvalidArgument = errors.New("Invalid chaincode arguments")
+	ErrInvalidState    = errors.New("Ledger record state does not allow the operation")
+	ErrConflict        = errors.New("Ledger record has been updated concurrently")
+	ErrUnauthorized    = errors.New("Caller is not allowed to access the ledger record")
+	ErrChaincode       = errors.New("Chaincode error")
+
+	// the private record is kept in a collection of another org
+	ErrPrivateDataUnavailable = errors.New("Private ledger record is not available on this peer")
+)
+
+// status of the chaincode error responses, set by the chaincode for every error of a known kind
+// other failures - shim.Error - are 500
+const (
+	StatusInvalidArgument        = 400
+	StatusUnauthorized           = 403
+	StatusNotFound               = 404
+	StatusAlreadyExists          = 409
+	StatusConflict               = 412
+	StatusInvalidState           = 422
+	StatusPrivateDataUnavailable = 424
+)
+
+// chaincode status -> error kind
+var chaincodeErrorKinds = map[int32]error{
+	StatusInvalidArgument:        ErrInvalidArgument,
+	StatusUnauthorized:           ErrUnauthorized,
+	StatusNotFound:               ErrNotFound,
+	StatusAlreadyExists:          ErrAlreadyExists,
+	StatusConflict:               ErrConflict,
+	StatusInvalidState:           ErrInvalidState,
+	StatusPrivateDataUnavailable: ErrPrivateDataUnavailable,
+}
+
+// ChaincodeError is an error returned by the chaincode through shim.Error
+type ChaincodeError struct {
+	Status  int32
+	Message string
+
+	kind error
+}
+
+func (chaincodeError *ChaincodeError) Error() string {
+
+	return chaincodeError.Message
+}
+
+func (chaincodeError *ChaincodeError) Unwrap() error {
+
+	return chaincodeError.kind
+}
+
+// NewChaincodeError returns the typed error of a chaincode response - for ledgers other than Fabric
+func NewChaincodeError(statusCode int32, message string) *ChaincodeError {
+
+	kind, ok := chaincodeErrorKinds[statusCode]
+	if !ok {
+		kind = ErrChaincode
+	}
+
+	return &ChaincodeError{Status: statusCode, Message: message, kind: kind}
+}
+
+// chaincodeError returns the typed error of a chaincode failure, other errors are returned as they are
+func chaincodeError(err error) error {
+
+	sdkStatus, ok := status.FromError(err)
+	if !ok || sdkStatus.Group != status.ChaincodeStatus {
+		return err
+	}
+
+	return NewChaincodeError(sdkStatus.Code, sdkStatus.Message)
+}
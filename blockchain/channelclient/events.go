@@ -0,0 +1,200 @@
+package channelclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+)
+
+// reconnect delays and event buffer of a subscription
+const (
+	subscriptionRetryDelay    = time.Second
+	subscriptionMaxRetryDelay = 30 * time.Second
+	subscriptionBufferSize    = 64
+)
+
+// SubscribeChaincodeEvents delivers the events of the chaincode whose names match the pattern
+// until ctx ends or the client is closed, fromBlock 0 starts with the next committed block
+// a broken connection is reopened from the block of the last delivered event, events are not repeated
+// the returned channel is closed when the subscription ends
+func (client *Client) SubscribeChaincodeEvents(ctx context.Context, pattern string, fromBlock uint64) (<-chan *fab.CCEvent, error) {
+
+	// next committed block -> the chain height now, so a reconnect before
+	// the first event resumes there instead of at the newest block
+	if fromBlock == 0 {
+		info, err := client.QueryChainInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		fromBlock = info.Height
+	}
+
+	subscription := &eventSubscription{
+		client:    client,
+		pattern:   pattern,
+		fromBlock: fromBlock,
+		delivered: make(map[string]bool),
+		events:    make(chan *fab.CCEvent, subscriptionBufferSize),
+	}
+
+	// first connection -> errors go to the caller
+	if err := subscription.connect(); err != nil {
+		return nil, err
+	}
+
+	go subscription.run(ctx)
+
+	return subscription.events, nil
+}
+
+type eventSubscription struct {
+	client  *Client
+	pattern string
+
+	eventClient  *event.Client
+	registration fab.Registration
+	notifier     <-chan *fab.CCEvent
+
+	// resume position - transactions of fromBlock already delivered
+	fromBlock uint64
+	delivered map[string]bool
+
+	events chan *fab.CCEvent
+}
+
+func (subscription *eventSubscription) connect() error {
+
+	channelCtx, err := subscription.client.channelContext()
+	if err != nil {
+		return err
+	}
+
+	// block events -> filtered events carry no chaincode event payload
+	eventClient, err := event.New(channelCtx, event.WithBlockEvents(), event.WithSeekType(seek.FromBlock), event.WithBlockNum(subscription.fromBlock))
+	if err != nil {
+		return err
+	}
+
+	registration, notifier, err := eventClient.RegisterChaincodeEvent(subscription.client.config.ChaincodeID, subscription.pattern)
+	if err != nil {
+		return err
+	}
+
+	subscription.eventClient = eventClient
+	subscription.registration = registration
+	subscription.notifier = notifier
+
+	return nil
+}
+
+func (subscription *eventSubscription) disconnect() {
+
+	if subscription.eventClient != nil {
+		subscription.eventClient.Unregister(subscription.registration)
+	}
+
+	subscription.eventClient = nil
+	subscription.registration = nil
+	subscription.notifier = nil
+}
+
+func (subscription *eventSubscription) run(ctx context.Context) {
+
+	defer close(subscription.events)
+	defer subscription.disconnect()
+
+	for {
+		if !subscription.consume(ctx) {
+			return
+		}
+
+		// notifier closed -> reconnect from the last block
+		subscription.disconnect()
+
+		if !subscription.reconnect(ctx) {
+			return
+		}
+	}
+}
+
+// consume forwards events until the notifier closes, false ends the subscription
+func (subscription *eventSubscription) consume(ctx context.Context) bool {
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+
+		case chaincodeEvent, ok := <-subscription.notifier:
+			if !ok {
+				return true
+			}
+
+			if !subscription.isNew(chaincodeEvent) {
+				continue
+			}
+
+			select {
+			case subscription.events <- chaincodeEvent:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+}
+
+// isNew skips events replayed after a reconnect
+func (subscription *eventSubscription) isNew(chaincodeEvent *fab.CCEvent) bool {
+
+	if chaincodeEvent.BlockNumber < subscription.fromBlock {
+		return false
+	}
+
+	if chaincodeEvent.BlockNumber > subscription.fromBlock {
+		subscription.fromBlock = chaincodeEvent.BlockNumber
+		subscription.delivered = make(map[string]bool)
+	}
+
+	if subscription.delivered[chaincodeEvent.TxID] {
+		return false
+	}
+
+	subscription.delivered[chaincodeEvent.TxID] = true
+
+	return true
+}
+
+// reconnect retries with a growing delay, false when ctx ends or the client is closed
+func (subscription *eventSubscription) reconnect(ctx context.Context) bool {
+
+	delay := subscriptionRetryDelay
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+
+		err := subscription.connect()
+		if err == nil {
+			return true
+		}
+
+		if err == ErrClientClosed {
+			return false
+		}
+
+		fmt.Println("Unable to reconnect event subscription: " + err.Error())
+
+		delay *= 2
+		if delay > subscriptionMaxRetryDelay {
+			delay = subscriptionMaxRetryDelay
+		}
+	}
+}
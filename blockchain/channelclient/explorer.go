@@ -0,0 +1,539 @@
+package channelclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mspProto "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// ChainInfo is the height and the latest block hashes of the channel
+type ChainInfo struct {
+	Height            uint64
+	CurrentBlockHash  string
+	PreviousBlockHash string
+
+	// peer which answered
+	Endorser string
+}
+
+// Block is a decoded block header with the transactions of the block
+type Block struct {
+	Number       uint64
+	Hash         string
+	PreviousHash string
+	DataHash     string
+	TxIDs        []string
+
+	// txID -> envelope, the data hash matches the header
+	envelopes map[string]*common.Envelope
+	dataValid bool
+}
+
+// Identity is a serialized Fabric identity
+type Identity struct {
+	MSPID string
+
+	// PEM encoded enrollment certificate
+	Certificate string
+}
+
+// Endorsement is the signature of one endorsing peer over the proposal response
+type Endorsement struct {
+	Endorser  Identity
+	Signature []byte
+
+	// the signature matches the certificate of the endorser and the certificate is issued
+	// by a CA of the endorser's MSP in the channel config and not revoked by its CRLs
+	Valid bool
+}
+
+// Transaction is a decoded transaction envelope
+type Transaction struct {
+	TxID           string
+	ChannelID      string
+	Timestamp      string
+	ChaincodeID    string
+	Function       string
+	ValidationCode string
+	Creator        Identity
+	Endorsements   []Endorsement
+
+	// marshaled envelope
+	Envelope []byte
+
+	envelope *common.Envelope
+}
+
+// TransactionProof shows when and in which block a transaction was committed and who endorsed it
+type TransactionProof struct {
+	Transaction *Transaction
+	Block       *Block
+	ChainInfo   *ChainInfo
+
+	// the envelope is part of the block data and the data hash matches the block header
+	IncludedInBlock bool
+
+	// every endorsement is valid - the endorsement policy itself is checked by the peers, see the validation code
+	EndorsementsValid bool
+}
+
+// channelMSP is the trust of one MSP of the channel config
+type channelMSP struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+
+	// serial numbers revoked by the CRLs of the MSP
+	revoked map[string]bool
+}
+
+// QueryChainInfo returns the height of the channel
+func (client *Client) QueryChainInfo(ctx context.Context) (*ChainInfo, error) {
+
+	ledgerClient, err := client.ledger()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ledgerClient.QueryInfo(client.ledgerOptions(ctx)...)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	return &ChainInfo{
+		Height:            response.BCI.Height,
+		CurrentBlockHash:  hex.EncodeToString(response.BCI.CurrentBlockHash),
+		PreviousBlockHash: hex.EncodeToString(response.BCI.PreviousBlockHash),
+		Endorser:          response.Endorser,
+	}, nil
+}
+
+// QueryBlock returns the block by number
+func (client *Client) QueryBlock(ctx context.Context, blockNumber uint64) (*Block, error) {
+
+	ledgerClient, err := client.ledger()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ledgerClient.QueryBlock(blockNumber, client.ledgerOptions(ctx)...)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	return decodeBlock(block)
+}
+
+// QueryBlockByTxID returns the block which contains the transaction
+func (client *Client) QueryBlockByTxID(ctx context.Context, txID string) (*Block, error) {
+
+	ledgerClient, err := client.ledger()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ledgerClient.QueryBlockByTxID(fab.TransactionID(txID), client.ledgerOptions(ctx)...)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	return decodeBlock(block)
+}
+
+// QueryTransaction returns the committed transaction with its creator, endorsements and validation code
+func (client *Client) QueryTransaction(ctx context.Context, txID string) (*Transaction, error) {
+
+	ledgerClient, err := client.ledger()
+	if err != nil {
+		return nil, err
+	}
+
+	processedTransaction, err := ledgerClient.QueryTransaction(fab.TransactionID(txID), client.ledgerOptions(ctx)...)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	msps, err := client.channelMSPs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := decodeEnvelope(processedTransaction.TransactionEnvelope, msps)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.ValidationCode = pb.TxValidationCode(processedTransaction.ValidationCode).String()
+
+	return transaction, nil
+}
+
+// GetTransactionProof collects the transaction, its block and the chain height
+func (client *Client) GetTransactionProof(ctx context.Context, txID string) (*TransactionProof, error) {
+
+	if txID == "" {
+		return nil, errors.New("Transaction ID cannot be an empty string")
+	}
+
+	transaction, err := client.QueryTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := client.QueryBlockByTxID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	chainInfo, err := client.QueryChainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &TransactionProof{
+		Transaction:       transaction,
+		Block:             block,
+		ChainInfo:         chainInfo,
+		EndorsementsValid: len(transaction.Endorsements) > 0,
+	}
+
+	// payload and signature are compared as committed, a marshaled envelope may differ in encoding
+	if envelope, ok := block.envelopes[txID]; ok {
+		proof.IncludedInBlock = block.dataValid &&
+			bytes.Equal(envelope.Payload, transaction.envelope.Payload) &&
+			bytes.Equal(envelope.Signature, transaction.envelope.Signature)
+	}
+
+	for _, endorsement := range transaction.Endorsements {
+		if !endorsement.Valid {
+			proof.EndorsementsValid = false
+		}
+	}
+
+	return proof, nil
+}
+
+// channelMSPs reads the CAs and CRLs of every Fabric MSP from the channel config
+func (client *Client) channelMSPs(ctx context.Context) (map[string]*channelMSP, error) {
+
+	ledgerClient, err := client.ledger()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := ledgerClient.QueryConfig(client.ledgerOptions(ctx)...)
+	if err != nil {
+		return nil, requestError(ctx, err)
+	}
+
+	msps := make(map[string]*channelMSP)
+
+	for _, mspConfig := range config.MSPs() {
+
+		// idemix MSPs have no certificates
+		if mspConfig.Type != fabricMSPType {
+			continue
+		}
+
+		fabricConfig := &mspProto.FabricMSPConfig{}
+		if err = proto.Unmarshal(mspConfig.Config, fabricConfig); err != nil {
+			return nil, err
+		}
+
+		msp := &channelMSP{
+			roots:         x509.NewCertPool(),
+			intermediates: x509.NewCertPool(),
+			revoked:       make(map[string]bool),
+		}
+
+		for _, root := range fabricConfig.RootCerts {
+			msp.roots.AppendCertsFromPEM(root)
+		}
+
+		for _, intermediate := range fabricConfig.IntermediateCerts {
+			msp.intermediates.AppendCertsFromPEM(intermediate)
+		}
+
+		// the CRLs are trusted as part of the channel config, like the CAs
+		for _, crlPEM := range fabricConfig.RevocationList {
+			crl, err := x509.ParseCRL(crlPEM)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, revoked := range crl.TBSCertList.RevokedCertificates {
+				msp.revoked[revoked.SerialNumber.String()] = true
+			}
+		}
+
+		msps[fabricConfig.Name] = msp
+	}
+
+	return msps, nil
+}
+
+func (client *Client) ledger() (*ledger.Client, error) {
+
+	client.mutex.RLock()
+	defer client.mutex.RUnlock()
+
+	if client.ledgerClient == nil {
+		return nil, ErrClientClosed
+	}
+
+	return client.ledgerClient, nil
+}
+
+// type of the msp.MSPConfig of an X.509 MSP
+const fabricMSPType = 0
+
+// block header as hashed by Fabric
+type asn1BlockHeader struct {
+	Number       *big.Int
+	PreviousHash []byte
+	DataHash     []byte
+}
+
+func decodeBlock(block *common.Block) (*Block, error) {
+
+	if block == nil || block.Header == nil || block.Data == nil {
+		return nil, errors.New("Block is incomplete")
+	}
+
+	headerBytes, err := asn1.Marshal(asn1BlockHeader{
+		Number:       new(big.Int).SetUint64(block.Header.Number),
+		PreviousHash: block.Header.PreviousHash,
+		DataHash:     block.Header.DataHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headerHash := sha256.Sum256(headerBytes)
+	dataHash := sha256.Sum256(bytes.Join(block.Data.Data, nil))
+
+	decoded := &Block{
+		Number:       block.Header.Number,
+		Hash:         hex.EncodeToString(headerHash[:]),
+		PreviousHash: hex.EncodeToString(block.Header.PreviousHash),
+		DataHash:     hex.EncodeToString(block.Header.DataHash),
+		envelopes:    make(map[string]*common.Envelope),
+		dataValid:    bytes.Equal(dataHash[:], block.Header.DataHash),
+	}
+
+	for _, envelopeBytes := range block.Data.Data {
+		envelope := &common.Envelope{}
+		if err = proto.Unmarshal(envelopeBytes, envelope); err != nil {
+			return nil, err
+		}
+
+		_, channelHeader, err := decodePayload(envelope)
+		if err != nil {
+			return nil, err
+		}
+
+		decoded.TxIDs = append(decoded.TxIDs, channelHeader.TxId)
+		decoded.envelopes[channelHeader.TxId] = envelope
+	}
+
+	return decoded, nil
+}
+
+func decodePayload(envelope *common.Envelope) (*common.Payload, *common.ChannelHeader, error) {
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, nil, err
+	}
+
+	if payload.Header == nil {
+		return nil, nil, errors.New("Transaction payload has no header")
+	}
+
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		return nil, nil, err
+	}
+
+	return payload, channelHeader, nil
+}
+
+// decodeEnvelope reads the creator, the chaincode call and the endorsements of a transaction
+// the endorsers are checked against the MSPs of the channel
+func decodeEnvelope(envelope *common.Envelope, msps map[string]*channelMSP) (*Transaction, error) {
+
+	if envelope == nil {
+		return nil, errors.New("Transaction has no envelope")
+	}
+
+	envelopeBytes, err := proto.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, channelHeader, err := decodePayload(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureHeader := &common.SignatureHeader{}
+	if err = proto.Unmarshal(payload.Header.SignatureHeader, signatureHeader); err != nil {
+		return nil, err
+	}
+
+	creator, err := decodeIdentity(signatureHeader.Creator)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &Transaction{
+		TxID:      channelHeader.TxId,
+		ChannelID: channelHeader.ChannelId,
+		Creator:   *creator,
+		Envelope:  envelopeBytes,
+		envelope:  envelope,
+	}
+
+	if channelHeader.Timestamp != nil {
+		transaction.Timestamp = time.Unix(channelHeader.Timestamp.Seconds, int64(channelHeader.Timestamp.Nanos)).UTC().Format(time.RFC3339Nano)
+	}
+
+	if common.HeaderType(channelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return transaction, nil
+	}
+
+	tx := &pb.Transaction{}
+	if err = proto.Unmarshal(payload.Data, tx); err != nil {
+		return nil, err
+	}
+
+	for _, action := range tx.Actions {
+		actionPayload := &pb.ChaincodeActionPayload{}
+		if err = proto.Unmarshal(action.Payload, actionPayload); err != nil {
+			return nil, err
+		}
+
+		if err = transaction.decodeProposal(actionPayload.ChaincodeProposalPayload); err != nil {
+			return nil, err
+		}
+
+		if actionPayload.Action == nil {
+			continue
+		}
+
+		for _, endorsement := range actionPayload.Action.Endorsements {
+			endorser, err := decodeIdentity(endorsement.Endorser)
+			if err != nil {
+				return nil, err
+			}
+
+			// endorsers sign the proposal response payload followed by their identity
+			signedBytes := append(append([]byte{}, actionPayload.Action.ProposalResponsePayload...), endorsement.Endorser...)
+
+			transaction.Endorsements = append(transaction.Endorsements, Endorsement{
+				Endorser:  *endorser,
+				Signature: endorsement.Signature,
+				Valid:     verifyEndorsement(endorser, msps, signedBytes, endorsement.Signature),
+			})
+		}
+	}
+
+	return transaction, nil
+}
+
+// decodeProposal keeps the chaincode and the function name - the arguments may carry account data
+func (transaction *Transaction) decodeProposal(proposalPayloadBytes []byte) error {
+
+	proposalPayload := &pb.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(proposalPayloadBytes, proposalPayload); err != nil {
+		return err
+	}
+
+	invocationSpec := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(proposalPayload.Input, invocationSpec); err != nil {
+		return err
+	}
+
+	spec := invocationSpec.ChaincodeSpec
+	if spec == nil {
+		return nil
+	}
+
+	if spec.ChaincodeId != nil {
+		transaction.ChaincodeID = spec.ChaincodeId.Name
+	}
+
+	if spec.Input != nil && len(spec.Input.Args) > 0 {
+		transaction.Function = string(spec.Input.Args[0])
+	}
+
+	return nil
+}
+
+func decodeIdentity(serializedIdentity []byte) (*Identity, error) {
+
+	identity := &mspProto.SerializedIdentity{}
+	if err := proto.Unmarshal(serializedIdentity, identity); err != nil {
+		return nil, err
+	}
+
+	return &Identity{MSPID: identity.Mspid, Certificate: string(identity.IdBytes)}, nil
+}
+
+// verifyEndorsement checks an ECDSA signature of a Fabric identity over sha256 of the message
+// and that the certificate of the identity chains to a CA of its MSP, like the MSP of a peer does
+func verifyEndorsement(identity *Identity, msps map[string]*channelMSP, message, signature []byte) bool {
+
+	msp, ok := msps[identity.MSPID]
+	if !ok {
+		return false
+	}
+
+	block, _ := pem.Decode([]byte(identity.Certificate))
+	if block == nil {
+		return false
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+
+	if msp.revoked[certificate.SerialNumber.String()] {
+		return false
+	}
+
+	// the certificate was valid when it endorsed - Fabric ignores the expiry of committed endorsements as well
+	_, err = certificate.Verify(x509.VerifyOptions{
+		Roots:         msp.roots,
+		Intermediates: msp.intermediates,
+		CurrentTime:   certificate.NotBefore.Add(time.Second),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return false
+	}
+
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+
+	digest := sha256.Sum256(message)
+
+	return ecdsa.VerifyASN1(publicKey, digest[:], signature)
+}
@@ -0,0 +1,121 @@
+package channelclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newTestCA(t *testing.T, mspID string) *StandInCA {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca." + mspID},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          subjectKeyIdentifier(&key.PublicKey),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := NewStandInCA(mspID,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ca
+}
+
+func testMSP(ca *StandInCA) *channelMSP {
+
+	msp := &channelMSP{roots: x509.NewCertPool(), intermediates: x509.NewCertPool(), revoked: make(map[string]bool)}
+	msp.roots.AddCert(ca.certificate)
+
+	return msp
+}
+
+func TestVerifyEndorsement(t *testing.T) {
+
+	channelCA := newTestCA(t, "PeerMSP")
+	otherCA := newTestCA(t, "PeerMSP")
+
+	endorser, err := channelCA.issue("peer0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impostor, err := otherCA.issue("peer0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("proposal response payload")
+
+	sign := func(identity *standInIdentity) []byte {
+
+		signature, err := identity.Sign(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signature
+	}
+
+	block, _ := pem.Decode(endorser.certificate)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := testMSP(channelCA)
+	revoked.revoked[certificate.SerialNumber.String()] = true
+
+	tests := []struct {
+		name      string
+		identity  *standInIdentity
+		msps      map[string]*channelMSP
+		signature []byte
+		valid     bool
+	}{
+		{"issued by the channel MSP", endorser, map[string]*channelMSP{"PeerMSP": testMSP(channelCA)}, sign(endorser), true},
+		{"issued by another CA", impostor, map[string]*channelMSP{"PeerMSP": testMSP(channelCA)}, sign(impostor), false},
+		{"MSP not in the channel", endorser, map[string]*channelMSP{"OtherMSP": testMSP(channelCA)}, sign(endorser), false},
+		{"revoked", endorser, map[string]*channelMSP{"PeerMSP": revoked}, sign(endorser), false},
+		{"signature of another identity", endorser, map[string]*channelMSP{"PeerMSP": testMSP(channelCA)}, sign(impostor), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			identity := &Identity{MSPID: test.identity.mspID, Certificate: string(test.identity.certificate)}
+
+			if valid := verifyEndorsement(identity, test.msps, message, test.signature); valid != test.valid {
+				t.Fatalf("verifyEndorsement() = %v, want %v", valid, test.valid)
+			}
+		})
+	}
+}
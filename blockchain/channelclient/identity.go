@@ -0,0 +1,234 @@
+package channelclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	mspApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+)
+
+// AccountAttribute is added to every enrollment certificate - the chaincode reads the acting account from it
+const AccountAttribute = "cerberus.publicID"
+
+// RoleAttribute with AdminRole marks the service identity of an org - Config.User
+// the chaincode lets the service identity of the admin org act for every account and ignores the role in other orgs,
+// identities without it act only for their own account, and only when their org holds its record
+const (
+	RoleAttribute = "cerberus.role"
+	AdminRole     = "admin"
+)
+
+// CertificateAuthority registers and enrolls the account identities
+type CertificateAuthority interface {
+	Register(enrollmentID string, attributes map[string]string) (string, error)
+	Enroll(enrollmentID, secret string) error
+	Revoke(enrollmentID, reason string) error
+
+	// identity of an enrolled account - the certificate carries AccountAttribute
+	SigningIdentity(enrollmentID string) (mspApi.SigningIdentity, error)
+}
+
+type actingAccountKey struct{}
+
+// WithActingAccount returns a context whose calls are signed by the identity of the account
+func WithActingAccount(ctx context.Context, accountPublicID string) context.Context {
+
+	return context.WithValue(ctx, actingAccountKey{}, accountPublicID)
+}
+
+// ActingAccount returns the account set by WithActingAccount
+func ActingAccount(ctx context.Context) (string, bool) {
+
+	accountPublicID, ok := ctx.Value(actingAccountKey{}).(string)
+
+	return accountPublicID, ok && accountPublicID != ""
+}
+
+func enrollmentID(accountPublicID string) string {
+
+	return "account-" + accountPublicID
+}
+
+// accountIdentities enrolls accounts and keeps one channel client per enrolled identity
+type accountIdentities struct {
+	sdk       *fabsdk.FabricSDK
+	channelID string
+	ca        CertificateAuthority
+	wallet    Wallet
+
+	mutex   sync.Mutex
+	clients map[string]*channel.Client
+}
+
+func newAccountIdentities(sdk *fabsdk.FabricSDK, config Config) (*accountIdentities, error) {
+
+	ca := config.CertificateAuthority
+	if ca == nil {
+		fabricCA, err := newFabricCA(sdk, config)
+		if err != nil {
+			return nil, err
+		}
+
+		ca = fabricCA
+	}
+
+	wallet := config.Wallet
+	if wallet == nil {
+		wallet = NewMemoryWallet()
+	}
+
+	return &accountIdentities{
+		sdk:       sdk,
+		channelID: config.ChannelID,
+		ca:        ca,
+		wallet:    wallet,
+		clients:   make(map[string]*channel.Client),
+	}, nil
+}
+
+// enroll registers the account with the CA once and stores the enrollment in the wallet
+func (identities *accountIdentities) enroll(accountPublicID string) error {
+
+	if _, err := identities.wallet.Get(accountPublicID); err == nil {
+		return nil
+	} else if err != ErrEnrollmentNotFound {
+		return err
+	}
+
+	id := enrollmentID(accountPublicID)
+
+	secret, err := identities.ca.Register(id, map[string]string{AccountAttribute: accountPublicID})
+	if err != nil {
+		return fmt.Errorf("Unable to register account %s: %w", accountPublicID, err)
+	}
+
+	if err = identities.ca.Enroll(id, secret); err != nil {
+		return fmt.Errorf("Unable to enroll account %s: %w", accountPublicID, err)
+	}
+
+	// the secret is used once, the wallet keeps the certificate and the reference to the key
+	signingIdentity, err := identities.ca.SigningIdentity(id)
+	if err != nil {
+		return fmt.Errorf("Unable to load the identity of account %s: %w", accountPublicID, err)
+	}
+
+	return identities.wallet.Put(&Enrollment{
+		AccountPublicID: accountPublicID,
+		EnrollmentID:    id,
+		Certificate:     string(signingIdentity.EnrollmentCertificate()),
+		KeyID:           hex.EncodeToString(signingIdentity.PrivateKey().SKI()),
+		EnrolledAt:      time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// revoke ends the identity of a deleted account
+func (identities *accountIdentities) revoke(accountPublicID string) error {
+
+	enrollment, err := identities.wallet.Get(accountPublicID)
+	if err == ErrEnrollmentNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = identities.ca.Revoke(enrollment.EnrollmentID, "account deleted"); err != nil {
+		return err
+	}
+
+	identities.mutex.Lock()
+	delete(identities.clients, accountPublicID)
+	identities.mutex.Unlock()
+
+	return identities.wallet.Remove(accountPublicID)
+}
+
+// channelClient returns the client signing as the account
+func (identities *accountIdentities) channelClient(accountPublicID string) (*channel.Client, error) {
+
+	identities.mutex.Lock()
+	defer identities.mutex.Unlock()
+
+	if client, ok := identities.clients[accountPublicID]; ok {
+		return client, nil
+	}
+
+	enrollment, err := identities.wallet.Get(accountPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	signingIdentity, err := identities.ca.SigningIdentity(enrollment.EnrollmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := channel.New(identities.sdk.ChannelContext(identities.channelID, fabsdk.WithIdentity(signingIdentity)))
+	if err != nil {
+		return nil, err
+	}
+
+	identities.clients[accountPublicID] = client
+
+	return client, nil
+}
+
+// fabricCA is the Fabric CA of the configured org, registration uses the registrar from the sdk config
+type fabricCA struct {
+	client      *msp.Client
+	affiliation string
+}
+
+func newFabricCA(sdk *fabsdk.FabricSDK, config Config) (*fabricCA, error) {
+
+	options := []msp.ClientOption{msp.WithOrg(config.Org)}
+	if config.CAName != "" {
+		options = append(options, msp.WithCAInstance(config.CAName))
+	}
+
+	client, err := msp.New(sdk.Context(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fabricCA{client: client, affiliation: config.Affiliation}, nil
+}
+
+func (ca *fabricCA) Register(enrollmentID string, attributes map[string]string) (string, error) {
+
+	request := &msp.RegistrationRequest{
+		Name:        enrollmentID,
+		Type:        "client",
+		Affiliation: ca.affiliation,
+	}
+
+	for name, value := range attributes {
+		request.Attributes = append(request.Attributes, msp.Attribute{Name: name, Value: value, ECert: true})
+	}
+
+	return ca.client.Register(request)
+}
+
+func (ca *fabricCA) Enroll(enrollmentID, secret string) error {
+
+	return ca.client.Enroll(enrollmentID, msp.WithSecret(secret))
+}
+
+func (ca *fabricCA) Revoke(enrollmentID, reason string) error {
+
+	_, err := ca.client.Revoke(&msp.RevocationRequest{Name: enrollmentID, Reason: reason})
+
+	return err
+}
+
+func (ca *fabricCA) SigningIdentity(enrollmentID string) (mspApi.SigningIdentity, error) {
+
+	return ca.client.GetSigningIdentity(enrollmentID)
+}
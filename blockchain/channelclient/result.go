@@ -0,0 +1,101 @@
+package channelclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// TxResult describes a committed transaction
+type TxResult struct {
+	TxID            string
+	ChaincodeStatus int32
+	ValidationCode  string
+
+	// 0 when the block could not be looked up
+	BlockNumber uint64
+
+	// peers which endorsed the proposal
+	Endorsers []string
+
+	// chaincode response - the stored record for most functions
+	Payload []byte
+}
+
+// Valid reports whether the committing peers accepted the transaction
+func (result *TxResult) Valid() bool {
+
+	return result.ValidationCode == pb.TxValidationCode_VALID.String()
+}
+
+// Decode unmarshals the JSON payload of the chaincode response into v
+func (result *TxResult) Decode(v interface{}) error {
+
+	if err := json.Unmarshal(result.Payload, v); err != nil {
+		return fmt.Errorf("Unable to decode the payload of transaction %s: %w", result.TxID, err)
+	}
+
+	return nil
+}
+
+// newTxResult checks the chaincode status and looks up the block of the transaction
+func (client *Client) newTxResult(ctx context.Context, response channel.Response) (*TxResult, error) {
+
+	if response.ChaincodeStatus >= 400 {
+		return nil, NewChaincodeError(response.ChaincodeStatus, string(response.Payload))
+	}
+
+	result := &TxResult{
+		TxID:            string(response.TransactionID),
+		ChaincodeStatus: response.ChaincodeStatus,
+		ValidationCode:  response.TxValidationCode.String(),
+		Payload:         response.Payload,
+	}
+
+	for _, proposalResponse := range response.Responses {
+		result.Endorsers = append(result.Endorsers, proposalResponse.Endorser)
+	}
+
+	if !result.Valid() {
+		return result, fmt.Errorf("Transaction %s was invalidated: %s", result.TxID, result.ValidationCode)
+	}
+
+	blockNumber, err := client.blockNumber(ctx, result.TxID)
+	if err != nil {
+		// the transaction is committed, only the block number is missing
+		fmt.Println("Unable to look up block of transaction " + result.TxID + ": " + err.Error())
+		return result, nil
+	}
+
+	result.BlockNumber = blockNumber
+
+	return result, nil
+}
+
+func (client *Client) blockNumber(ctx context.Context, txID string) (uint64, error) {
+
+	ledgerClient, err := client.ledger()
+	if err != nil {
+		return 0, err
+	}
+
+	block, err := ledgerClient.QueryBlockByTxID(fab.TransactionID(txID), client.ledgerOptions(ctx)...)
+	if err != nil {
+		return 0, requestError(ctx, err)
+	}
+
+	return block.Header.Number, nil
+}
+
+func (client *Client) ledgerOptions(ctx context.Context) []ledger.RequestOption {
+
+	return []ledger.RequestOption{
+		ledger.WithTargetEndpoints(client.config.Peers...),
+		ledger.WithParentContext(ctx),
+	}
+}
@@ -0,0 +1,27 @@
+package channelclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTxResultDecode(t *testing.T) {
+
+	result := &TxResult{TxID: "tx1", Payload: []byte(`[{"function":"deleteAccount","payload":"{}"}]`)}
+
+	operations, err := ParseBatchResults(result)
+	if err != nil {
+		t.Fatalf("ParseBatchResults() = %v", err)
+	}
+
+	if len(operations) != 1 || operations[0].Function != "deleteAccount" {
+		t.Fatalf("ParseBatchResults() = %+v", operations)
+	}
+
+	result.Payload = []byte("Account deleted")
+
+	var decoded map[string]string
+	if err = result.Decode(&decoded); err == nil || !strings.Contains(err.Error(), "tx1") {
+		t.Fatalf("Decode() of a text payload = %v, want an error naming the transaction", err)
+	}
+}
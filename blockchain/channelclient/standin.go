@@ -0,0 +1,339 @@
+package channelclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	mspApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	mspProto "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+// certificate extension the Fabric CA puts the attributes in, read by the chaincode through cid
+var attributesExtension = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+const standInCertificateValidity = 365 * 24 * time.Hour
+
+// StandInCA issues the account identities from a local CA key pair instead of a Fabric CA - for local networks
+// the key pair must be a CA of the org MSP, e.g. the one cryptogen generated, so the peers accept the identities
+// registrations live in memory and revoked identities are not published in a CRL
+type StandInCA struct {
+	mspID       string
+	certificate *x509.Certificate
+	key         crypto.Signer
+
+	mutex      sync.Mutex
+	secrets    map[string]string
+	attributes map[string]map[string]string
+	identities map[string]*standInIdentity
+}
+
+// NewStandInCA - PEM encoded CA certificate and private key of the org with the MSP ID
+func NewStandInCA(mspID string, certificatePEM, keyPEM []byte) (*StandInCA, error) {
+
+	if mspID == "" {
+		return nil, errors.New("MSP ID cannot be an empty string")
+	}
+
+	certificateBlock, _ := pem.Decode(certificatePEM)
+	if certificateBlock == nil {
+		return nil, errors.New("CA certificate is not PEM encoded")
+	}
+
+	certificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !certificate.IsCA {
+		return nil, errors.New("Certificate is not a CA certificate")
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("CA private key is not PEM encoded")
+	}
+
+	key, err := parseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StandInCA{
+		mspID:       mspID,
+		certificate: certificate,
+		key:         key,
+		secrets:     make(map[string]string),
+		attributes:  make(map[string]map[string]string),
+		identities:  make(map[string]*standInIdentity),
+	}, nil
+}
+
+// cryptogen writes PKCS #8 keys, other tools SEC 1 keys
+func parseECPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("CA private key is not an ECDSA key")
+	}
+
+	return ecKey, nil
+}
+
+func (ca *StandInCA) Register(enrollmentID string, attributes map[string]string) (string, error) {
+
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	if _, ok := ca.secrets[enrollmentID]; ok {
+		return "", errors.New("Identity " + enrollmentID + " is already registered")
+	}
+
+	secretBytes := make([]byte, 16)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", err
+	}
+
+	secret := hex.EncodeToString(secretBytes)
+	ca.secrets[enrollmentID] = secret
+	ca.attributes[enrollmentID] = attributes
+
+	return secret, nil
+}
+
+// Enroll issues a certificate with the registered attributes for a new key
+func (ca *StandInCA) Enroll(enrollmentID, secret string) error {
+
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	if registered, ok := ca.secrets[enrollmentID]; !ok || registered != secret {
+		return errors.New("Invalid secret for identity " + enrollmentID)
+	}
+
+	identity, err := ca.issue(enrollmentID, ca.attributes[enrollmentID])
+	if err != nil {
+		return err
+	}
+
+	ca.identities[enrollmentID] = identity
+
+	return nil
+}
+
+func (ca *StandInCA) Revoke(enrollmentID, reason string) error {
+
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	delete(ca.secrets, enrollmentID)
+	delete(ca.attributes, enrollmentID)
+	delete(ca.identities, enrollmentID)
+
+	return nil
+}
+
+func (ca *StandInCA) SigningIdentity(enrollmentID string) (mspApi.SigningIdentity, error) {
+
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	identity, ok := ca.identities[enrollmentID]
+	if !ok {
+		return nil, errors.New("Identity " + enrollmentID + " is not enrolled")
+	}
+
+	return identity, nil
+}
+
+// issue - client certificate in the layout of the Fabric CA, attributes included
+func (ca *StandInCA) issue(enrollmentID string, attributes map[string]string) (*standInIdentity, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	attributesValue, err := json.Marshal(map[string]map[string]string{"attrs": attributes})
+	if err != nil {
+		return nil, err
+	}
+
+	ski := subjectKeyIdentifier(&key.PublicKey)
+	now := time.Now()
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: enrollmentID, OrganizationalUnit: []string{"client"}},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(standInCertificateValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		SubjectKeyId:          ski,
+		AuthorityKeyId:        ca.certificate.SubjectKeyId,
+		ExtraExtensions:       []pkix.Extension{{Id: attributesExtension, Value: attributesValue}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &standInIdentity{
+		mspID:       ca.mspID,
+		id:          enrollmentID,
+		certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:         &standInKey{privateKey: key, publicKey: &key.PublicKey, ski: ski},
+	}, nil
+}
+
+// subject key identifier as computed by Fabric - hash of the uncompressed public point
+func subjectKeyIdentifier(publicKey *ecdsa.PublicKey) []byte {
+
+	hash := sha256.Sum256(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+
+	return hash[:]
+}
+
+// standInIdentity signs like a Fabric identity: ECDSA over the SHA-256 digest with a low S
+type standInIdentity struct {
+	mspID       string
+	id          string
+	certificate []byte
+	key         *standInKey
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func (identity *standInIdentity) Identifier() *mspApi.IdentityIdentifier {
+
+	return &mspApi.IdentityIdentifier{MSPID: identity.mspID, ID: identity.id}
+}
+
+func (identity *standInIdentity) Verify(msg []byte, sig []byte) error {
+
+	signature := &ecdsaSignature{}
+	if _, err := asn1.Unmarshal(sig, signature); err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(msg)
+	if !ecdsa.Verify(identity.key.publicKey, digest[:], signature.R, signature.S) {
+		return errors.New("Invalid signature of identity " + identity.id)
+	}
+
+	return nil
+}
+
+func (identity *standInIdentity) Serialize() ([]byte, error) {
+
+	return proto.Marshal(&mspProto.SerializedIdentity{Mspid: identity.mspID, IdBytes: identity.certificate})
+}
+
+func (identity *standInIdentity) EnrollmentCertificate() []byte {
+
+	return identity.certificate
+}
+
+func (identity *standInIdentity) Sign(msg []byte) ([]byte, error) {
+
+	if identity.key.privateKey == nil {
+		return nil, errors.New("Identity " + identity.id + " cannot sign")
+	}
+
+	digest := sha256.Sum256(msg)
+
+	r, s, err := ecdsa.Sign(rand.Reader, identity.key.privateKey, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// the peers reject signatures with a high S
+	order := identity.key.privateKey.Params().N
+	if s.Cmp(new(big.Int).Rsh(order, 1)) > 0 {
+		s.Sub(order, s)
+	}
+
+	return asn1.Marshal(ecdsaSignature{R: r, S: s})
+}
+
+func (identity *standInIdentity) PublicVersion() mspApi.Identity {
+
+	return &standInIdentity{
+		mspID:       identity.mspID,
+		id:          identity.id,
+		certificate: identity.certificate,
+		key:         &standInKey{publicKey: identity.key.publicKey, ski: identity.key.ski},
+	}
+}
+
+func (identity *standInIdentity) PrivateKey() core.Key {
+
+	return identity.key
+}
+
+// standInKey - the private key never leaves the process
+type standInKey struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  *ecdsa.PublicKey
+	ski        []byte
+}
+
+func (key *standInKey) Bytes() ([]byte, error) {
+
+	if key.privateKey != nil {
+		return nil, errors.New("Private key cannot be exported")
+	}
+
+	return x509.MarshalPKIXPublicKey(key.publicKey)
+}
+
+func (key *standInKey) SKI() []byte {
+
+	return key.ski
+}
+
+func (key *standInKey) Symmetric() bool {
+
+	return false
+}
+
+func (key *standInKey) Private() bool {
+
+	return key.privateKey != nil
+}
+
+func (key *standInKey) PublicKey() (core.Key, error) {
+
+	return &standInKey{publicKey: key.publicKey, ski: key.ski}, nil
+}
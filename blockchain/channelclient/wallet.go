@@ -0,0 +1,170 @@
+package channelclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrEnrollmentNotFound = errors.New("No enrollment exists for the account")
+
+// Enrollment links an account to its Fabric identity
+// the private key stays in the sdk key store, KeyID is its subject key identifier
+// the enrollment secret is never kept
+type Enrollment struct {
+	AccountPublicID string `json:"accountPublicID"`
+	EnrollmentID    string `json:"enrollmentID"`
+	Certificate     string `json:"certificate"`
+	KeyID           string `json:"keyID"`
+	EnrolledAt      string `json:"enrolledAt"`
+}
+
+// enrollment files written before the secret was dropped
+type legacyEnrollment struct {
+	Enrollment
+	Secret string `json:"secret"`
+}
+
+// Wallet stores the enrollments of the accounts
+type Wallet interface {
+	Get(accountPublicID string) (*Enrollment, error)
+	Put(enrollment *Enrollment) error
+	Remove(accountPublicID string) error
+}
+
+// FileWallet keeps one JSON file per account, readable by the owner only
+type FileWallet struct {
+	root  string
+	mutex sync.Mutex
+}
+
+func NewFileWallet(root string) (*FileWallet, error) {
+
+	if root == "" {
+		return nil, errors.New("Wallet path cannot be an empty string")
+	}
+
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+
+	return &FileWallet{root: root}, nil
+}
+
+func (wallet *FileWallet) Get(accountPublicID string) (*Enrollment, error) {
+
+	wallet.mutex.Lock()
+	defer wallet.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(wallet.path(accountPublicID))
+	if os.IsNotExist(err) {
+		return nil, ErrEnrollmentNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	legacy := &legacyEnrollment{}
+	if err = json.Unmarshal(data, legacy); err != nil {
+		return nil, err
+	}
+
+	// a stored secret -> rewrite the file without it
+	if legacy.Secret != "" {
+		if err = wallet.write(&legacy.Enrollment); err != nil {
+			return nil, err
+		}
+	}
+
+	return &legacy.Enrollment, nil
+}
+
+func (wallet *FileWallet) Put(enrollment *Enrollment) error {
+
+	wallet.mutex.Lock()
+	defer wallet.mutex.Unlock()
+
+	return wallet.write(enrollment)
+}
+
+func (wallet *FileWallet) write(enrollment *Enrollment) error {
+
+	data, err := json.Marshal(enrollment)
+	if err != nil {
+		return err
+	}
+
+	// write -> rename, a crash never leaves a partial enrollment
+	temporaryPath := wallet.path(enrollment.AccountPublicID) + ".tmp"
+	if err = ioutil.WriteFile(temporaryPath, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, wallet.path(enrollment.AccountPublicID))
+}
+
+func (wallet *FileWallet) Remove(accountPublicID string) error {
+
+	wallet.mutex.Lock()
+	defer wallet.mutex.Unlock()
+
+	err := os.Remove(wallet.path(accountPublicID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (wallet *FileWallet) path(accountPublicID string) string {
+
+	return filepath.Join(wallet.root, filepath.Base(accountPublicID)+".json")
+}
+
+// MemoryWallet loses the enrollments on restart - for local tests
+type MemoryWallet struct {
+	mutex       sync.Mutex
+	enrollments map[string]Enrollment
+}
+
+func NewMemoryWallet() *MemoryWallet {
+
+	return &MemoryWallet{enrollments: make(map[string]Enrollment)}
+}
+
+func (wallet *MemoryWallet) Get(accountPublicID string) (*Enrollment, error) {
+
+	wallet.mutex.Lock()
+	defer wallet.mutex.Unlock()
+
+	enrollment, ok := wallet.enrollments[accountPublicID]
+	if !ok {
+		return nil, ErrEnrollmentNotFound
+	}
+
+	return &enrollment, nil
+}
+
+func (wallet *MemoryWallet) Put(enrollment *Enrollment) error {
+
+	wallet.mutex.Lock()
+	defer wallet.mutex.Unlock()
+
+	wallet.enrollments[enrollment.AccountPublicID] = *enrollment
+
+	return nil
+}
+
+func (wallet *MemoryWallet) Remove(accountPublicID string) error {
+
+	wallet.mutex.Lock()
+	defer wallet.mutex.Unlock()
+
+	delete(wallet.enrollments, accountPublicID)
+
+	return nil
+}
@@ -0,0 +1,57 @@
+// Package instaccntschannel is the client of the institution accounts channel
+// the chaincode functions are added on top of the generic channel client as the institution flows are built
+package instaccntschannel
+
+import (
+	"cerberus/blockchain/channelclient"
+	"os"
+)
+
+const (
+	InstitutionAccountsChannelID        = "instaccntschannel"
+	InstitutionAccountsChannelChainCode = "instaccntschannelcc"
+
+	SipherOrg   = "Sipher"
+	WhiteBoxOrg = "WhiteBox"
+
+	WhiteBoxAdmin = "Admin"
+	WhiteBoxUser  = "User1"
+
+	AnchorPrSipher   = "anchorpr.sipher.cerberus.dev"
+	AnchorPrWhiteBox = "anchorpr.whitebox.cerberus.dev"
+)
+
+type Config = channelclient.Config
+
+type TxResult = channelclient.TxResult
+
+func DefaultConfig() Config {
+
+	return Config{
+		ConfigFile:  os.Getenv("GOPATH") + "/src/cerberus/hl/config.yaml",
+		ChannelID:   InstitutionAccountsChannelID,
+		ChaincodeID: InstitutionAccountsChannelChainCode,
+		Org:         WhiteBoxOrg,
+		User:        WhiteBoxUser,
+		Discovery:   true,
+		Peers:       []string{AnchorPrWhiteBox, AnchorPrSipher},
+		QueryPeers:  []string{AnchorPrWhiteBox, AnchorPrSipher},
+		Retry:       channelclient.DefaultRetryPolicy(),
+	}
+}
+
+// CerberusClient is the channel client of the institution accounts chaincode
+// a single client is safe for concurrent use
+type CerberusClient struct {
+	*channelclient.Client
+}
+
+func New(config Config) (*CerberusClient, error) {
+
+	client, err := channelclient.New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CerberusClient{Client: client}, nil
+}
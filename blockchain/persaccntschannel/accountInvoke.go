@@ -1,124 +1,121 @@
 package persaccntschannel
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 )
 
-func (persAccntsChannelClient *CerberusClient) CreateAccount(publicID string, accountObject []byte) ([]string, []byte, error) {
+// CreateAccount enrolls the identity of the account first when account identities are on
+// the account signs its own creation, the record goes in the transient map to the private data collection of the org
+func (persAccntsChannelClient *CerberusClient) CreateAccount(ctx context.Context, publicID string, accountObject []byte) (*TxResult, error) {
 
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return nil, nil, err
-	}
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
+	// identity -> enroll
+	ctx, err := persAccntsChannelClient.EnrollAccount(ctx, publicID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// request -> prepare
-	var args [][]byte
-	args = append(args, []byte(publicID))
-	args = append(args, accountObject)
-
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
-		Fcn:         "createAccount",
-		Args:        args,
+		ChaincodeID:  persAccntsChannelClient.ChaincodeID(),
+		Fcn:          "createAccount",
+		Args:         [][]byte{[]byte(publicID)},
+		TransientMap: map[string][]byte{RecordTransientKey(publicID): accountObject},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Successfully updated records.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
-	}
-
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return persAccntsChannelClient.Execute(ctx, request)
 }
 
-func (persAccntsChannelClient *CerberusClient) DeleteAccount(publicId string) ([]string, []byte, error) {
-
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-	if err != nil {
-		return nil, nil, err
-	}
+func (persAccntsChannelClient *CerberusClient) DeleteAccount(ctx context.Context, publicId string) (*TxResult, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "deleteAccount",
 		Args:        [][]byte{[]byte(publicId)},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
+	result, err := persAccntsChannelClient.Execute(ctx, request)
 	if err != nil {
-		return nil, nil, err
+		return result, err
 	}
 
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Successfully updated records.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
+	// identity -> revoke, the account is gone already
+	if err = persAccntsChannelClient.RevokeAccount(publicId); err != nil {
+		fmt.Println("Unable to revoke identity of account " + publicId + ": " + err.Error())
 	}
 
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return result, nil
 }
 
-func (persAccntsChannelClient *CerberusClient) UpdateRecords(updateType, updateArgs []string) ([]string, []byte, error) {
+// UpdateAccount sets a field of an encrypted account
+// the passphrase and the value are sent in the transient map, they are never written to the ledger
+// the payload is the hash of the updated record
+func (persAccntsChannelClient *CerberusClient) UpdateAccount(ctx context.Context, publicID string, passphrase []byte, dataField, value string) (*TxResult, error) {
 
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-	if err != nil {
-		return nil, nil, err
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "updateRecords",
+		Args:        [][]byte{[]byte("updateAccount"), []byte(publicID), []byte(dataField)},
+		TransientMap: map[string][]byte{
+			PassphraseTransientKey(publicID): passphrase,
+			ValueTransientKey(publicID):      []byte(value),
+		},
 	}
-	defer sdkInstance.Close()
 
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-	if err != nil {
-		fmt.Println(err)
-		return nil, nil, err
-	}
+	return persAccntsChannelClient.Execute(ctx, request)
+}
+
+// UpdateDocumentRecords replaces the account record, the record is sent in the transient map
+// the payload is the hash of the record
+func (persAccntsChannelClient *CerberusClient) UpdateDocumentRecords(ctx context.Context, publicID string, record []byte) (*TxResult, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
-		Fcn:         "updateRecords",
-		Args:        updateArgs,
+		ChaincodeID:  persAccntsChannelClient.ChaincodeID(),
+		Fcn:          "updateRecords",
+		Args:         [][]byte{[]byte("updateDocumentRecords"), []byte(publicID)},
+		TransientMap: map[string][]byte{RecordTransientKey(publicID): record},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
+	return persAccntsChannelClient.Execute(ctx, request)
+}
 
-	if err != nil {
-		return nil, nil, err
+// UpdateRecords sends the update without secrets - use UpdateAccount and UpdateDocumentRecords for account records
+func (persAccntsChannelClient *CerberusClient) UpdateRecords(ctx context.Context, updateType string, updateArgs []string) (*TxResult, error) {
+
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "updateRecords",
+		Args:        [][]byte{[]byte(updateType)},
 	}
 
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Successfully updated records.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
+	for _, updateArg := range updateArgs {
+		request.Args = append(request.Args, []byte(updateArg))
 	}
 
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return persAccntsChannelClient.Execute(ctx, request)
+}
+
+// PassphraseTransientKey is the transient map key of the passphrase of an account
+// chaincode args are kept on the ledger, secrets are passed in the transient map only
+func PassphraseTransientKey(publicID string) string {
+
+	return "passphrase/" + publicID
+}
+
+// RecordTransientKey is the transient map key of an account record kept in a private data collection
+func RecordTransientKey(publicID string) string {
+
+	return "record/" + publicID
+}
+
+// ValueTransientKey is the transient map key of the new value of an account field
+func ValueTransientKey(publicID string) string {
+
+	return "value/" + publicID
 }
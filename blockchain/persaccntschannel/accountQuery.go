@@ -1,82 +1,73 @@
 package persaccntschannel
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 )
 
-func (persAccntsChannelClient *CerberusClient) QueryRecords(selectorKey, selectorValue string) (string, error) {
-
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return "", err
-	}
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-
-	if err != nil {
-		return "", err
-	}
+// account records are read from the private data collections - on the private peers
+func (persAccntsChannelClient *CerberusClient) QueryRecords(ctx context.Context, selectorKey, selectorValue string) (string, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "queryRecords",
 		Args:        [][]byte{[]byte(selectorKey), []byte(selectorValue)},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Query(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
+	payload, err := persAccntsChannelClient.QueryPrivate(ctx, request)
 	if err != nil {
 		return "", err
 	}
 
-	if len(response.Payload) < 5 { // small random number of bytes
+	if len(payload) < 5 { // small random number of bytes
 		fmt.Println("No records with " + selectorKey + ":" + selectorValue + " exist.")
 		return "", nil
 	}
 
-	return string(response.Payload), nil
+	return string(payload), nil
 }
 
-func (persAccntsChannelClient *CerberusClient) QueryAccountData(queryType, publicId string) (string, error) {
-
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
+// returns one page of person account records and the bookmark for the next page
+// empty bookmark starts from the beginning, ErrPrivateDataUnavailable when the peer does not hold a record of the page
+func (persAccntsChannelClient *CerberusClient) QueryAccounts(ctx context.Context, pageSize int, bookmark string) (string, error) {
 
-	defer sdkInstance.Close()
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "queryAccounts",
+		Args:        [][]byte{[]byte(strconv.Itoa(pageSize)), []byte(bookmark)},
+	}
 
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
+	payload, err := persAccntsChannelClient.QueryPrivate(ctx, request)
 	if err != nil {
-		fmt.Println(err)
 		return "", err
 	}
 
+	return string(payload), nil
+}
+
+func (persAccntsChannelClient *CerberusClient) QueryAccountData(ctx context.Context, queryType, publicId string) (string, error) {
+
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "queryAccountData",
 		Args:        [][]byte{[]byte(queryType), []byte(publicId)},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Query(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
+	payload, err := persAccntsChannelClient.QueryPrivate(ctx, request)
 	if err != nil {
 		return "", err
 	}
 
-	if len(response.Payload) < 5 { // small random number of bytes
+	if len(payload) < 5 { // small random number of bytes
 		fmt.Println("No records with id: " + publicId + " exist.")
 		return "", nil
 	}
 
-	return string(response.Payload), nil
+	return string(payload), nil
 }
@@ -0,0 +1,206 @@
+package persaccntschannel
+
+import (
+	"cerberus/blockchain/channelclient"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// operations accepted by the chaincode in one batch
+const BatchMaxOperations = channelclient.BatchMaxOperations
+
+type BatchOperation = channelclient.BatchOperation
+type BatchOperationResult = channelclient.BatchOperationResult
+type BatchResult = channelclient.BatchResult
+
+// Batch collects operations applied in a single transaction - all of them or none
+// reads of an operation see the state before the batch, so an account or a request can be written by one operation only
+// and a record written by one operation cannot be used by a later one, e.g. a request to an account created in the batch -
+// Validate fails for such batches with ErrConflict, the chaincode refuses them with ErrConflict as well
+type Batch struct {
+	operations []BatchOperation
+
+	// secrets of the operations, sent in the transient map
+	transientMap map[string][]byte
+
+	// record key -> operation which writes it, the first repeated or dependent record fails the batch
+	records map[string]int
+	err     error
+}
+
+func NewBatch() *Batch {
+
+	return &Batch{transientMap: make(map[string][]byte), records: make(map[string]int)}
+}
+
+// Len returns the number of operations
+func (batch *Batch) Len() int {
+
+	return len(batch.operations)
+}
+
+// Operations returns a copy of the operations
+func (batch *Batch) Operations() []BatchOperation {
+
+	return append([]BatchOperation(nil), batch.operations...)
+}
+
+// TransientMap returns a copy of the secrets of the operations
+func (batch *Batch) TransientMap() map[string][]byte {
+
+	transientMap := make(map[string][]byte, len(batch.transientMap))
+	for key, value := range batch.transientMap {
+		transientMap[key] = value
+	}
+
+	return transientMap
+}
+
+func (batch *Batch) transient(key string, value []byte) {
+
+	if batch.transientMap == nil {
+		batch.transientMap = make(map[string][]byte)
+	}
+
+	batch.transientMap[key] = value
+}
+
+// add appends the operation writing the record with the key and reading the other records, empty keys are left to the chaincode
+func (batch *Batch) add(record string, reads []string, function string, args ...string) *Batch {
+
+	if batch.records == nil {
+		batch.records = make(map[string]int)
+	}
+
+	for _, read := range reads {
+		if operation, ok := batch.records[read]; ok && read != "" && batch.err == nil {
+			batch.err = fmt.Errorf("%w: operation %d of the batch reads record %s written by operation %d, reads see the state before the batch",
+				ErrConflict, len(batch.operations), read, operation)
+		}
+	}
+
+	if record != "" {
+		if operation, ok := batch.records[record]; ok && batch.err == nil {
+			batch.err = fmt.Errorf("%w: operations %d and %d of the batch write record %s", ErrConflict, operation, len(batch.operations), record)
+		}
+
+		batch.records[record] = len(batch.operations)
+	}
+
+	batch.operations = append(batch.operations, BatchOperation{Function: function, Args: args})
+
+	return batch
+}
+
+// requestRecords returns the key of a new request and the accounts it reads
+func requestRecords(newRequest []byte) (string, []string) {
+
+	request := struct {
+		PublicID          string `json:"publicID"`
+		RequesterPublicID string `json:"requesterPublicID"`
+		RecipientPublicID string `json:"recipientPublicID"`
+	}{}
+
+	if err := json.Unmarshal(newRequest, &request); err != nil {
+		return "", nil
+	}
+
+	return request.PublicID, []string{request.RequesterPublicID, request.RecipientPublicID}
+}
+
+// CreateAccount adds the account, the record goes in the transient map
+func (batch *Batch) CreateAccount(publicID string, accountObject []byte) *Batch {
+
+	batch.transient(RecordTransientKey(publicID), accountObject)
+
+	return batch.add(publicID, nil, "createAccount", publicID)
+}
+
+// UpdateRecords - the first update arg is the publicID of the account
+func (batch *Batch) UpdateRecords(updateType string, updateArgs []string) *Batch {
+
+	var record string
+	if len(updateArgs) > 0 {
+		record = updateArgs[0]
+	}
+
+	return batch.add(record, nil, "updateRecords", append([]string{updateType}, updateArgs...)...)
+}
+
+// UpdateAccount sets a field of an encrypted account, the passphrase and the value go in the transient map
+func (batch *Batch) UpdateAccount(publicID string, passphrase []byte, dataField, value string) *Batch {
+
+	batch.transient(PassphraseTransientKey(publicID), passphrase)
+	batch.transient(ValueTransientKey(publicID), []byte(value))
+
+	return batch.add(publicID, nil, "updateRecords", "updateAccount", publicID, dataField)
+}
+
+// UpdateDocumentRecords replaces the account record, the record goes in the transient map
+func (batch *Batch) UpdateDocumentRecords(publicID string, record []byte) *Batch {
+
+	batch.transient(RecordTransientKey(publicID), record)
+
+	return batch.add(publicID, nil, "updateRecords", "updateDocumentRecords", publicID)
+}
+
+func (batch *Batch) DeleteAccount(publicID string) *Batch {
+
+	return batch.add(publicID, nil, "deleteAccount", publicID)
+}
+
+func (batch *Batch) CreateAccountDataRequest(newRequest, requestData []byte) *Batch {
+
+	record, reads := requestRecords(newRequest)
+
+	return batch.add(record, reads, "createRequest", "accountData", string(newRequest), string(requestData))
+}
+
+func (batch *Batch) CreateDocumentDataRequest(newRequest, requestData []byte) *Batch {
+
+	record, reads := requestRecords(newRequest)
+
+	return batch.add(record, reads, "createRequest", "documentData", string(newRequest), string(requestData))
+}
+
+func (batch *Batch) AcceptRequest(requestType, requestPublicID, recipientPublicID string, acceptedData []byte) *Batch {
+
+	return batch.add(requestPublicID, []string{recipientPublicID}, "acceptRequest", requestType, requestPublicID, recipientPublicID, string(acceptedData))
+}
+
+func (batch *Batch) RejectRequest(requestType, requestPublicID, recipientPublicID string) *Batch {
+
+	return batch.add(requestPublicID, []string{recipientPublicID}, "rejectRequest", requestType, requestPublicID, recipientPublicID)
+}
+
+func (batch *Batch) UpdateRequest(requestType, requestPublicID, requesterPublicID, recipientPublicID string, updatedData []byte) *Batch {
+
+	return batch.add(requestPublicID, []string{requesterPublicID, recipientPublicID}, "updateRequest", requestType, requestPublicID, requesterPublicID, recipientPublicID, string(updatedData))
+}
+
+// Validate checks the batch size and that no record is written by two operations or read after it was written before sending
+func (batch *Batch) Validate() error {
+
+	if batch.err != nil {
+		return batch.err
+	}
+
+	return channelclient.ValidateBatch(batch.operations)
+}
+
+// ParseBatchResults decodes the payload of a batchInvoke response
+func ParseBatchResults(result *TxResult) ([]BatchOperationResult, error) {
+
+	return channelclient.ParseBatchResults(result)
+}
+
+// ExecuteBatch applies the operations in one transaction, the error of the first failing operation fails the batch
+func (persAccntsChannelClient *CerberusClient) ExecuteBatch(ctx context.Context, batch *Batch) (*BatchResult, error) {
+
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	return persAccntsChannelClient.Client.ExecuteBatch(ctx, batch.operations, batch.transientMap)
+}
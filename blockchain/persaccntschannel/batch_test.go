@@ -0,0 +1,96 @@
+package persaccntschannel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBatchValidateRecords(t *testing.T) {
+
+	request := func(publicID string) []byte {
+		return []byte(`{"publicID":"` + publicID + `","requesterPublicID":"a1","recipientPublicID":"a2"}`)
+	}
+
+	tests := []struct {
+		name     string
+		batch    *Batch
+		conflict bool
+	}{
+		{
+			name:  "different accounts",
+			batch: NewBatch().UpdateDocumentRecords("a1", []byte("{}")).DeleteAccount("a2"),
+		},
+		{
+			name:  "account and request",
+			batch: NewBatch().UpdateDocumentRecords("a1", []byte("{}")).AcceptRequest("accountData", "r1", "a3", []byte("{}")),
+		},
+		{
+			name:  "account written after a request reads it",
+			batch: NewBatch().CreateAccountDataRequest(request("r1"), []byte("{}")).DeleteAccount("a1"),
+		},
+		{
+			name:     "account written twice",
+			batch:    NewBatch().UpdateAccount("a1", []byte("passphrase"), "firstName", "ann").DeleteAccount("a1"),
+			conflict: true,
+		},
+		{
+			name:     "account updated through update records",
+			batch:    NewBatch().CreateAccount("a1", []byte("{}")).UpdateRecords("updateDocumentRecords", []string{"a1"}),
+			conflict: true,
+		},
+		{
+			name:     "request written twice",
+			batch:    NewBatch().AcceptRequest("accountData", "r1", "a2", []byte("{}")).RejectRequest("accountData", "r1", "a2"),
+			conflict: true,
+		},
+		{
+			name:     "request to an account created in the batch",
+			batch:    NewBatch().CreateAccount("a2", []byte("{}")).CreateAccountDataRequest(request("r1"), []byte("{}")),
+			conflict: true,
+		},
+		{
+			name:     "request accepted by an account updated in the batch",
+			batch:    NewBatch().UpdateDocumentRecords("a1", []byte("{}")).AcceptRequest("accountData", "r1", "a1", []byte("{}")),
+			conflict: true,
+		},
+		{
+			name:     "new request written again",
+			batch:    NewBatch().CreateAccountDataRequest(request("r1"), []byte("{}")).UpdateRequest("accountData", "r1", "a1", "a2", []byte("{}")),
+			conflict: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			err := test.batch.Validate()
+
+			if !test.conflict {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("Validate() = %v, want ErrConflict", err)
+			}
+		})
+	}
+}
+
+func TestBatchValidateSize(t *testing.T) {
+
+	if err := NewBatch().Validate(); err == nil {
+		t.Fatal("Validate() of an empty batch succeeded")
+	}
+
+	batch := NewBatch()
+	for i := 0; i <= BatchMaxOperations; i++ {
+		batch.DeleteAccount(string(rune('a'+i%26)) + string(rune('a'+i/26)))
+	}
+
+	if err := batch.Validate(); err == nil || errors.Is(err, ErrConflict) {
+		t.Fatalf("Validate() of %d operations = %v, want a size error", batch.Len(), err)
+	}
+}
@@ -0,0 +1,36 @@
+package persaccntschannel
+
+import "cerberus/blockchain/channelclient"
+
+// kinds of chaincode errors - test with errors.Is
+var (
+	ErrNotFound        = channelclient.ErrNotFound
+	ErrAlreadyExists   = channelclient.ErrAlreadyExists
+	ErrInvalidArgument = channelclient.ErrInvalidArgument
+	ErrInvalidState    = channelclient.ErrInvalidState
+	ErrConflict        = channelclient.ErrConflict
+	ErrUnauthorized    = channelclient.ErrUnauthorized
+	ErrChaincode       = channelclient.ErrChaincode
+
+	ErrPrivateDataUnavailable = channelclient.ErrPrivateDataUnavailable
+)
+
+// status of the chaincode error responses, one per kind
+const (
+	StatusInvalidArgument        = channelclient.StatusInvalidArgument
+	StatusUnauthorized           = channelclient.StatusUnauthorized
+	StatusNotFound               = channelclient.StatusNotFound
+	StatusAlreadyExists          = channelclient.StatusAlreadyExists
+	StatusConflict               = channelclient.StatusConflict
+	StatusInvalidState           = channelclient.StatusInvalidState
+	StatusPrivateDataUnavailable = channelclient.StatusPrivateDataUnavailable
+)
+
+// ChaincodeError is an error returned by the chaincode through shim.Error
+type ChaincodeError = channelclient.ChaincodeError
+
+// NewChaincodeError returns the typed error of a chaincode status - for ledgers other than Fabric
+func NewChaincodeError(statusCode int32, message string) *ChaincodeError {
+
+	return channelclient.NewChaincodeError(statusCode, message)
+}
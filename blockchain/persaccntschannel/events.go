@@ -0,0 +1,206 @@
+package persaccntschannel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// chaincode event types
+const (
+	EventAccountCreated  = "AccountCreated"
+	EventAccountDeleted  = "AccountDeleted"
+	EventRequestCreated  = "RequestCreated"
+	EventRequestAccepted = "RequestAccepted"
+	EventRequestRejected = "RequestRejected"
+	EventRequestUpdated  = "RequestUpdated"
+	EventRequestExpired  = "RequestExpired"
+)
+
+// carries the events of the operations of a batch, delivered one by one
+const eventBatchCommitted = "BatchCommitted"
+
+var eventTypes = []string{
+	EventAccountCreated,
+	EventAccountDeleted,
+	EventRequestCreated,
+	EventRequestAccepted,
+	EventRequestRejected,
+	EventRequestUpdated,
+	EventRequestExpired,
+}
+
+// Event is a decoded chaincode event
+type Event struct {
+	Type              string `json:"type"`
+	AccountPublicID   string `json:"accountPublicID"`
+	RequestPublicID   string `json:"requestPublicID"`
+	RequestType       string `json:"requestType"`
+	RequesterPublicID string `json:"requesterPublicID"`
+	RecipientPublicID string `json:"recipientPublicID"`
+	Status            string `json:"status"`
+	TxID              string `json:"txID"`
+	Timestamp         string `json:"timestamp"`
+
+	// block the transaction was committed in - pass it as FromBlock to resume
+	BlockNumber uint64 `json:"-"`
+}
+
+// EventFilter selects the delivered events, empty fields match everything
+type EventFilter struct {
+	Types []string
+
+	// matches the account, the requester or the recipient of the event
+	AccountPublicID string
+	RequestPublicID string
+
+	// first block to replay, 0 starts with the next committed block
+	FromBlock uint64
+}
+
+func (filter EventFilter) pattern() (string, error) {
+
+	types := filter.Types
+	if len(types) == 0 {
+		types = eventTypes
+	}
+
+	quoted := make([]string, 0, len(types))
+
+	for _, eventType := range types {
+		if !isEventType(eventType) {
+			return "", fmt.Errorf("Unknown event type %s", eventType)
+		}
+
+		quoted = append(quoted, regexp.QuoteMeta(eventType))
+	}
+
+	// any batch may contain the selected types
+	quoted = append(quoted, regexp.QuoteMeta(eventBatchCommitted))
+
+	return "^(" + strings.Join(quoted, "|") + ")$", nil
+}
+
+func (filter EventFilter) matches(event *Event) bool {
+
+	if len(filter.Types) > 0 && !containsEventType(filter.Types, event.Type) {
+		return false
+	}
+
+	if filter.RequestPublicID != "" && event.RequestPublicID != filter.RequestPublicID {
+		return false
+	}
+
+	if filter.AccountPublicID != "" &&
+		event.AccountPublicID != filter.AccountPublicID &&
+		event.RequesterPublicID != filter.AccountPublicID &&
+		event.RecipientPublicID != filter.AccountPublicID {
+		return false
+	}
+
+	return true
+}
+
+func isEventType(eventType string) bool {
+
+	return containsEventType(eventTypes, eventType)
+}
+
+func containsEventType(types []string, eventType string) bool {
+
+	for _, known := range types {
+		if known == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Subscribe delivers the chaincode events selected by the filter until ctx ends or the client is closed
+// a broken connection is reopened from the block of the last delivered event, events are not repeated
+// the returned channel is closed when the subscription ends
+func (persAccntsChannelClient *CerberusClient) Subscribe(ctx context.Context, filter EventFilter) (<-chan Event, error) {
+
+	pattern, err := filter.pattern()
+	if err != nil {
+		return nil, err
+	}
+
+	chaincodeEvents, err := persAccntsChannelClient.SubscribeChaincodeEvents(ctx, pattern, filter.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event, cap(chaincodeEvents))
+
+	go func() {
+
+		defer close(events)
+
+		for chaincodeEvent := range chaincodeEvents {
+			for _, decoded := range decodeEvent(chaincodeEvent, filter) {
+				select {
+				case events <- decoded:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// decodeEvent skips malformed events, a batch event is split into the events of its operations
+// an event without payload - a filtered event - is delivered with its type, transaction and block only
+func decodeEvent(chaincodeEvent *fab.CCEvent, filter EventFilter) []Event {
+
+	var decoded []Event
+
+	if len(chaincodeEvent.Payload) == 0 {
+		if chaincodeEvent.EventName == eventBatchCommitted {
+			fmt.Println("Unable to split chaincode event " + chaincodeEvent.EventName + " of transaction " + chaincodeEvent.TxID + ": the event has no payload")
+			return nil
+		}
+
+		decoded = []Event{{Type: chaincodeEvent.EventName}}
+	} else if chaincodeEvent.EventName == eventBatchCommitted {
+		batch := struct {
+			Events []Event `json:"events"`
+		}{}
+
+		if err := json.Unmarshal(chaincodeEvent.Payload, &batch); err != nil {
+			fmt.Println("Unable to decode chaincode event " + chaincodeEvent.EventName + ": " + err.Error())
+			return nil
+		}
+
+		decoded = batch.Events
+	} else {
+		event := Event{}
+		if err := json.Unmarshal(chaincodeEvent.Payload, &event); err != nil {
+			fmt.Println("Unable to decode chaincode event " + chaincodeEvent.EventName + ": " + err.Error())
+			return nil
+		}
+
+		event.Type = chaincodeEvent.EventName
+		decoded = []Event{event}
+	}
+
+	matching := make([]Event, 0, len(decoded))
+
+	for _, event := range decoded {
+		event.TxID = chaincodeEvent.TxID
+		event.BlockNumber = chaincodeEvent.BlockNumber
+
+		if filter.matches(&event) {
+			matching = append(matching, event)
+		}
+	}
+
+	return matching
+}
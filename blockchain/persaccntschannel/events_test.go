@@ -0,0 +1,84 @@
+package persaccntschannel
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+func TestDecodeEvent(t *testing.T) {
+
+	blockEvent := &fab.CCEvent{
+		TxID:        "tx1",
+		EventName:   EventRequestCreated,
+		BlockNumber: 7,
+		Payload:     []byte(`{"requestPublicID":"r1","requesterPublicID":"a1","recipientPublicID":"a2","status":"pending"}`),
+	}
+
+	batchEvent := &fab.CCEvent{
+		TxID:        "tx2",
+		EventName:   eventBatchCommitted,
+		BlockNumber: 8,
+		Payload:     []byte(`{"events":[{"type":"AccountCreated","accountPublicID":"a1"},{"type":"AccountDeleted","accountPublicID":"a2"}]}`),
+	}
+
+	// filtered events of the event service carry no payload
+	filteredEvent := &fab.CCEvent{TxID: "tx3", EventName: EventRequestCreated, BlockNumber: 9}
+	filteredBatchEvent := &fab.CCEvent{TxID: "tx4", EventName: eventBatchCommitted, BlockNumber: 9}
+
+	tests := []struct {
+		name   string
+		event  *fab.CCEvent
+		filter EventFilter
+		want   []Event
+	}{
+		{
+			name:  "block event",
+			event: blockEvent,
+			want: []Event{{Type: EventRequestCreated, RequestPublicID: "r1", RequesterPublicID: "a1", RecipientPublicID: "a2",
+				Status: "pending", TxID: "tx1", BlockNumber: 7}},
+		},
+		{
+			name:   "block event of another account",
+			event:  blockEvent,
+			filter: EventFilter{AccountPublicID: "a3"},
+		},
+		{
+			name:   "batch event",
+			event:  batchEvent,
+			filter: EventFilter{AccountPublicID: "a2"},
+			want:   []Event{{Type: EventAccountDeleted, AccountPublicID: "a2", TxID: "tx2", BlockNumber: 8}},
+		},
+		{
+			name:  "filtered event",
+			event: filteredEvent,
+			want:  []Event{{Type: EventRequestCreated, TxID: "tx3", BlockNumber: 9}},
+		},
+		{
+			name:   "filtered event without the account",
+			event:  filteredEvent,
+			filter: EventFilter{AccountPublicID: "a1"},
+		},
+		{
+			name:  "filtered batch event",
+			event: filteredBatchEvent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			decoded := decodeEvent(test.event, test.filter)
+
+			if len(decoded) != len(test.want) {
+				t.Fatalf("decodeEvent() = %+v, want %+v", decoded, test.want)
+			}
+
+			for i := range decoded {
+				if decoded[i] != test.want[i] {
+					t.Fatalf("decodeEvent()[%d] = %+v, want %+v", i, decoded[i], test.want[i])
+				}
+			}
+		})
+	}
+}
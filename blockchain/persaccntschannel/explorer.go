@@ -0,0 +1,11 @@
+package persaccntschannel
+
+import "cerberus/blockchain/channelclient"
+
+// blocks and transactions of the channel - QueryChainInfo, QueryBlock, QueryTransaction and GetTransactionProof
+type ChainInfo = channelclient.ChainInfo
+type Block = channelclient.Block
+type Identity = channelclient.Identity
+type Endorsement = channelclient.Endorsement
+type Transaction = channelclient.Transaction
+type TransactionProof = channelclient.TransactionProof
@@ -0,0 +1,38 @@
+package persaccntschannel
+
+import (
+	"cerberus/blockchain/channelclient"
+	"context"
+)
+
+// AccountAttribute is added to every enrollment certificate - the chaincode reads the acting account from it
+const AccountAttribute = channelclient.AccountAttribute
+
+// RoleAttribute with AdminRole marks the service identity of an org
+const (
+	RoleAttribute = channelclient.RoleAttribute
+	AdminRole     = channelclient.AdminRole
+)
+
+// CertificateAuthority registers and enrolls the account identities
+type CertificateAuthority = channelclient.CertificateAuthority
+
+// StandInCA issues the account identities from a local CA key pair instead of a Fabric CA - for local networks
+type StandInCA = channelclient.StandInCA
+
+func NewStandInCA(mspID string, certificatePEM, keyPEM []byte) (*StandInCA, error) {
+
+	return channelclient.NewStandInCA(mspID, certificatePEM, keyPEM)
+}
+
+// WithActingAccount returns a context whose calls are signed by the identity of the account
+func WithActingAccount(ctx context.Context, accountPublicID string) context.Context {
+
+	return channelclient.WithActingAccount(ctx, accountPublicID)
+}
+
+// ActingAccount returns the account set by WithActingAccount
+func ActingAccount(ctx context.Context) (string, bool) {
+
+	return channelclient.ActingAccount(ctx)
+}
@@ -1,202 +1,118 @@
 package persaccntschannel
 
 import (
-	"fmt"
+	"context"
+	"strconv"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 )
 
-func (persAccntsChannelClient *CerberusClient) CreateAccountDataRequest(newRequest, requestData []byte) ([]string, []byte, error) {
-
-	//channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return nil, nil, err
-	}
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-
-	if err != nil {
-		return nil, nil, err
-	}
+func (persAccntsChannelClient *CerberusClient) CreateAccountDataRequest(ctx context.Context, newRequest, requestData []byte) (*TxResult, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "createRequest",
 		Args:        [][]byte{[]byte("accountData"), newRequest, requestData},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Request created successfully.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
-	}
-
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return persAccntsChannelClient.Execute(ctx, request)
 }
 
-func (persAccntsChannelClient *CerberusClient) CreateDocumentDataRequest(newRequest, requestData []byte) ([]string, []byte, error) {
-
-	//channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return nil, nil, err
-	}
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-
-	if err != nil {
-		return nil, nil, err
-	}
+func (persAccntsChannelClient *CerberusClient) CreateDocumentDataRequest(ctx context.Context, newRequest, requestData []byte) (*TxResult, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "createRequest",
 		Args:        [][]byte{[]byte("documentData"), newRequest, requestData},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Request created successfully.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
-	}
-
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return persAccntsChannelClient.Execute(ctx, request)
 }
 
-func (persAccntsChannelClient *CerberusClient) AcceptRequest(requestType, requestPublicId, recipientPublicId string, acceptedData []byte) ([]string, []byte, error) {
-
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return nil, nil, err
-	}
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-
-	if err != nil {
-		return nil, nil, err
-	}
+func (persAccntsChannelClient *CerberusClient) AcceptRequest(ctx context.Context, requestType, requestPublicId, recipientPublicId string, acceptedData []byte) (*TxResult, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "acceptRequest",
 		Args:        [][]byte{[]byte(requestType), []byte(requestPublicId), []byte(recipientPublicId), acceptedData},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Execute(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Request accepted successfully.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
-	}
-
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return persAccntsChannelClient.Execute(ctx, request)
 }
 
-func (persAccntsChannelClient *CerberusClient) RejectRequest(requestType, requestPublicId, recipientPublicId string) ([]string, []byte, error) {
-
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-	if err != nil {
-		fmt.Println(err)
-		return nil, nil, err
-	}
+func (persAccntsChannelClient *CerberusClient) RejectRequest(ctx context.Context, requestType, requestPublicId, recipientPublicId string) (*TxResult, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "rejectRequest",
 		Args:        [][]byte{[]byte(requestType), []byte(requestPublicId), []byte(recipientPublicId)},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
+	return persAccntsChannelClient.Execute(ctx, request)
+}
 
-	if err != nil {
-		return nil, nil, err
-	}
+func (persAccntsChannelClient *CerberusClient) UpdateRequest(ctx context.Context, requestType, requestPublicId, requesterPublicId, recipientId string, updatedData []byte) (*TxResult, error) {
 
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Request accepted successfully.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "updateRequest",
+		Args:        [][]byte{[]byte(requestType), []byte(requestPublicId), []byte(requesterPublicId), []byte(recipientId), []byte(updatedData)},
 	}
 
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return persAccntsChannelClient.Execute(ctx, request)
 }
 
-func (persAccntsChannelClient *CerberusClient) UpdateRequest(requestType, requestPublicId, requesterPublicId, recipientId string, updatedData []byte) ([]string, []byte, error) {
+// SweepResult lists the requests marked as expired by a sweep
+type SweepResult struct {
+	*TxResult `json:"-"`
 
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
+	Expired []string `json:"expired"`
 
-	if err != nil {
-		return nil, nil, err
+	// further expired requests are left for the next sweep
+	More bool `json:"more"`
+}
+
+// ParseSweepResult decodes the payload of a sweepExpiredRequests response
+func ParseSweepResult(result *TxResult) (*SweepResult, error) {
+
+	sweepResult := &SweepResult{TxResult: result}
+	if err := result.Decode(sweepResult); err != nil {
+		return nil, err
 	}
-	defer sdkInstance.Close()
 
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-	if err != nil {
-		return nil, nil, err
+	return sweepResult, nil
+}
+
+// SweepExpiredRequests marks pending requests past their expiry as expired - only the service identity may sweep
+// maxRequests limits the requests of one transaction, 0 uses the chaincode maximum
+func (persAccntsChannelClient *CerberusClient) SweepExpiredRequests(ctx context.Context, maxRequests int) (*SweepResult, error) {
+
+	args := [][]byte{}
+	if maxRequests > 0 {
+		args = append(args, []byte(strconv.Itoa(maxRequests)))
 	}
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
-		Fcn:         "updateRequest",
-		Args:        [][]byte{[]byte(requestType), []byte(requestPublicId), []byte(requesterPublicId), []byte(recipientId), []byte(updatedData)},
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "sweepExpiredRequests",
+		Args:        args,
 	}
 
-	//response, err := instAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Execute(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
+	result, err := persAccntsChannelClient.Execute(ctx, request)
 	if err != nil {
-		return nil, nil, err
-	}
+		// an invalidated sweep keeps its transaction result
+		if result != nil {
+			return &SweepResult{TxResult: result}, err
+		}
 
-	if response.ChaincodeStatus == 200 {
-		fmt.Println("Request updated successfully.")
-		fmt.Println("Transaction ID is: " + response.TransactionID)
+		return nil, err
 	}
 
-	return []string{"200", string(response.TransactionID)}, response.Payload, nil
+	return ParseSweepResult(result)
 }
@@ -1,87 +1,71 @@
 package persaccntschannel
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 )
 
-func (persAccntsChannelClient *CerberusClient) QueryRequestData(idType, id string) (string, error) {
-
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
-
-	if err != nil {
-		return "", err
-	}
-
-	defer sdkInstance.Close()
-
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-	if err != nil {
-		fmt.Println(err)
-		return "", err
-	}
+func (persAccntsChannelClient *CerberusClient) QueryRequestData(ctx context.Context, idType, id string) (string, error) {
 
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
 		Fcn:         "queryRequestData",
 		Args:        [][]byte{[]byte(idType), []byte(id)},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Query(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
+	payload, err := persAccntsChannelClient.Query(ctx, request)
 	if err != nil {
 		return "", err
 	}
 
-	if len(response.Payload) < 5 { // small random number of bytes
+	if len(payload) < 5 { // small random number of bytes
 		fmt.Println("No records with id: " + id + " exist.")
 		return "", nil
 	}
 
-	return string(response.Payload), nil
+	return string(payload), nil
 }
 
-func (persAccntsChannelClient *CerberusClient) QueryRequests(queryType, requestType, selectorKey, selectorValue string) (string, error) {
+func (persAccntsChannelClient *CerberusClient) QueryRequests(ctx context.Context, queryType, requestType, selectorKey, selectorValue string) (string, error) {
 
-	// channel instance -> create
-	err := persAccntsChannelClient.setupPersonAccountsChannelClient()
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "queryRequests",
+		Args:        [][]byte{[]byte(queryType), []byte(requestType), []byte(selectorKey), []byte(selectorValue)},
+	}
 
+	payload, err := persAccntsChannelClient.Query(ctx, request)
 	if err != nil {
 		return "", err
 	}
-	defer sdkInstance.Close()
 
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
-
-	if err != nil {
-		return "", err
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No records with " + selectorKey + ":" + selectorValue + " exist.")
+		return "", nil
 	}
 
+	return string(payload), nil
+}
+
+// QueryAcceptedData returns the values accepted for a request
+// they are kept in the private data collection of the recipient org
+func (persAccntsChannelClient *CerberusClient) QueryAcceptedData(ctx context.Context, requestID string) (string, error) {
+
 	// request -> prepare
 	request := channel.Request{
-		ChaincodeID: PersonAccountsChannelChainCode,
-		Fcn:         "queryRequests",
-		Args:        [][]byte{[]byte(queryType), []byte(requestType), []byte(selectorKey), []byte(selectorValue)},
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "queryAcceptedData",
+		Args:        [][]byte{[]byte(requestID)},
 	}
 
-	//response, err := persAccntsChannelClient.channelClient.Query(request)
-	// or:
-	response, err := persAccntsChannelClient.channelClient.Query(request, channel.WithTargetEndpoints(AnchorPrSipher))
-
+	payload, err := persAccntsChannelClient.QueryPrivate(ctx, request)
 	if err != nil {
-		fmt.Println(err)
 		return "", err
 	}
 
-	if len(response.Payload) < 5 { // small random number of bytes
-		fmt.Println("No records with " + selectorKey + ":" + selectorValue + " exist.")
-		return "", nil
-	}
-
-	return string(response.Payload), nil
+	return string(payload), nil
 }
@@ -0,0 +1,6 @@
+package persaccntschannel
+
+import "cerberus/blockchain/channelclient"
+
+// TxResult describes a committed transaction
+type TxResult = channelclient.TxResult
@@ -0,0 +1,42 @@
+package persaccntschannel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+)
+
+func (persAccntsChannelClient *CerberusClient) QueryRootDirectory(ctx context.Context, group string) (string, error) {
+
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "queryRootDirectory",
+		Args:        [][]byte{[]byte(group)},
+	}
+
+	payload, err := persAccntsChannelClient.Query(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) < 5 { // small random number of bytes
+		fmt.Println("No root directory for group " + group + " exists.")
+		return "", nil
+	}
+
+	return string(payload), nil
+}
+
+func (persAccntsChannelClient *CerberusClient) UpdateRootDirectory(ctx context.Context, group, previousRoot, newRoot string) (*TxResult, error) {
+
+	// request -> prepare
+	request := channel.Request{
+		ChaincodeID: persAccntsChannelClient.ChaincodeID(),
+		Fcn:         "updateRootDirectory",
+		Args:        [][]byte{[]byte(group), []byte(previousRoot), []byte(newRoot)},
+	}
+
+	return persAccntsChannelClient.Execute(ctx, request)
+}
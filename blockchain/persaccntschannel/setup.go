@@ -1,17 +1,8 @@
 package persaccntschannel
 
 import (
-	"fmt"
+	"cerberus/blockchain/channelclient"
 	"os"
-	"time"
-
-	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
-	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
-	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
-	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
-	"github.com/pkg/errors"
 )
 
 const (
@@ -29,65 +20,50 @@ const (
 	AnchorPrWhiteBox = "anchorpr.whitebox.cerberus.dev"
 )
 
-type CerberusClient struct {
-	channelCtx    context.ChannelProvider
-	channelClient *channel.Client
-	registration  fab.Registration
-	initialized   bool
-	event         *event.Client
-}
+// ErrClientClosed is returned by every call made after Close
+var ErrClientClosed = channelclient.ErrClientClosed
 
-var sdkInstance *fabsdk.FabricSDK
-var err error
+// ErrRequestTimeout and ErrRequestCanceled wrap errors of calls whose context ended
+var ErrRequestTimeout = channelclient.ErrRequestTimeout
+var ErrRequestCanceled = channelclient.ErrRequestCanceled
 
-func (persAccntsChannelClient *CerberusClient) setupPersonAccountsChannelClient() error {
+type Config = channelclient.Config
 
-	// check sdk instance
-	if persAccntsChannelClient.initialized {
-		fmt.Println("sdk is already initialized.")
-		return errors.New("sdk is already initialized.") // fix check
-	}
+type RetryPolicy = channelclient.RetryPolicy
 
-	// config file -> get
-	configFile := os.Getenv("GOPATH") + "/src/cerberus/hl/config.yaml"
+func DefaultRetryPolicy() RetryPolicy {
 
-	// sdk instance -> create
-	sdkInstance, err = fabsdk.New(config.FromFile(configFile))
-	if err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	persAccntsChannelClient.channelCtx = sdkInstance.ChannelContext(PersonAccountsChannelID, fabsdk.WithUser(SipherUser), fabsdk.WithOrg(SipherOrg))
+	return channelclient.DefaultRetryPolicy()
+}
 
-	// register event
-	persAccntsChannelClient.event, err = event.New(persAccntsChannelClient.channelCtx)
-	if err != nil {
-		fmt.Println(err)
-		return err
+func DefaultConfig() Config {
+
+	return Config{
+		ConfigFile:   os.Getenv("GOPATH") + "/src/cerberus/hl/config.yaml",
+		ChannelID:    PersonAccountsChannelID,
+		ChaincodeID:  PersonAccountsChannelChainCode,
+		Org:          SipherOrg,
+		User:         SipherUser,
+		Discovery:    true,
+		Peers:        []string{AnchorPrSipher, AnchorPrWhiteBox},
+		QueryPeers:   []string{AnchorPrSipher, AnchorPrWhiteBox},
+		PrivatePeers: []string{AnchorPrSipher},
+		Retry:        DefaultRetryPolicy(),
 	}
+}
 
-	var notifier <-chan *fab.CCEvent
-	persAccntsChannelClient.registration, notifier, err = persAccntsChannelClient.event.RegisterChaincodeEvent(PersonAccountsChannelChainCode, "event123")
-	if err != nil {
-		fmt.Println(err)
-		return err
-	}
-	defer persAccntsChannelClient.event.Unregister(persAccntsChannelClient.registration) // optimize
+// CerberusClient is the channel client of the person accounts chaincode
+// a single client is safe for concurrent use
+type CerberusClient struct {
+	*channelclient.Client
+}
 
-	select {
-	case chaincodeEvent := <-notifier:
-		fmt.Printf("received chaincode event %v\n", chaincodeEvent)
-	case <-time.After(time.Second * 5):
-		fmt.Println("timeout while waiting for chaincode event")
-	}
+func New(config Config) (*CerberusClient, error) {
 
-	// instantiate channel
-	persAccntsChannelClient.channelClient, err = channel.New(persAccntsChannelClient.channelCtx)
+	client, err := channelclient.New(config)
 	if err != nil {
-		fmt.Println(err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &CerberusClient{Client: client}, nil
 }
@@ -0,0 +1,23 @@
+package persaccntschannel
+
+import "cerberus/blockchain/channelclient"
+
+var ErrEnrollmentNotFound = channelclient.ErrEnrollmentNotFound
+
+type Enrollment = channelclient.Enrollment
+
+// Wallet keeps the enrollments of the accounts
+type Wallet = channelclient.Wallet
+
+type FileWallet = channelclient.FileWallet
+type MemoryWallet = channelclient.MemoryWallet
+
+func NewFileWallet(root string) (*FileWallet, error) {
+
+	return channelclient.NewFileWallet(root)
+}
+
+func NewMemoryWallet() *MemoryWallet {
+
+	return channelclient.NewMemoryWallet()
+}
@@ -0,0 +1,222 @@
+package person
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// the caller is known from its certificate only, publicIDs in the args are never trusted
+// - account identities carry the publicID of their account in accountAttribute, honored for the accounts of their org only
+// - the service identity of adminMSPID carries adminRole in roleAttribute and may act for every account
+// - adminRole in a certificate of another org is ignored
+const (
+	accountAttribute = "cerberus.publicID"
+	roleAttribute    = "cerberus.role"
+	adminRole        = "admin"
+	adminMSPID       = "SipherMSP"
+)
+
+// roles of the caller on an account or a request
+const (
+	roleOwner     = "owner"
+	roleRequester = "requester"
+	roleRecipient = "recipient"
+	roleAdmin     = "admin"
+)
+
+// unauthorizedError is returned when the caller has none of the required roles
+type unauthorizedError struct {
+	message string
+}
+
+func (err *unauthorizedError) Error() string {
+
+	return err.message
+}
+
+func newUnauthorizedError(roles []string, publicID string) error {
+
+	return &unauthorizedError{message: "Caller is not authorized as " + strings.Join(roles, " or ") + " of " + publicID}
+}
+
+// unauthorized returns the response of a refused call
+func unauthorized(err error) pb.Response {
+
+	return pb.Response{Status: statusUnauthorized, Message: err.Error()}
+}
+
+// caller is the identity which signed the proposal
+type caller struct {
+	id       string
+	mspID    string
+	publicID string
+	admin    bool
+}
+
+func getCaller(stub shim.ChaincodeStubInterface) (*caller, error) {
+
+	clientIdentity, err := cid.New(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := clientIdentity.GetID()
+	if err != nil {
+		return nil, err
+	}
+
+	mspID, err := clientIdentity.GetMSPID()
+	if err != nil {
+		return nil, err
+	}
+
+	publicID, _, err := clientIdentity.GetAttributeValue(accountAttribute)
+	if err != nil {
+		return nil, err
+	}
+
+	role, _, err := clientIdentity.GetAttributeValue(roleAttribute)
+	if err != nil {
+		return nil, err
+	}
+
+	return &caller{id: id, mspID: mspID, publicID: publicID, admin: role == adminRole && mspID == adminMSPID}, nil
+}
+
+// actsAs - the certificate names the account, or the identity created the account
+// either way the account record is held in the collection of the org of the caller -
+// a CA of one org cannot issue identities for the accounts of another
+func (caller *caller) actsAs(stub shim.ChaincodeStubInterface, publicID string) (bool, error) {
+
+	collection, ok := accountCollections[caller.mspID]
+	if !ok {
+		return false, nil
+	}
+
+	marker, err := readPrivateRecordMarker(stub, publicID)
+	if err != nil {
+		return false, err
+	}
+
+	// a new account is created in the collection of the caller org
+	if marker == nil {
+		return caller.publicID != "" && caller.publicID == publicID, nil
+	}
+
+	if marker.Collection != collection {
+		return false, nil
+	}
+
+	if caller.publicID != "" {
+		return caller.publicID == publicID, nil
+	}
+
+	return marker.Owner != "" && marker.Owner == caller.id, nil
+}
+
+// authorizeAccount - owner or admin of the account
+func authorizeAccount(stub shim.ChaincodeStubInterface, publicID string) error {
+
+	caller, err := getCaller(stub)
+	if err != nil {
+		return err
+	}
+
+	if caller.admin {
+		return nil
+	}
+
+	owner, err := caller.actsAs(stub, publicID)
+	if err != nil {
+		return err
+	}
+
+	if !owner {
+		return newUnauthorizedError([]string{roleOwner, roleAdmin}, publicID)
+	}
+
+	return nil
+}
+
+// authorizeRequest - one of the roles on the request or admin
+func authorizeRequest(stub shim.ChaincodeStubInterface, requestID, requesterPublicID, recipientPublicID string, roles ...string) error {
+
+	caller, err := getCaller(stub)
+	if err != nil {
+		return err
+	}
+
+	if caller.admin {
+		return nil
+	}
+
+	for _, role := range roles {
+		var publicID string
+
+		switch role {
+		case roleRequester:
+			publicID = requesterPublicID
+
+		case roleRecipient:
+			publicID = recipientPublicID
+
+		default:
+			continue
+		}
+
+		allowed, err := caller.actsAs(stub, publicID)
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			return nil
+		}
+	}
+
+	return newUnauthorizedError(append(roles, roleAdmin), "request "+requestID)
+}
+
+// authorizeAdmin - the service identity of an org
+func authorizeAdmin(stub shim.ChaincodeStubInterface, resource string) error {
+
+	caller, err := getCaller(stub)
+	if err != nil {
+		return err
+	}
+
+	if !caller.admin {
+		return newUnauthorizedError([]string{roleAdmin}, resource)
+	}
+
+	return nil
+}
+
+// authorizeSelector - admin, or an account querying the records which name it under the selector
+func authorizeSelector(stub shim.ChaincodeStubInterface, selectorKey, selectorValue string, ownSelectors map[string]bool) error {
+
+	caller, err := getCaller(stub)
+	if err != nil {
+		return err
+	}
+
+	if caller.admin {
+		return nil
+	}
+
+	if ownSelectors[selectorKey] {
+		allowed, err := caller.actsAs(stub, selectorValue)
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			return nil
+		}
+	}
+
+	return newUnauthorizedError([]string{roleAdmin}, selectorKey+" "+selectorValue)
+}
@@ -0,0 +1,60 @@
+package person
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestCallerActsAs(t *testing.T) {
+
+	stub := shim.NewMockStub("person", nil)
+	stub.MockTransactionStart("access")
+	defer stub.MockTransactionEnd("access")
+
+	marker, err := json.Marshal(&privateRecord{
+		ObjectType: privateRecordObjectType,
+		Key:        "a1",
+		RecordType: privateAccountRecord,
+		Collection: "SipherAccounts",
+		Owner:      "owner",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = stub.PutState("a1", marker); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		caller   *caller
+		publicID string
+		allowed  bool
+	}{
+		{"account identity", &caller{mspID: "SipherMSP", publicID: "a1"}, "a1", true},
+		{"identity of another account", &caller{mspID: "SipherMSP", publicID: "a2"}, "a1", false},
+		{"account identity of another org", &caller{mspID: "WhiteBoxMSP", publicID: "a1"}, "a1", false},
+		{"account identity of an unknown org", &caller{mspID: "OtherMSP", publicID: "a1"}, "a1", false},
+		{"owner", &caller{id: "owner", mspID: "SipherMSP"}, "a1", true},
+		{"owner of another org", &caller{id: "owner", mspID: "WhiteBoxMSP"}, "a1", false},
+		{"new account", &caller{mspID: "WhiteBoxMSP", publicID: "a3"}, "a3", true},
+		{"new account of an unknown org", &caller{mspID: "OtherMSP", publicID: "a3"}, "a3", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			allowed, err := test.caller.actsAs(stub, test.publicID)
+			if err != nil {
+				t.Fatalf("actsAs(%s) = %v", test.publicID, err)
+			}
+
+			if allowed != test.allowed {
+				t.Fatalf("actsAs(%s) = %t, want %t", test.publicID, allowed, test.allowed)
+			}
+		})
+	}
+}
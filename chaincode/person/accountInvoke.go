@@ -1,10 +1,9 @@
-package main
+package person
 
 import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
@@ -14,60 +13,66 @@ func (t *CerberusPersonAccounts) createAccount(stub shim.ChaincodeStubInterface,
 
 	fmt.Println("Start Person account initialization.")
 
-	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+	if len(args) != 1 {
+		return invalidArgument("Incorrect number of arguments. Expecting 1, the account record is passed in the transient map.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
-	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+	publicID := args[0]
+
+	// caller -> the identity of the account or admin
+	if err := authorizeAccount(stub, publicID); err != nil {
+		return unauthorized(err)
 	}
 
-	publicID := args[0]
-	accountObject := args[1]
+	accountObject, err := getTransientSecret(stub, recordTransientKey(publicID))
+	if err != nil {
+		return errorResponse(err)
+	}
 
-	// check if account exists
-	queryResultBytes, _, err := t.readAccount(stub, []string{publicID})
+	// check if account exists - accounts of other orgs are known by their hash
+	marker, err := readPrivateRecordMarker(stub, publicID)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	if queryResultBytes != nil {
-		return shim.Error("Record with public id: " + publicID + " already exists.")
+	if marker != nil {
+		return alreadyExists("Record with public id: " + publicID + " already exists.")
 	}
 
-	// ledger invoke operation
-	err = stub.PutState(publicID, accountObject)
+	// ledger invoke operation -> record in the collection of the org, hash on the channel
+	_, err = putPrivateRecord(stub, publicID, privateAccountRecord, accountObject)
+
+	if err != nil {
+		return errorResponse(err)
+	}
 
+	err = emitAccountEvent(stub, eventAccountCreated, publicID)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end createAccount")
-	return shim.Success(publicID)
+	return shim.Success([]byte(publicID))
 }
 
 func (t *CerberusPersonAccounts) updateRecords(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	fmt.Println("Initialize updateRecords")
 
-	if len(args) < 3 {
-		return shim.Error("Incorrect number of arguments. Expecting 3.")
+	if len(args) < 2 {
+		return invalidArgument("Incorrect number of arguments. Expecting 2.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
-	}
-
-	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	// assign values
@@ -83,127 +88,183 @@ func (t *CerberusPersonAccounts) updateRecords(stub shim.ChaincodeStubInterface,
 		return t.updateDocumentRecords(stub, updateArgs)
 
 	default:
-		return shim.Error("Function name not found.")
+		return invalidArgument("Function name not found.")
 	}
 }
 
 func (t *CerberusPersonAccounts) updateAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
+	// the passphrase and the new value are read from the transient map, secrets in the args are refused
+	if len(args) != 2 {
+		return invalidArgument("Incorrect number of arguments. Expecting 2, the passphrase and the value are passed in the transient map.")
+	}
+
 	// assign values
 	publicID := args[0]
-	passphrase := args[1]
-	dataField := args[2]
-	updateValue := args[3]
+	dataField := args[1]
+
+	// caller -> owner of the account or admin
+	if err := authorizeAccount(stub, publicID); err != nil {
+		return unauthorized(err)
+	}
+
+	passphrase, err := getTransientSecret(stub, passphraseTransientKey(publicID))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	updateValue, err := getTransientSecret(stub, valueTransientKey(publicID))
+	if err != nil {
+		return errorResponse(err)
+	}
 
 	// check if account exists
 	queryResultBytes, _, err := t.readAccount(stub, []string{publicID})
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if queryResultBytes == nil {
-		return shim.Error("No records with provided id exist.")
+		return notFound("No records with provided id exist.")
 	}
 
 	// object -> get
-	currentRecord, err := decrAESGCM(queryResultBytes, []byte(passphrase)
+	currentRecord, err := decrAESGCM(queryResultBytes, passphrase)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	recordUpdate := &personAccount{}
 	err = json.Unmarshal(currentRecord, recordUpdate)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// object -> update
 	value := reflect.ValueOf(recordUpdate.AccountData).Elem().FieldByName(dataField)
 	if value.IsValid() {
-		value.SetString(updateValue)
+		value.SetString(string(updateValue))
 	}
 
 	recordUpdate.AccountData.UpdatedAt = getTime()
 	recordUpdateAsBytes, err := json.Marshal(recordUpdate)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// encrypt again
 	encrRecord, err := encrAESGCM(recordUpdateAsBytes, passphrase)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// ledger invoke operation -> only the hash of the record is returned, responses are kept in the block
+	marker, err := putPrivateRecord(stub, publicID, privateAccountRecord, encrRecord)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	// ledger invoke operation
-	err = stub.PutState(publicID, encrRecord)
+	markerAsBytes, err := json.Marshal(marker)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end updateAccount: ")
-	return shim.Success()
+	return shim.Success(markerAsBytes)
 }
 
 func (t *CerberusPersonAccounts) deleteAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	// input sanitation
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1.")
+		return invalidArgument("Incorrect number of arguments. Expecting 1.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	// assign values
 	publicID := args[0]
 
+	// caller -> owner of the account or admin
+	if err := authorizeAccount(stub, publicID); err != nil {
+		return unauthorized(err)
+	}
+
 	// check if account exists
-	queryResultBytes, _, err := t.readAccount(stub, []string{publicID})
+	marker, err := readPrivateRecordMarker(stub, publicID)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	if queryResultBytes == nil {
-		return shim.Error("No records with provided id exist.")
+	if marker == nil || marker.RecordType != privateAccountRecord {
+		return notFound("No records with provided id exist.")
+	}
+
+	// ledger invoke operation -> record and hash
+	err = delPrivateRecord(stub, publicID)
+
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	// ledger invoke operation
-	err = stub.DelState(publicID)
+	markerAsBytes, err := json.Marshal(marker)
+	if err != nil {
+		return errorResponse(err)
+	}
 
+	err = emitAccountEvent(stub, eventAccountDeleted, publicID)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end deleteAccount")
-	return shim.Success(queryResultBytes)
+	return shim.Success(markerAsBytes)
 }
 
 func (t *CerberusPersonAccounts) updateDocumentRecords(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
+	if len(args) != 1 {
+		return invalidArgument("Incorrect number of arguments. Expecting 1, the account record is passed in the transient map.")
+	}
+
 	// assign values
 	publicID := args[0]
-	data := args[2]
+
+	// caller -> owner of the account or admin
+	if err := authorizeAccount(stub, publicID); err != nil {
+		return unauthorized(err)
+	}
+
+	data, err := getTransientSecret(stub, recordTransientKey(publicID))
+	if err != nil {
+		return errorResponse(err)
+	}
 
 	// check if account exists
 	queryResultBytes, _, err := t.readAccount(stub, []string{publicID})
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if queryResultBytes == nil {
-		return shim.Error("No records with provided id exist.")
+		return notFound("No records with provided id exist.")
 	}
 
-	// ledger invoke operation
-	err = stub.PutState(publicID, []byte(data))
+	// ledger invoke operation -> only the hash of the record is returned, responses are kept in the block
+	marker, err := putPrivateRecord(stub, publicID, privateAccountRecord, data)
+
+	if err != nil {
+		return errorResponse(err)
+	}
 
+	markerAsBytes, err := json.Marshal(marker)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	fmt.Println("- end updateDocumentRecords: " + string(data))
-	return shim.Success([]byte(data))
+	fmt.Println("- end updateDocumentRecords: " + marker.Hash)
+	return shim.Success(markerAsBytes)
 }
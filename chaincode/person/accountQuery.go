@@ -1,7 +1,8 @@
-package main
+package person
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,21 +17,26 @@ func (t *CerberusPersonAccounts) queryAccountData(stub shim.ChaincodeStubInterfa
 	fmt.Println("Start queryAccountData initialization.")
 
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2.")
+		return invalidArgument("Incorrect number of arguments. Expecting 2.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	// get query function
 	queryFunction := args[0]
 	accountPublicID := args[1]
 
+	// caller -> owner of the account or admin
+	if err := authorizeAccount(stub, accountPublicID); err != nil {
+		return unauthorized(err)
+	}
+
 	switch queryFunction {
 
 	case "getAccountHistory":
@@ -40,43 +46,121 @@ func (t *CerberusPersonAccounts) queryAccountData(stub shim.ChaincodeStubInterfa
 		return t.getAccountRecords(stub, []string{accountPublicID})
 
 	default:
-		return shim.Error("Function name not found.")
+		return invalidArgument("Function name not found.")
 	}
-
-	return shim.Success(nil)
 }
 
 func (t *CerberusPersonAccounts) queryRecords(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2.")
+		return invalidArgument("Incorrect number of arguments. Expecting 2.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 1 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	// assign values
 	selectorKey := args[0]
 	selectorValue := strings.ToLower(args[1])
 
+	// records of every account -> admin
+	if err := authorizeAdmin(stub, "account records"); err != nil {
+		return unauthorized(err)
+	}
+
 	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"person\", \"accountData\":{\"%s\":\"%s\"}}}", selectorKey, selectorValue)
 
-	// obtain records
-	queryResults, err := getQueryResultForQueryString(stub, queryString)
+	// obtain records - private records of the collections on the peer
+	queryResults, err := getPrivateQueryResult(stub, queryString)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end queryRecords by: " + selectorKey + ": " + string(queryResults))
 	return shim.Success(queryResults)
 }
 
+// {"records":[{"Key":..., "Record":base64}], "bookmark":...}
+type accountsPage struct {
+	Records  []accountsPageRecord `json:"records"`
+	Bookmark string               `json:"bookmark"`
+}
+
+type accountsPageRecord struct {
+	Key    string `json:"Key"`
+	Record []byte `json:"Record"`
+}
+
+// pageSize, bookmark
+// returns all person account records page by page - used by the ipfs garbage collector
+// pages are read from the hashes on the channel - a record of a collection the peer does not hold
+// fails the page with statusPrivateDataUnavailable, a page with gaps would let the collector remove referenced objects
+func (t *CerberusPersonAccounts) queryAccounts(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) < 1 {
+		return invalidArgument("Incorrect number of arguments. Expecting at least 1.")
+	}
+
+	pageSize, err := strconv.Atoi(args[0])
+	if err != nil || pageSize <= 0 {
+		return invalidArgument("1st argument must be a positive number")
+	}
+
+	var bookmark string
+	if len(args) > 1 {
+		bookmark = args[1]
+	}
+
+	// records of every account -> admin
+	if err = authorizeAdmin(stub, "account records"); err != nil {
+		return unauthorized(err)
+	}
+
+	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"recordType\":\"%s\"}}", privateRecordObjectType, privateAccountRecord)
+
+	resultsIterator, responseMetadata, err := stub.GetQueryResultWithPagination(queryString, int32(pageSize), bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+	defer resultsIterator.Close()
+
+	// records are ciphertext -> base64 in the JSON page
+	page := &accountsPage{Records: []accountsPageRecord{}, Bookmark: responseMetadata.Bookmark}
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		record, _, err := getPrivateRecord(stub, queryResponse.Key)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if record == nil {
+			continue
+		}
+
+		page.Records = append(page.Records, accountsPageRecord{Key: queryResponse.Key, Record: record})
+	}
+
+	pageAsBytes, err := json.Marshal(page)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end queryAccounts: " + strconv.Itoa(int(responseMetadata.FetchedRecordsCount)) + " records")
+	return shim.Success(pageAsBytes)
+}
+
+// the channel keeps the history of the record hashes, the collections keep the current record only
 func (t *CerberusPersonAccounts) getAccountHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	// assign values
@@ -86,7 +170,7 @@ func (t *CerberusPersonAccounts) getAccountHistory(stub shim.ChaincodeStubInterf
 	resultsIterator, err := stub.GetHistoryForKey(publicID)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 	defer resultsIterator.Close()
 
@@ -98,7 +182,7 @@ func (t *CerberusPersonAccounts) getAccountHistory(stub shim.ChaincodeStubInterf
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
-			return shim.Error(err.Error())
+			return errorResponse(err)
 		}
 		// Add a comma before array members, suppress it for the first array member
 		if bArrayMemberAlreadyWritten == true {
@@ -148,11 +232,11 @@ func (t *CerberusPersonAccounts) getAccountRecords(stub shim.ChaincodeStubInterf
 	queryResultBytes, _, err := t.readAccount(stub, []string{publicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if queryResultBytes == nil {
-		return shim.Error("No records with provided id exist.")
+		return notFound("No records with provided id exist.")
 	}
 
 	fmt.Println("- end getAccountRecords: " + string(queryResultBytes))
@@ -213,49 +297,33 @@ func constructQueryResponseFromIterator(resultsIterator shim.StateQueryIteratorI
 	return &buffer, nil
 }
 
+// readAccount returns the account record and the collection it is kept in
 func (t *CerberusPersonAccounts) readAccount(stub shim.ChaincodeStubInterface, args []string) ([]byte, string, error) {
 
-	var resultBytes []byte
-
 	if len(args) < 1 {
-		return nil, "", fmt.Errorf("Not enough arguments provided, Expecting 1")
+		return nil, "", newStatusError(statusInvalidArgument, "Not enough arguments provided, Expecting 1")
 	}
 
 	if len(args[0]) <= 0 {
-		return nil, "", fmt.Errorf("1st argument must be a non-empty string")
+		return nil, "", newStatusError(statusInvalidArgument, "1st argument must be a non-empty string")
 	}
 
 	// assign values
 	publicID := args[0]
 
-	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"person\",\"publicID\":\"%s\"}}", publicID)
-
-	// obtain records
-	resultsIterator, err := stub.GetQueryResult(queryString)
+	// obtain record - hash on the channel, record in the collection of the holder org
+	accountBytes, marker, err := getPrivateRecord(stub, publicID)
 
 	if err != nil {
 		return nil, "", err
 	}
-	defer resultsIterator.Close()
-
-	for resultsIterator.HasNext() {
-		response, err := resultsIterator.Next()
-
-		if err != nil {
-			return nil, "", err
-		}
-
-		resultBytes = response.Value
-	}
 
-	buffer, err := constructQueryResponseFromIterator(resultsIterator)
-
-	if err != nil {
-		return nil, "", err
+	if marker == nil || marker.RecordType != privateAccountRecord {
+		return nil, "", nil
 	}
 
 	fmt.Println("- end readAccount")
-	return resultBytes, string(buffer.Bytes()), nil
+	return accountBytes, marker.Collection, nil
 }
 
 func getTime() string {
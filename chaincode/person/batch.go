@@ -0,0 +1,175 @@
+package person
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// operations in a single batch transaction
+const batchMaxOperations = 100
+
+type batchOperation struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+type batchOperationResult struct {
+	Function string `json:"function"`
+	Payload  string `json:"payload"`
+}
+
+// batchStub runs the operations of a batch on the transaction stub
+// reads see the state before the batch, like in every Fabric transaction -
+// so a key written by one operation cannot be read or written again by a later one, the later operation fails with statusConflict
+// instead of acting on the stale state, persaccntschannel.Batch refuses such batches before they are sent
+type batchStub struct {
+	shim.ChaincodeStubInterface
+
+	operation int
+	writers   map[string]int
+	events    []*cerberusEvent
+}
+
+func (stub *batchStub) GetState(key string) ([]byte, error) {
+
+	if writer, ok := stub.writers[key]; ok && writer != stub.operation {
+		return nil, newStatusError(statusConflict, "Key "+key+" is read after operation "+strconv.Itoa(writer)+
+			" of the batch wrote it, reads see the state before the batch")
+	}
+
+	return stub.ChaincodeStubInterface.GetState(key)
+}
+
+func (stub *batchStub) PutState(key string, value []byte) error {
+
+	if err := stub.claim(key); err != nil {
+		return err
+	}
+
+	return stub.ChaincodeStubInterface.PutState(key, value)
+}
+
+func (stub *batchStub) DelState(key string) error {
+
+	if err := stub.claim(key); err != nil {
+		return err
+	}
+
+	return stub.ChaincodeStubInterface.DelState(key)
+}
+
+func (stub *batchStub) claim(key string) error {
+
+	if writer, ok := stub.writers[key]; ok && writer != stub.operation {
+		return newStatusError(statusConflict, "Key "+key+" is written by operation "+strconv.Itoa(writer)+" of the batch already")
+	}
+
+	stub.writers[key] = stub.operation
+
+	return nil
+}
+
+// SetEvent collects the events of the operations, the batch emits them together
+func (stub *batchStub) SetEvent(name string, payload []byte) error {
+
+	event := &cerberusEvent{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return err
+	}
+
+	stub.events = append(stub.events, event)
+
+	return nil
+}
+
+// operations -> JSON list of {"function": ..., "args": [...]}
+// the operations are applied in order, the first failing operation fails the whole transaction
+func (t *CerberusPersonAccounts) batchInvoke(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	fmt.Println("Start batchInvoke initialization.")
+
+	if len(args) < 1 {
+		return invalidArgument("Incorrect number of arguments. Expecting 1.")
+	}
+
+	if len(args[0]) <= 0 {
+		return invalidArgument("1st argument must be a non-empty string")
+	}
+
+	var operations []batchOperation
+	err := json.Unmarshal([]byte(args[0]), &operations)
+	if err != nil {
+		return invalidArgument("1st argument must be a JSON list of operations: " + err.Error())
+	}
+
+	if len(operations) == 0 {
+		return invalidArgument("Batch must contain at least 1 operation")
+	}
+
+	if len(operations) > batchMaxOperations {
+		return invalidArgument("Batch must contain at most " + strconv.Itoa(batchMaxOperations) + " operations")
+	}
+
+	batch := &batchStub{
+		ChaincodeStubInterface: stub,
+		writers:                make(map[string]int),
+	}
+
+	results := make([]batchOperationResult, 0, len(operations))
+
+	for i, operation := range operations {
+		batch.operation = i
+
+		var response pb.Response
+
+		switch operation.Function {
+
+		case "createAccount":
+			response = t.createAccount(batch, operation.Args)
+
+		case "updateRecords":
+			response = t.updateRecords(batch, operation.Args)
+
+		case "deleteAccount":
+			response = t.deleteAccount(batch, operation.Args)
+
+		case "createRequest":
+			response = t.createRequest(batch, operation.Args)
+
+		case "acceptRequest":
+			response = t.acceptRequest(batch, operation.Args)
+
+		case "rejectRequest":
+			response = t.rejectRequest(batch, operation.Args)
+
+		case "updateRequest":
+			response = t.updateRequest(batch, operation.Args)
+
+		default:
+			return invalidArgument("Operation " + strconv.Itoa(i) + ": function " + operation.Function + " is not allowed in a batch")
+		}
+
+		// the status of the operation is kept - unauthorized operations fail the batch as unauthorized
+		if response.Status >= shim.ERRORTHRESHOLD {
+			return pb.Response{Status: response.Status, Message: "Operation " + strconv.Itoa(i) + " (" + operation.Function + ") failed: " + response.Message}
+		}
+
+		results = append(results, batchOperationResult{Function: operation.Function, Payload: string(response.Payload)})
+	}
+
+	if err = emitBatchEvent(stub, batch.events); err != nil {
+		return errorResponse(err)
+	}
+
+	resultsAsBytes, err := json.Marshal(results)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end batchInvoke: " + strconv.Itoa(len(operations)) + " operations")
+	return shim.Success(resultsAsBytes)
+}
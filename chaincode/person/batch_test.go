@@ -0,0 +1,49 @@
+package person
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestBatchStubClaim(t *testing.T) {
+
+	mock := shim.NewMockStub("person", nil)
+	mock.MockTransactionStart("batch")
+	defer mock.MockTransactionEnd("batch")
+
+	batch := &batchStub{ChaincodeStubInterface: mock, writers: make(map[string]int)}
+
+	// one operation may write its keys more than once
+	if err := batch.PutState("a1", []byte("{}")); err != nil {
+		t.Fatalf("PutState(a1) = %v", err)
+	}
+
+	if err := batch.PutState("a1", []byte(`{"publicID":"a1"}`)); err != nil {
+		t.Fatalf("second PutState(a1) of the same operation = %v", err)
+	}
+
+	batch.operation++
+
+	if err := batch.PutState("a2", []byte("{}")); err != nil {
+		t.Fatalf("PutState(a2) = %v", err)
+	}
+
+	// the operation reads its own writes
+	if _, err := batch.GetState("a2"); err != nil {
+		t.Fatalf("GetState(a2) of the writing operation = %v", err)
+	}
+
+	for name, write := range map[string]func() error{
+		"GetState": func() error { _, err := batch.GetState("a1"); return err },
+		"PutState": func() error { return batch.PutState("a1", []byte("{}")) },
+		"DelState": func() error { return batch.DelState("a1") },
+	} {
+		err := write()
+
+		statusErr, ok := err.(*statusError)
+		if !ok || statusErr.status != statusConflict {
+			t.Fatalf("%s(a1) of a later operation = %v, want status %d", name, err, statusConflict)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"cerberus/chaincode/person"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// installed from this directory - META-INF holds the CouchDB indexes of the chaincode
+func main() {
+
+	err := shim.Start(new(person.CerberusPersonAccounts))
+
+	if err != nil {
+		fmt.Println("Error starting Person chaincode: " + err.Error())
+	}
+}
@@ -1,4 +1,17 @@
-package main
+package person
+
+import "time"
+
+// request expiry
+// - requests created without expiresAt expire after defaultRequestTTL
+// - requests stored before expiresAt existed expire defaultRequestTTL after their createdAt
+// - a requested expiresAt is capped at maxRequestTTL after the transaction time
+// - one sweepExpiredRequests transaction marks at most sweepMaxRequests requests as expired
+const (
+	defaultRequestTTL = 30 * 24 * time.Hour
+	maxRequestTTL     = 365 * 24 * time.Hour
+	sweepMaxRequests  = 100
+)
 
 type accountDataRequest struct {
 	ID                string            `json:"id"`
@@ -12,6 +25,12 @@ type accountDataRequest struct {
 	CreatedAt         string            `json:"createdAt"`
 	UpdatedAt         string            `json:"updatedAt"`
 	Status            string            `json:"status"`
+
+	// RFC3339 in UTC - pending requests cannot be accepted or updated from then on
+	ExpiresAt string `json:"expiresAt,omitempty"`
+
+	// accepted values are kept in the collection of the recipient org - queryAcceptedData
+	AcceptedDataHash string `json:"acceptedDataHash,omitempty"`
 }
 
 type documentDataRequest struct {
@@ -28,6 +47,12 @@ type documentDataRequest struct {
 	CreatedAt         string            `json:"createdAt"`
 	UpdatedAt         string            `json:"updatedAt"`
 	Status            string            `json:"status"`
+
+	// RFC3339 in UTC - pending requests cannot be accepted or updated from then on
+	ExpiresAt string `json:"expiresAt,omitempty"`
+
+	// accepted values are kept in the collection of the recipient org - queryAcceptedData
+	AcceptedDataHash string `json:"acceptedDataHash,omitempty"`
 }
 
 type ipfsDocumentVersionData struct {
@@ -82,7 +107,7 @@ type accountData struct {
 	UpdatedAt string `json:"updatedAt"`
 }
 
-type personalAccount struct {
+type personAccount struct {
 	ID              string                        `json:"id"`
 	PublicID        string                        `json:"publicID"`
 	ObjectType      string                        `json:"objectType"`
@@ -90,3 +115,10 @@ type personalAccount struct {
 	IPFSAccountData *IPFSDirectoryData            `json:"ipfsAccountData"`
 	Documents       map[string]*documentDirectory `json:"documents"`
 }
+
+type rootDirectory struct {
+	ObjectType        string `json:"docType"`
+	Group             string `json:"group"`
+	ContentIdentifier string `json:"contentIdentifier"`
+	UpdatedAt         string `json:"updatedAt"`
+}
@@ -1,4 +1,14 @@
-package main
+package person
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+)
 
 // Create a Merke-Damgard MD5 checksum, hex encoded
 // the output is not going to be stored so we do not care about the MSD5 insecurity
@@ -11,15 +21,20 @@ func hashMD5(key []byte) string {
 }
 
 // Encrypt bytes stream: account data, image, document data
-func encrAESGCM(data []byte, key[]byte) ([]byte, error) {
-	gcm, err := cipher.NewGCM(key)
+func encrAESGCM(data []byte, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
 	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		retutn nil, err
+		return nil, err
 	}
 
 	gcmCipher := gcm.Seal(nonce, nonce, data, nil)
@@ -38,11 +53,15 @@ func decrAESGCM(data []byte, key []byte) ([]byte, error) {
 	}
 
 	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("Ciphertext is shorter than the nonce")
+	}
+
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
 		return nil, err
 	}
-	
-	return data, nil
-}
\ No newline at end of file
+
+	return plaintext, nil
+}
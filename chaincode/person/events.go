@@ -0,0 +1,95 @@
+package person
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// chaincode event names - the client subscribes by these names
+// a transaction carries at most one event, the last SetEvent call wins
+const (
+	eventAccountCreated  = "AccountCreated"
+	eventAccountDeleted  = "AccountDeleted"
+	eventRequestCreated  = "RequestCreated"
+	eventRequestAccepted = "RequestAccepted"
+	eventRequestRejected = "RequestRejected"
+	eventRequestUpdated  = "RequestUpdated"
+	eventRequestExpired  = "RequestExpired"
+
+	// the events of the operations of a batch transaction
+	eventBatchCommitted = "BatchCommitted"
+)
+
+// cerberusEvent is the JSON payload of every event
+// it carries identifiers only - account and requested data stay in the state
+type cerberusEvent struct {
+	Type              string `json:"type"`
+	AccountPublicID   string `json:"accountPublicID,omitempty"`
+	RequestPublicID   string `json:"requestPublicID,omitempty"`
+	RequestType       string `json:"requestType,omitempty"`
+	RequesterPublicID string `json:"requesterPublicID,omitempty"`
+	RecipientPublicID string `json:"recipientPublicID,omitempty"`
+	Status            string `json:"status,omitempty"`
+	TxID              string `json:"txID"`
+	Timestamp         string `json:"timestamp"`
+
+	Events []*cerberusEvent `json:"events,omitempty"`
+}
+
+func emitAccountEvent(stub shim.ChaincodeStubInterface, eventType, accountPublicID string) error {
+
+	return emitEvent(stub, &cerberusEvent{
+		Type:            eventType,
+		AccountPublicID: accountPublicID,
+	})
+}
+
+func emitRequestEvent(stub shim.ChaincodeStubInterface, eventType, requestPublicID, requestType, requesterPublicID, recipientPublicID, status string) error {
+
+	return emitEvent(stub, &cerberusEvent{
+		Type:              eventType,
+		RequestPublicID:   requestPublicID,
+		RequestType:       requestType,
+		RequesterPublicID: requesterPublicID,
+		RecipientPublicID: recipientPublicID,
+		Status:            status,
+	})
+}
+
+// emitBatchEvent emits the only event of a batch as it is, several events as one BatchCommitted event
+func emitBatchEvent(stub shim.ChaincodeStubInterface, events []*cerberusEvent) error {
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if len(events) == 1 {
+		return emitEvent(stub, events[0])
+	}
+
+	return emitEvent(stub, &cerberusEvent{
+		Type:   eventBatchCommitted,
+		Events: events,
+	})
+}
+
+func emitEvent(stub shim.ChaincodeStubInterface, event *cerberusEvent) error {
+
+	// transaction timestamp -> same on every endorsing peer
+	now, err := txTime(stub)
+	if err != nil {
+		return err
+	}
+
+	event.TxID = stub.GetTxID()
+	event.Timestamp = now.Format(time.RFC3339Nano)
+
+	eventAsBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return stub.SetEvent(event.Type, eventAsBytes)
+}
@@ -0,0 +1,264 @@
+package person
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// result of a sweepExpiredRequests transaction
+// more -> further expired requests are left for the next sweep
+type sweepResult struct {
+	Expired []string `json:"expired"`
+	More    bool     `json:"more"`
+}
+
+// txTime returns the transaction timestamp - the same on every endorsing peer
+func txTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC(), nil
+}
+
+// layout of createdAt of requests stored before it was taken from the transaction timestamp - getTime
+const requestTimeLayout = "2006-01-02 15:04:05"
+
+// requestCreatedAt returns the creation time of a new request - the transaction timestamp, RFC3339 in UTC
+func requestCreatedAt(stub shim.ChaincodeStubInterface) (string, error) {
+
+	now, err := txTime(stub)
+	if err != nil {
+		return "", err
+	}
+
+	return now.Format(time.RFC3339), nil
+}
+
+// requestExpiresAt returns the expiry of a new request - the requested one up to the max TTL or the default TTL
+func requestExpiresAt(stub shim.ChaincodeStubInterface, expiresAt string) (string, error) {
+
+	now, err := txTime(stub)
+	if err != nil {
+		return "", err
+	}
+
+	if expiresAt == "" {
+		return now.Add(defaultRequestTTL).Format(time.RFC3339), nil
+	}
+
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return "", newStatusError(statusInvalidArgument, "Request expiresAt argument must be an RFC3339 time")
+	}
+
+	if !expiry.After(now) {
+		return "", newStatusError(statusInvalidArgument, "Request expiresAt argument must be later than the transaction time")
+	}
+
+	if maxExpiry := now.Add(maxRequestTTL); expiry.After(maxExpiry) {
+		expiry = maxExpiry
+	}
+
+	return expiry.UTC().Format(time.RFC3339), nil
+}
+
+// requestExpiry - requests stored before expiresAt existed expire defaultRequestTTL after their createdAt
+// their createdAt is the local time of the peer in requestTimeLayout, the peer runs in UTC
+func requestExpiry(createdAt, expiresAt string) (time.Time, error) {
+
+	if expiresAt != "" {
+		return time.Parse(time.RFC3339, expiresAt)
+	}
+
+	created, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		created, err = time.Parse(requestTimeLayout, createdAt)
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return created.Add(defaultRequestTTL), nil
+}
+
+// withRequestExpiry returns the stored request with its expiry - clients read it and never derive it themselves
+// requests stored before expiresAt existed get the one derived from createdAt, a request without a valid createdAt is returned as it is
+func withRequestExpiry(requestBytes []byte) ([]byte, error) {
+
+	request := make(map[string]interface{})
+	if err := json.Unmarshal(requestBytes, &request); err != nil {
+		return nil, err
+	}
+
+	if expiresAt, _ := request["expiresAt"].(string); expiresAt != "" {
+		return requestBytes, nil
+	}
+
+	createdAt, _ := request["createdAt"].(string)
+
+	expiry, err := requestExpiry(createdAt, "")
+	if err != nil {
+		return requestBytes, nil
+	}
+
+	request["expiresAt"] = expiry.UTC().Format(time.RFC3339)
+
+	return json.Marshal(request)
+}
+
+// requestExpired compares the expiry of a stored request with the transaction time
+func requestExpired(stub shim.ChaincodeStubInterface, createdAt, expiresAt string) (bool, error) {
+
+	expiry, err := requestExpiry(createdAt, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	now, err := txTime(stub)
+	if err != nil {
+		return false, err
+	}
+
+	return !now.Before(expiry), nil
+}
+
+// expireRequest marks a stored request of either type as expired
+func expireRequest(requestBytes []byte) ([]byte, *accountDataRequest, error) {
+
+	request := &accountDataRequest{}
+	if err := json.Unmarshal(requestBytes, request); err != nil {
+		return nil, nil, err
+	}
+
+	var expiredRequest interface{}
+
+	switch request.RequestType {
+	case "documentData":
+		documentRequest := &documentDataRequest{}
+		if err := json.Unmarshal(requestBytes, documentRequest); err != nil {
+			return nil, nil, err
+		}
+
+		documentRequest.Status = "expired"
+		documentRequest.UpdatedAt = getTime()
+		expiredRequest = documentRequest
+
+	default:
+		request.UpdatedAt = getTime()
+		expiredRequest = request
+	}
+
+	request.Status = "expired"
+
+	expiredRequestAsBytes, err := json.Marshal(expiredRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return expiredRequestAsBytes, request, nil
+}
+
+// [max requests]
+// marks the pending requests past their expiry as expired, at most sweepMaxRequests in one transaction
+func (t *CerberusPersonAccounts) sweepExpiredRequests(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	fmt.Println("Start sweepExpiredRequests initialization.")
+
+	// caller -> the service identity sweeps the requests of every account
+	if err := authorizeAdmin(stub, "requests"); err != nil {
+		return unauthorized(err)
+	}
+
+	maxRequests := sweepMaxRequests
+
+	if len(args) > 0 && len(args[0]) > 0 {
+		var err error
+		maxRequests, err = strconv.Atoi(args[0])
+
+		if err != nil || maxRequests <= 0 || maxRequests > sweepMaxRequests {
+			return invalidArgument("1st argument must be a number between 1 and " + strconv.Itoa(sweepMaxRequests))
+		}
+	}
+
+	now, err := txTime(stub)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// requests without expiresAt -> by createdAt, both layouts sort by time
+	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"persAccntsRequest\",\"status\":\"pending\",\"$or\":["+
+		"{\"expiresAt\":{\"$lte\":\"%s\"}},"+
+		"{\"expiresAt\":{\"$exists\":false},\"createdAt\":{\"$lte\":\"%s\"}}]}}",
+		now.Format(time.RFC3339), now.Add(-defaultRequestTTL).Format(requestTimeLayout))
+
+	// obtain records
+	resultsIterator, err := stub.GetQueryResult(queryString)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+	defer resultsIterator.Close()
+
+	// events of the expired requests -> one transaction event, like in a batch
+	sweep := &batchStub{
+		ChaincodeStubInterface: stub,
+		writers:                make(map[string]int),
+	}
+
+	result := &sweepResult{Expired: []string{}}
+
+	for resultsIterator.HasNext() {
+		if len(result.Expired) == maxRequests {
+			result.More = true
+			break
+		}
+
+		response, err := resultsIterator.Next()
+
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		expiredRequestAsBytes, request, err := expireRequest(response.Value)
+
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = sweep.PutState(response.Key, expiredRequestAsBytes)
+
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = emitRequestEvent(sweep, eventRequestExpired, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		result.Expired = append(result.Expired, request.PublicID)
+	}
+
+	if err = emitBatchEvent(stub, sweep.events); err != nil {
+		return errorResponse(err)
+	}
+
+	resultAsBytes, err := json.Marshal(result)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end sweepExpiredRequests: " + strconv.Itoa(len(result.Expired)) + " requests expired")
+	return shim.Success(resultAsBytes)
+}
@@ -0,0 +1,141 @@
+package person
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func newExpiryStub(t *testing.T) (*shim.MockStub, time.Time) {
+
+	stub := shim.NewMockStub("person", nil)
+	stub.MockTransactionStart("expiry")
+
+	now, err := txTime(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stub, now
+}
+
+func TestRequestExpiresAt(t *testing.T) {
+
+	stub, now := newExpiryStub(t)
+	defer stub.MockTransactionEnd("expiry")
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		want      time.Time
+		invalid   bool
+	}{
+		{"default TTL", "", now.Add(defaultRequestTTL), false},
+		{"requested expiry", now.Add(time.Hour).Format(time.RFC3339), now.Add(time.Hour), false},
+		{"capped at the max TTL", now.Add(10 * maxRequestTTL).Format(time.RFC3339), now.Add(maxRequestTTL), false},
+		{"past expiry", now.Add(-time.Hour).Format(time.RFC3339), time.Time{}, true},
+		{"not RFC3339", "tomorrow", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			expiresAt, err := requestExpiresAt(stub, test.expiresAt)
+
+			if test.invalid {
+				statusErr, ok := err.(*statusError)
+				if !ok || statusErr.status != statusInvalidArgument {
+					t.Fatalf("requestExpiresAt() = %v, want status %d", err, statusInvalidArgument)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("requestExpiresAt() = %v", err)
+			}
+
+			if want := test.want.Format(time.RFC3339); expiresAt != want {
+				t.Fatalf("requestExpiresAt() = %s, want %s", expiresAt, want)
+			}
+		})
+	}
+}
+
+func TestRequestExpired(t *testing.T) {
+
+	stub, now := newExpiryStub(t)
+	defer stub.MockTransactionEnd("expiry")
+
+	createdAt := func(age time.Duration) string {
+
+		return now.Add(-age).Format(requestTimeLayout)
+	}
+
+	tests := []struct {
+		name      string
+		createdAt string
+		expiresAt string
+		expired   bool
+	}{
+		{"pending", createdAt(time.Hour), now.Add(time.Hour).Format(time.RFC3339), false},
+		{"past expiresAt", createdAt(time.Hour), now.Add(-time.Minute).Format(time.RFC3339), true},
+		{"without expiresAt within the default TTL", createdAt(time.Hour), "", false},
+		{"without expiresAt past the default TTL", createdAt(defaultRequestTTL + time.Hour), "", true},
+		{"RFC3339 createdAt past the default TTL", now.Add(-defaultRequestTTL - time.Hour).Format(time.RFC3339), "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			expired, err := requestExpired(stub, test.createdAt, test.expiresAt)
+			if err != nil {
+				t.Fatalf("requestExpired() = %v", err)
+			}
+
+			if expired != test.expired {
+				t.Fatalf("requestExpired() = %v, want %v", expired, test.expired)
+			}
+		})
+	}
+}
+
+func TestWithRequestExpiry(t *testing.T) {
+
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := createdAt.Add(defaultRequestTTL).Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		request string
+		want    string
+	}{
+		{"stored expiresAt", `{"createdAt":"2020-01-02T03:04:05Z","expiresAt":"2020-01-03T00:00:00Z"}`, "2020-01-03T00:00:00Z"},
+		{"legacy createdAt", `{"createdAt":"` + createdAt.Format(requestTimeLayout) + `"}`, want},
+		{"RFC3339 createdAt", `{"createdAt":"` + createdAt.Format(time.RFC3339) + `"}`, want},
+		{"invalid createdAt", `{"createdAt":"yesterday"}`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			requestBytes, err := withRequestExpiry([]byte(test.request))
+			if err != nil {
+				t.Fatalf("withRequestExpiry() = %v", err)
+			}
+
+			request := struct {
+				ExpiresAt string `json:"expiresAt"`
+			}{}
+
+			if err = json.Unmarshal(requestBytes, &request); err != nil {
+				t.Fatal(err)
+			}
+
+			if request.ExpiresAt != test.want {
+				t.Fatalf("withRequestExpiry() expiresAt = %q, want %q", request.ExpiresAt, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package person
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// account records and accepted request data are kept in the private data collection of the holder org
+// - collections_config.json, the channel state keeps a privateRecord with the hash of the record only
+const privateRecordObjectType = "privateRecord"
+
+// record types of the private records
+const (
+	privateAccountRecord  = "person"
+	privateAcceptedRecord = "acceptedData"
+)
+
+// org MSP ID -> private data collection
+var accountCollections = map[string]string{
+	"SipherMSP":   "SipherAccounts",
+	"WhiteBoxMSP": "WhiteBoxAccounts",
+}
+
+// privateRecord is the public part of a private record
+type privateRecord struct {
+	ObjectType string `json:"docType"`
+	Key        string `json:"key"`
+	RecordType string `json:"recordType"`
+	Collection string `json:"collection"`
+	Hash       string `json:"hash"`
+	UpdatedAt  string `json:"updatedAt"`
+
+	// identity which created the record - access.go
+	Owner string `json:"owner,omitempty"`
+}
+
+// key of the accepted data of a request
+func acceptedDataKey(requestID string) string {
+
+	return "acceptedData/" + requestID
+}
+
+// holderCollection returns the collection of the org of the caller
+func holderCollection(stub shim.ChaincodeStubInterface) (string, error) {
+
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", err
+	}
+
+	collection, ok := accountCollections[mspID]
+	if !ok {
+		return "", errors.New("No private data collection exists for organization " + mspID)
+	}
+
+	return collection, nil
+}
+
+func recordHash(value []byte) string {
+
+	hash := sha256.Sum256(value)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// putPrivateRecord writes the record to the collection of the caller org and its hash to the channel state
+// a record keeps the collection and the owner it was created with
+func putPrivateRecord(stub shim.ChaincodeStubInterface, key, recordType string, value []byte) (*privateRecord, error) {
+
+	marker, err := readPrivateRecordMarker(stub, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if marker == nil {
+		collection, err := holderCollection(stub)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err := cid.GetID(stub)
+		if err != nil {
+			return nil, err
+		}
+
+		marker = &privateRecord{
+			ObjectType: privateRecordObjectType,
+			Key:        key,
+			RecordType: recordType,
+			Collection: collection,
+			Owner:      owner,
+		}
+	}
+
+	marker.Hash = recordHash(value)
+	marker.UpdatedAt = getTime()
+
+	markerAsBytes, err := json.Marshal(marker)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = stub.PutPrivateData(marker.Collection, key, value); err != nil {
+		return nil, err
+	}
+
+	if err = stub.PutState(key, markerAsBytes); err != nil {
+		return nil, err
+	}
+
+	return marker, nil
+}
+
+// readPrivateRecordMarker returns the public part of the record, nil when the record does not exist
+func readPrivateRecordMarker(stub shim.ChaincodeStubInterface, key string) (*privateRecord, error) {
+
+	markerAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if markerAsBytes == nil {
+		return nil, nil
+	}
+
+	marker := &privateRecord{}
+	if err = json.Unmarshal(markerAsBytes, marker); err != nil {
+		return nil, err
+	}
+
+	if marker.ObjectType != privateRecordObjectType {
+		return nil, errors.New("Record " + key + " is not a private record")
+	}
+
+	return marker, nil
+}
+
+// getPrivateRecord returns the record and its public part, nil when the record does not exist
+// the record is read from the collection on the peer - peers of other orgs do not have it
+func getPrivateRecord(stub shim.ChaincodeStubInterface, key string) ([]byte, *privateRecord, error) {
+
+	marker, err := readPrivateRecordMarker(stub, key)
+	if err != nil || marker == nil {
+		return nil, nil, err
+	}
+
+	value, err := stub.GetPrivateData(marker.Collection, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if value == nil {
+		return nil, nil, newStatusError(statusPrivateDataUnavailable, "Private record "+key+" is not available on this peer")
+	}
+
+	if recordHash(value) != marker.Hash {
+		return nil, nil, errors.New("Private record " + key + " does not match its hash")
+	}
+
+	return value, marker, nil
+}
+
+// delPrivateRecord removes the record from its collection and the hash from the channel state
+func delPrivateRecord(stub shim.ChaincodeStubInterface, key string) error {
+
+	marker, err := readPrivateRecordMarker(stub, key)
+	if err != nil {
+		return err
+	}
+
+	if marker == nil {
+		return newStatusError(statusNotFound, "Private record "+key+" does not exist")
+	}
+
+	if err = stub.DelPrivateData(marker.Collection, key); err != nil {
+		return err
+	}
+
+	return stub.DelState(key)
+}
+
+// getPrivateQueryResult runs the rich query in every collection
+// the collections of other orgs are empty on the peer, only the records of its own org are returned
+func getPrivateQueryResult(stub shim.ChaincodeStubInterface, queryString string) ([]byte, error) {
+
+	fmt.Printf("- getPrivateQueryResult queryString:\n%s\n", queryString)
+
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+
+	bArrayMemberAlreadyWritten := false
+	for _, collection := range sortedCollections() {
+		resultsIterator, err := stub.GetPrivateDataQueryResult(collection, queryString)
+		if err != nil {
+			return nil, err
+		}
+
+		collectionBuffer, err := constructQueryResponseFromIterator(resultsIterator)
+		resultsIterator.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		// [] -> no records in the collection
+		results := collectionBuffer.Bytes()
+		if len(results) <= 2 {
+			continue
+		}
+
+		if bArrayMemberAlreadyWritten == true {
+			buffer.WriteString(",")
+		}
+		buffer.Write(results[1 : len(results)-1])
+		bArrayMemberAlreadyWritten = true
+	}
+	buffer.WriteString("]")
+
+	return buffer.Bytes(), nil
+}
+
+// collections in a fixed order - query results are the same on every peer
+func sortedCollections() []string {
+
+	collections := make([]string, 0, len(accountCollections))
+	for _, collection := range accountCollections {
+		collections = append(collections, collection)
+	}
+
+	sort.Strings(collections)
+
+	return collections
+}
@@ -1,4 +1,4 @@
-package main
+package person
 
 import (
 	"encoding/json"
@@ -18,19 +18,19 @@ func (t *CerberusPersonAccounts) createRequest(stub shim.ChaincodeStubInterface,
 	fmt.Println("Start createRequest initialization.")
 
 	if len(args) < 3 {
-		return shim.Error("Incorrect number of arguments. Expecting at least 3")
+		return invalidArgument("Incorrect number of arguments. Expecting at least 3")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
+		return invalidArgument("3rd argument must be a non-empty string")
 	}
 
 	// assign values
@@ -45,7 +45,7 @@ func (t *CerberusPersonAccounts) createRequest(stub shim.ChaincodeStubInterface,
 		return t.createDocumentDataRequest(stub, requestArgs)
 
 	default:
-		return shim.Error("Request type not found.")
+		return invalidArgument("Request type not found.")
 	}
 }
 
@@ -59,14 +59,24 @@ func (t *CerberusPersonAccounts) createAccountDataRequest(stub shim.ChaincodeStu
 	err := json.Unmarshal([]byte(requestObject), newRequest)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> the requester creates the request
+	if err = authorizeRequest(stub, newRequest.PublicID, newRequest.RequesterPublicID, newRequest.RecipientPublicID, roleRequester); err != nil {
+		return unauthorized(err)
+	}
+
+	// key -> chosen by the requester, never an existing record
+	if err = checkNewRequestKey(stub, newRequest.PublicID); err != nil {
+		return errorResponse(err)
 	}
 
 	// check attributes
 	_, err = t.checkRequestAttributes(stub, []string{newRequest.RequesterPublicID, newRequest.RecipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// store requested data
@@ -74,7 +84,7 @@ func (t *CerberusPersonAccounts) createAccountDataRequest(stub shim.ChaincodeStu
 	err = json.Unmarshal([]byte(requestData), &requestedFields)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	requestedData := storeRequestedData(requestedFields)
@@ -83,30 +93,46 @@ func (t *CerberusPersonAccounts) createAccountDataRequest(stub shim.ChaincodeStu
 	requestExistAsBytes, _, err := t.checkRequestImage(stub, []string{newRequest.RequesterPublicID, newRequest.RecipientPublicID, requestedData})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestExistAsBytes != nil {
-		return shim.Error("Request with same image already exists")
+		return alreadyExists("Request with same image already exists")
 	}
 
 	// object -> finish creation
 	newRequest.Status = "pending"
 	newRequest.RequestedData = requestedData
 	newRequest.AccountData = requestedFields
-	newRequest.CreatedAt = getTime()
+	newRequest.CreatedAt, err = requestCreatedAt(stub)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// expiry -> requested or default TTL from the transaction timestamp
+	newRequest.ExpiresAt, err = requestExpiresAt(stub, newRequest.ExpiresAt)
+
+	if err != nil {
+		return errorResponse(err)
+	}
 
 	requestAsBytes, err := json.Marshal(newRequest)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// ledger invoke operation -> store with public id key
 	err = stub.PutState(newRequest.PublicID, requestAsBytes)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestCreated, newRequest.PublicID, newRequest.RequestType, newRequest.RequesterPublicID, newRequest.RecipientPublicID, newRequest.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end createAccountDataRequest")
@@ -122,26 +148,36 @@ func (t *CerberusPersonAccounts) createDocumentDataRequest(stub shim.ChaincodeSt
 	err := json.Unmarshal([]byte(requestObject), newRequest)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> the requester creates the request
+	if err = authorizeRequest(stub, newRequest.PublicID, newRequest.RequesterPublicID, newRequest.RecipientPublicID, roleRequester); err != nil {
+		return unauthorized(err)
+	}
+
+	// key -> chosen by the requester, never an existing record
+	if err = checkNewRequestKey(stub, newRequest.PublicID); err != nil {
+		return errorResponse(err)
 	}
 
 	// check attributes
 	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{newRequest.RecipientPublicID, newRequest.RecipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	recipientAccount := &personAccount{}
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// check if requested document exists
 	if _, ok := recipientAccount.Documents[newRequest.DocumentName]; !ok {
-		return shim.Error("Document name " + newRequest.DocumentName + " does not exist in recipient account records and its data cannot be requested")
+		return invalidState("Document name " + newRequest.DocumentName + " does not exist in recipient account records and its data cannot be requested")
 	}
 
 	// store requested data
@@ -149,7 +185,7 @@ func (t *CerberusPersonAccounts) createDocumentDataRequest(stub shim.ChaincodeSt
 	err = json.Unmarshal([]byte(requestData), &requestedFields)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	requestedData := storeRequestedData(requestedFields)
@@ -158,11 +194,11 @@ func (t *CerberusPersonAccounts) createDocumentDataRequest(stub shim.ChaincodeSt
 	requestExistAsBytes, _, err := t.checkRequestImage(stub, []string{newRequest.RequesterPublicID, newRequest.RecipientPublicID, requestedData})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestExistAsBytes != nil {
-		return shim.Error("Request with same image already exists")
+		return alreadyExists("Request with same image already exists")
 	}
 
 	if _, ok := requestedFields["documentCopy"]; ok {
@@ -174,47 +210,82 @@ func (t *CerberusPersonAccounts) createDocumentDataRequest(stub shim.ChaincodeSt
 	newRequest.Status = "pending"
 	newRequest.RequestedData = requestedData
 	newRequest.DocumentData = requestedFields
-	newRequest.CreatedAt = getTime()
+	newRequest.CreatedAt, err = requestCreatedAt(stub)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// expiry -> requested or default TTL from the transaction timestamp
+	newRequest.ExpiresAt, err = requestExpiresAt(stub, newRequest.ExpiresAt)
+
+	if err != nil {
+		return errorResponse(err)
+	}
 
 	requestDataAsBytes, err := json.Marshal(newRequest)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// ledger invoke operation -> store with public id key
 	err = stub.PutState(newRequest.PublicID, requestDataAsBytes)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestCreated, newRequest.PublicID, newRequest.RequestType, newRequest.RequesterPublicID, newRequest.RecipientPublicID, newRequest.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end createDocumentDataRequest")
 	return shim.Success(requestDataAsBytes)
 }
 
+// checkNewRequestKey refuses the key of a new request when any record - an account, a request or accepted data - uses it
+func checkNewRequestKey(stub shim.ChaincodeStubInterface, key string) error {
+
+	if key == "" {
+		return newStatusError(statusInvalidArgument, "Request public ID must be a non-empty string")
+	}
+
+	existingAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+
+	if existingAsBytes != nil {
+		return newStatusError(statusAlreadyExists, "Record "+key+" already exists")
+	}
+
+	return nil
+}
+
 func (t *CerberusPersonAccounts) acceptRequest(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	fmt.Println("Start acceptRequest initialization.")
 
 	if len(args) < 4 {
-		return shim.Error("Incorrect number of arguments. Expecting at least 4.")
+		return invalidArgument("Incorrect number of arguments. Expecting at least 4.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
+		return invalidArgument("3rd argument must be a non-empty string")
 	}
 
 	if len(args[3]) <= 0 {
-		return shim.Error("4th argument must be a non-empty string")
+		return invalidArgument("4th argument must be a non-empty string")
 	}
 
 	// assign values
@@ -229,7 +300,7 @@ func (t *CerberusPersonAccounts) acceptRequest(stub shim.ChaincodeStubInterface,
 		return t.acceptDocumentDataRequest(stub, requestArgs)
 
 	default:
-		return shim.Error("Request type not found.")
+		return invalidArgument("Request type not found.")
 	}
 }
 
@@ -243,25 +314,30 @@ func (t *CerberusPersonAccounts) acceptAccountDataRequest(stub shim.ChaincodeStu
 	requestBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestBytes == nil {
-		return shim.Error("Request with id: " + requestID + " does not exist")
+		return notFound("Request with id: " + requestID + " does not exist")
 	}
 
 	request := &accountDataRequest{}
 	err = json.Unmarshal(requestBytes, request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> only the recipient accepts the request
+	if err = authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roleRecipient); err != nil {
+		return unauthorized(err)
 	}
 
 	// check attributes
 	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{request.RequesterPublicID, recipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// get recipient account data
@@ -269,15 +345,26 @@ func (t *CerberusPersonAccounts) acceptAccountDataRequest(stub shim.ChaincodeStu
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if recipientAccount.PublicID != request.RecipientPublicID {
-		return shim.Error("Request recipient ID does not match the provided ID")
+		return unauthorized(errors.New("Request recipient ID does not match the provided ID"))
 	}
 
 	if request.Status != "pending" {
-		return shim.Error("Request status is already " + request.Status + " and cannot be accepted")
+		return invalidState("Request status is already " + request.Status + " and cannot be accepted")
+	}
+
+	// check request expiry -> transaction timestamp
+	expired, err := requestExpired(stub, request.CreatedAt, request.ExpiresAt)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if expired {
+		return invalidState("Request with ID " + request.PublicID + " has expired and cannot be accepted")
 	}
 
 	// obtain data
@@ -285,7 +372,7 @@ func (t *CerberusPersonAccounts) acceptAccountDataRequest(stub shim.ChaincodeStu
 	fieldsDataAsBytes, err := json.Marshal(fieldsData)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// umarshal account data as a map to create intersection
@@ -293,14 +380,14 @@ func (t *CerberusPersonAccounts) acceptAccountDataRequest(stub shim.ChaincodeStu
 	err = json.Unmarshal(fieldsDataAsBytes, &values)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	acceptedFields := make(map[string]string)
 	err = json.Unmarshal([]byte(acceptedData), &acceptedFields)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// match accepted fields values
@@ -310,20 +397,38 @@ func (t *CerberusPersonAccounts) acceptAccountDataRequest(stub shim.ChaincodeStu
 		}
 	}
 
-	request.AccountData = acceptedFields
+	// accepted values -> collection of the recipient org, the request keeps their hash
+	acceptedFieldsAsBytes, err := json.Marshal(acceptedFields)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	acceptedMarker, err := putPrivateRecord(stub, acceptedDataKey(requestID), privateAcceptedRecord, acceptedFieldsAsBytes)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	request.AcceptedDataHash = acceptedMarker.Hash
 	request.Status = "accepted"
 	request.UpdatedAt = getTime()
 
 	acceptedRequestAsBytes, err := json.Marshal(request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	err = stub.PutState(requestID, acceptedRequestAsBytes) // store with public id again
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestAccepted, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end acceptAccountDocumentRequest")
@@ -340,25 +445,30 @@ func (t *CerberusPersonAccounts) acceptDocumentDataRequest(stub shim.ChaincodeSt
 	requestBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestBytes == nil {
-		return shim.Error("Request with id: " + requestId + " does not exist")
+		return notFound("Request with id: " + requestID + " does not exist")
 	}
 
 	request := &documentDataRequest{}
 	err = json.Unmarshal(requestBytes, request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> only the recipient accepts the request
+	if err = authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roleRecipient); err != nil {
+		return unauthorized(err)
 	}
 
 	// check attributes
 	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{request.RequesterPublicID, recipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// get recipient account data
@@ -366,15 +476,26 @@ func (t *CerberusPersonAccounts) acceptDocumentDataRequest(stub shim.ChaincodeSt
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if recipientAccount.PublicID != request.RecipientPublicID {
-		return shim.Error("Request recipient ID does not match the provided ID")
+		return unauthorized(errors.New("Request recipient ID does not match the provided ID"))
 	}
 
 	if request.Status != "pending" {
-		return shim.Error("Request status is already " + request.Status + " and cannot be accepted")
+		return invalidState("Request status is already " + request.Status + " and cannot be accepted")
+	}
+
+	// check request expiry -> transaction timestamp
+	expired, err := requestExpired(stub, request.CreatedAt, request.ExpiresAt)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if expired {
+		return invalidState("Request with ID " + request.PublicID + " has expired and cannot be accepted")
 	}
 
 	// obtain data
@@ -382,7 +503,7 @@ func (t *CerberusPersonAccounts) acceptDocumentDataRequest(stub shim.ChaincodeSt
 	fieldsDataAsBytes, err := json.Marshal(fieldsData)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// umarshal account data as a map to create intersection
@@ -390,14 +511,14 @@ func (t *CerberusPersonAccounts) acceptDocumentDataRequest(stub shim.ChaincodeSt
 	err = json.Unmarshal(fieldsDataAsBytes, &values)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	acceptedFields := make(map[string]string)
 	err = json.Unmarshal([]byte(acceptedData), &acceptedFields)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// match accepted fields values
@@ -407,20 +528,38 @@ func (t *CerberusPersonAccounts) acceptDocumentDataRequest(stub shim.ChaincodeSt
 		}
 	}
 
-	request.DocumentData = acceptedFields
+	// accepted values -> collection of the recipient org, the request keeps their hash
+	acceptedFieldsAsBytes, err := json.Marshal(acceptedFields)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	acceptedMarker, err := putPrivateRecord(stub, acceptedDataKey(requestID), privateAcceptedRecord, acceptedFieldsAsBytes)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	request.AcceptedDataHash = acceptedMarker.Hash
 	request.Status = "accepted"
 	request.UpdatedAt = getTime()
 
 	acceptedRequestAsBytes, err := json.Marshal(request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	err = stub.PutState(requestID, acceptedRequestAsBytes)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestAccepted, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end acceptDocumentDataRequest")
@@ -432,19 +571,19 @@ func (t *CerberusPersonAccounts) rejectRequest(stub shim.ChaincodeStubInterface,
 	fmt.Println("Start rejectRequest initialization.")
 
 	if len(args) < 3 {
-		return shim.Error("Incorrect number of arguments. Expecting 2.")
+		return invalidArgument("Incorrect number of arguments. Expecting 2.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
+		return invalidArgument("3rd argument must be a non-empty string")
 	}
 
 	// assign values
@@ -459,38 +598,43 @@ func (t *CerberusPersonAccounts) rejectRequest(stub shim.ChaincodeStubInterface,
 		return t.rejectDocumentDataRequest(stub, requestArgs)
 
 	default:
-		return shim.Error("Request type not recognized.")
+		return invalidArgument("Request type not recognized.")
 	}
 }
 
 func (t *CerberusPersonAccounts) rejectAccountDataRequest(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	requestD := args[0]
+	requestID := args[0]
 	recipientPublicID := args[1]
 
 	// obtain request object
 	requestBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestBytes == nil {
-		return shim.Error("Request with ID: " + requestID + " does not exist")
+		return notFound("Request with ID: " + requestID + " does not exist")
 	}
 
 	request := &accountDataRequest{}
 	err = json.Unmarshal(requestBytes, request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> only the recipient rejects the request
+	if err = authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roleRecipient); err != nil {
+		return unauthorized(err)
 	}
 
 	// check attributes
-	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{request.RequesterPublicId, recipientPublicId})
+	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{request.RequesterPublicID, recipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// get recipient account data
@@ -498,15 +642,15 @@ func (t *CerberusPersonAccounts) rejectAccountDataRequest(stub shim.ChaincodeStu
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if recipientAccount.PublicID != request.RecipientPublicID {
-		return shim.Error("Request recipient ID does not match the provided ID")
+		return unauthorized(errors.New("Request recipient ID does not match the provided ID"))
 	}
 
 	if request.Status != "pending" {
-		return shim.Error("Request status is already " + request.Status + " and cannot be rejected")
+		return invalidState("Request status is already " + request.Status + " and cannot be rejected")
 	}
 
 	request.Status = "rejected"
@@ -515,13 +659,18 @@ func (t *CerberusPersonAccounts) rejectAccountDataRequest(stub shim.ChaincodeStu
 	requestUpdateAsBytes, err := json.Marshal(request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	err = stub.PutState(requestId, requestUpdateAsBytes)
+	err = stub.PutState(requestID, requestUpdateAsBytes)
+
+	if err != nil {
+		return errorResponse(err)
+	}
 
+	err = emitRequestEvent(stub, eventRequestRejected, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end rejectAccountDataRequest")
@@ -537,25 +686,30 @@ func (t *CerberusPersonAccounts) rejectDocumentDataRequest(stub shim.ChaincodeSt
 	queryResultBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if queryResultBytes == nil {
-		return shim.Error("No requests with ID: " + requestID + " exist.")
+		return notFound("No requests with ID: " + requestID + " exist.")
 	}
 
 	request := &documentDataRequest{}
 	err = json.Unmarshal(queryResultBytes, request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> only the recipient rejects the request
+	if err = authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roleRecipient); err != nil {
+		return unauthorized(err)
 	}
 
 	// check attributes
 	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{request.RequesterPublicID, recipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// get recipient account data
@@ -563,15 +717,15 @@ func (t *CerberusPersonAccounts) rejectDocumentDataRequest(stub shim.ChaincodeSt
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if recipientAccount.PublicID != request.RecipientPublicID {
-		return shim.Error("Request recipient ID does not match the provided ID")
+		return unauthorized(errors.New("Request recipient ID does not match the provided ID"))
 	}
 
 	if request.Status != "pending" {
-		return shim.Error("Request status is already " + request.Status + " and cannot be rejected")
+		return invalidState("Request status is already " + request.Status + " and cannot be rejected")
 	}
 
 	request.Status = "rejected"
@@ -580,13 +734,18 @@ func (t *CerberusPersonAccounts) rejectDocumentDataRequest(stub shim.ChaincodeSt
 	requestUpdateAsBytes, err := json.Marshal(request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	err = stub.PutState(requestID, requestUpdateAsBytes)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestRejected, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end rejectDocumentDataRequest")
@@ -598,27 +757,27 @@ func (t *CerberusPersonAccounts) updateRequest(stub shim.ChaincodeStubInterface,
 	fmt.Println("Start updateRequest initialization.")
 
 	if len(args) < 5 {
-		return shim.Error("Incorrect number of arguments. Expecting 5.")
+		return invalidArgument("Incorrect number of arguments. Expecting 5.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
+		return invalidArgument("3rd argument must be a non-empty string")
 	}
 
 	if len(args[3]) <= 0 {
-		return shim.Error("4th argument must be a non-empty string")
+		return invalidArgument("4th argument must be a non-empty string")
 	}
 
 	if len(args[4]) <= 0 {
-		return shim.Error("5th argument must be a non-empty string")
+		return invalidArgument("5th argument must be a non-empty string")
 	}
 
 	// assign values
@@ -633,7 +792,7 @@ func (t *CerberusPersonAccounts) updateRequest(stub shim.ChaincodeStubInterface,
 		return t.updateDocumentDataRequest(stub, requestArgs)
 
 	default:
-		return shim.Error("Unknown request type")
+		return invalidArgument("Unknown request type")
 	}
 }
 
@@ -650,36 +809,45 @@ func (t *CerberusPersonAccounts) updateAccountDataRequest(stub shim.ChaincodeStu
 	requestBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestBytes == nil {
-		return shim.Error("Request with id: " + requestID + " does not exist")
+		return notFound("Request with id: " + requestID + " does not exist")
 	}
 
 	request := &accountDataRequest{}
 	err = json.Unmarshal(requestBytes, request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> only the requester updates the request
+	if err = authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roleRequester); err != nil {
+		return unauthorized(err)
+	}
+
+	if requesterPublicID != request.RequesterPublicID {
+		return unauthorized(errors.New("Request requester ID does not match the provided ID"))
 	}
 
 	// check attributes
 	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{requesterPublicID, recipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	recipientAccount := &personAccount{}
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if recipientAccount.PublicID != request.RecipientPublicID {
-		return shim.Error("Request recipient ID does not match the provided ID")
+		return unauthorized(errors.New("Request recipient ID does not match the provided ID"))
 	}
 
 	// check request status
@@ -688,12 +856,23 @@ func (t *CerberusPersonAccounts) updateAccountDataRequest(stub shim.ChaincodeStu
 		return shim.Success(nil)
 	}
 
+	// check request expiry -> transaction timestamp
+	expired, err := requestExpired(stub, request.CreatedAt, request.ExpiresAt)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if expired {
+		return invalidState("Request with ID " + request.PublicID + " has expired and cannot be updated")
+	}
+
 	// store requested data
 	requestedFields := make(map[string]string)
 	err = json.Unmarshal([]byte(data), &requestedFields)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	requestedData := storeRequestedData(requestedFields)
@@ -702,11 +881,11 @@ func (t *CerberusPersonAccounts) updateAccountDataRequest(stub shim.ChaincodeStu
 	requestExistAsBytes, _, err := t.checkRequestImage(stub, []string{requesterPublicID, recipientPublicID, requestedData})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestExistAsBytes != nil {
-		return shim.Error("Request with same image already exists.")
+		return alreadyExists("Request with same image already exists.")
 	}
 
 	// object -> update
@@ -717,14 +896,19 @@ func (t *CerberusPersonAccounts) updateAccountDataRequest(stub shim.ChaincodeStu
 	requestAsBytes, err := json.Marshal(request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// ledger invoke operation -> store with public id key
 	err = stub.PutState(requestID, requestAsBytes)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestUpdated, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end updateAccountDataRequest")
@@ -744,36 +928,45 @@ func (t *CerberusPersonAccounts) updateDocumentDataRequest(stub shim.ChaincodeSt
 	requestBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestBytes == nil {
-		return shim.Error("Request with id: " + requestID + " does not exist")
+		return notFound("Request with id: " + requestID + " does not exist")
 	}
 
 	request := &documentDataRequest{}
 	err = json.Unmarshal(requestBytes, request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	// caller -> only the requester updates the request
+	if err = authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roleRequester); err != nil {
+		return unauthorized(err)
+	}
+
+	if requesterPublicID != request.RequesterPublicID {
+		return unauthorized(errors.New("Request requester ID does not match the provided ID"))
 	}
 
 	// check attributes
 	recipientAccountAsBytes, err := t.checkRequestAttributes(stub, []string{requesterPublicID, recipientPublicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	recipientAccount := &personAccount{}
 	err = json.Unmarshal(recipientAccountAsBytes, recipientAccount)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if recipientAccount.PublicID != request.RecipientPublicID {
-		return shim.Error("Request recipient ID does not match the provided ID")
+		return unauthorized(errors.New("Request recipient ID does not match the provided ID"))
 	}
 
 	// check request status
@@ -782,11 +975,22 @@ func (t *CerberusPersonAccounts) updateDocumentDataRequest(stub shim.ChaincodeSt
 		return shim.Success(nil)
 	}
 
+	// check request expiry -> transaction timestamp
+	expired, err := requestExpired(stub, request.CreatedAt, request.ExpiresAt)
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if expired {
+		return invalidState("Request with ID " + request.PublicID + " has expired and cannot be updated")
+	}
+
 	// data fields -> update
 	requestedFields, err := updateFields(request.RequestedData, data)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// check if data has been already requested
@@ -795,11 +999,11 @@ func (t *CerberusPersonAccounts) updateDocumentDataRequest(stub shim.ChaincodeSt
 	requestExistAsBytes, _, err := t.checkRequestImage(stub, []string{requesterPublicID, recipientPublicID, requestedData})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if requestExistAsBytes != nil {
-		return shim.Error("Request with same image already exists.")
+		return alreadyExists("Request with same image already exists.")
 	}
 
 	// object -> update
@@ -810,14 +1014,19 @@ func (t *CerberusPersonAccounts) updateDocumentDataRequest(stub shim.ChaincodeSt
 	requestAsBytes, err := json.Marshal(request)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// ledger invoke operation -> store with public id key
 	err = stub.PutState(requestID, requestAsBytes)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
+	}
+
+	err = emitRequestEvent(stub, eventRequestUpdated, request.PublicID, request.RequestType, request.RequesterPublicID, request.RecipientPublicID, request.Status)
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end updateDocumentDataRequest")
@@ -827,33 +1036,33 @@ func (t *CerberusPersonAccounts) updateDocumentDataRequest(stub shim.ChaincodeSt
 func (t *CerberusPersonAccounts) checkRequestAttributes(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 
 	if len(args) < 2 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 3")
+		return nil, newStatusError(statusInvalidArgument, "Incorrect number of arguments. Expecting 3")
 	}
 
 	if len(args[0]) <= 0 {
-		return nil, errors.New("1st argument must be a non-empty string")
+		return nil, newStatusError(statusInvalidArgument, "1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return nil, errors.New("2nd argument must be a non-empty string")
+		return nil, newStatusError(statusInvalidArgument, "2nd argument must be a non-empty string")
 	}
 
 	// assign values
 	requesterPublicID := args[0]
 	recipientPublicID := args[1]
 
-	// check if requester exists
-	requesterDataAsBytes, _, err := t.readAccount(stub, []string{requesterPublicID})
+	// check if requester exists - the record may be kept by another org, its hash is enough
+	requesterMarker, err := readPrivateRecordMarker(stub, requesterPublicID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if requesterDataAsBytes == nil {
-		return nil, errors.New("Account with ID (for requester): " + requesterPublicID + " does not exist")
+	if requesterMarker == nil || requesterMarker.RecordType != privateAccountRecord {
+		return nil, newStatusError(statusNotFound, "Account with ID (for requester): "+requesterPublicID+" does not exist")
 	}
 
-	// check if recipient account exists
+	// check if recipient account exists - requests are endorsed by peers of the recipient org
 	recipientAccountAsBytes, _, err := t.readAccount(stub, []string{recipientPublicID})
 
 	if err != nil {
@@ -861,7 +1070,7 @@ func (t *CerberusPersonAccounts) checkRequestAttributes(stub shim.ChaincodeStubI
 	}
 
 	if recipientAccountAsBytes == nil {
-		return nil, errors.New("Account with ID (for recipient): " + recipientPublicID + " does not exist")
+		return nil, newStatusError(statusNotFound, "Account with ID (for recipient): "+recipientPublicID+" does not exist")
 	}
 
 	return recipientAccountAsBytes, nil
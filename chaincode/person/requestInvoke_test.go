@@ -0,0 +1,47 @@
+package person
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestCheckNewRequestKey(t *testing.T) {
+
+	stub := shim.NewMockStub("person", nil)
+	stub.MockTransactionStart("request")
+	defer stub.MockTransactionEnd("request")
+
+	if err := stub.PutState("a1", []byte(`{"docType":"privateRecord"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		status int32
+	}{
+		{"new key", "r1", 0},
+		{"empty key", "", statusInvalidArgument},
+		{"key of an account", "a1", statusAlreadyExists},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			err := checkNewRequestKey(stub, test.key)
+
+			if test.status == 0 {
+				if err != nil {
+					t.Fatalf("checkNewRequestKey(%q) = %v, want no error", test.key, err)
+				}
+				return
+			}
+
+			statusErr, ok := err.(*statusError)
+			if !ok || statusErr.status != test.status {
+				t.Fatalf("checkNewRequestKey(%q) = %v, want status %d", test.key, err, test.status)
+			}
+		})
+	}
+}
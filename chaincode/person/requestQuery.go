@@ -1,6 +1,7 @@
-package main
+package person
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -8,20 +9,26 @@ import (
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
+// selectors of queryRequests an account may use with its own publicID
+var requestOwnSelectors = map[string]bool{
+	"requesterPublicID": true,
+	"recipientPublicID": true,
+}
+
 func (t *CerberusPersonAccounts) queryRequestData(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	fmt.Println("Start queryRequestData a initialization.")
 
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 1.")
+		return invalidArgument("Incorrect number of arguments. Expecting 1.")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	idType := args[0]
@@ -31,31 +38,99 @@ func (t *CerberusPersonAccounts) queryRequestData(stub shim.ChaincodeStubInterfa
 	queryResultBytes, _, err := t.readRequest(stub, []string{idType, publicID})
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	if queryResultBytes == nil {
-		return shim.Error("No requests with " + idType + " : " + publicID + " exist.")
+		return notFound("No requests with " + idType + " : " + publicID + " exist.")
+	}
+
+	// caller -> requester or recipient of the request
+	if err = t.authorizeRequestRecord(stub, queryResultBytes, roleRequester, roleRecipient); err != nil {
+		return unauthorized(err)
+	}
+
+	// expiry -> reported for every request, clients do not derive it
+	queryResultBytes, err = withRequestExpiry(queryResultBytes)
+
+	if err != nil {
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end queryRequestData: " + string(queryResultBytes))
 	return shim.Success(queryResultBytes)
 }
 
+// requestID
+// returns the accepted values of a request - read on a peer of the recipient org
+func (t *CerberusPersonAccounts) queryAcceptedData(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	fmt.Println("Start queryAcceptedData initialization.")
+
+	if len(args) < 1 {
+		return invalidArgument("Incorrect number of arguments. Expecting 1.")
+	}
+
+	if len(args[0]) <= 0 {
+		return invalidArgument("1st argument must be a non-empty string")
+	}
+
+	requestID := args[0]
+
+	requestBytes, _, err := t.readRequest(stub, []string{"publicID", requestID})
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if requestBytes == nil {
+		return notFound("Request with id: " + requestID + " does not exist")
+	}
+
+	// caller -> requester or recipient of the request
+	if err = t.authorizeRequestRecord(stub, requestBytes, roleRequester, roleRecipient); err != nil {
+		return unauthorized(err)
+	}
+
+	acceptedDataAsBytes, _, err := getPrivateRecord(stub, acceptedDataKey(requestID))
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if acceptedDataAsBytes == nil {
+		return notFound("No accepted data for request with ID: " + requestID + " exist.")
+	}
+
+	fmt.Println("- end queryAcceptedData")
+	return shim.Success(acceptedDataAsBytes)
+}
+
+// authorizeRequestRecord checks the roles of the caller on a stored request of either type
+func (t *CerberusPersonAccounts) authorizeRequestRecord(stub shim.ChaincodeStubInterface, requestBytes []byte, roles ...string) error {
+
+	request := &accountDataRequest{}
+	if err := json.Unmarshal(requestBytes, request); err != nil {
+		return err
+	}
+
+	return authorizeRequest(stub, request.PublicID, request.RequesterPublicID, request.RecipientPublicID, roles...)
+}
+
 func (t *CerberusPersonAccounts) readRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, string, error) {
 
 	var resultBytes []byte
 
 	if len(args) < 2 {
-		return nil, "", fmt.Errorf("Not enough arguments provided")
+		return nil, "", newStatusError(statusInvalidArgument, "Not enough arguments provided")
 	}
 
 	if len(args[0]) <= 0 {
-		return nil, "", fmt.Errorf("1st argument must be a non-empty string")
+		return nil, "", newStatusError(statusInvalidArgument, "1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return nil, "", fmt.Errorf("2nd argument must be a non-empty string")
+		return nil, "", newStatusError(statusInvalidArgument, "2nd argument must be a non-empty string")
 	}
 
 	// assign values
@@ -72,7 +147,7 @@ func (t *CerberusPersonAccounts) readRequest(stub shim.ChaincodeStubInterface, a
 		queryString = fmt.Sprintf("{\"selector\":{\"docType\":\"persAccntsRequest\",\"ID\":\"%s\"}}", ID)
 
 	default:
-		return nil, "", fmt.Errorf("Unknown ID type")
+		return nil, "", newStatusError(statusInvalidArgument, "Unknown ID type")
 	}
 
 	// obtain records
@@ -108,23 +183,28 @@ func (t *CerberusPersonAccounts) queryRequests(stub shim.ChaincodeStubInterface,
 	fmt.Println("Start queryRequests initialization")
 
 	if len(args) < 4 {
-		return shim.Error("Incorrect number of arguments. Expecting 4")
+		return invalidArgument("Incorrect number of arguments. Expecting 4")
 	}
 
 	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
+		return invalidArgument("1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 1 {
-		return shim.Error("2nd argument must be a non-empty string")
+		return invalidArgument("2nd argument must be a non-empty string")
 	}
 
 	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
+		return invalidArgument("3rd argument must be a non-empty string")
 	}
 
 	if len(args[3]) <= 0 {
-		return shim.Error("4th argument must be a non-empty string")
+		return invalidArgument("4th argument must be a non-empty string")
+	}
+
+	// requests of an account -> requester or recipient, other selectors -> admin
+	if err := authorizeSelector(stub, args[2], strings.ToLower(args[3]), requestOwnSelectors); err != nil {
+		return unauthorized(err)
 	}
 
 	// assign values
@@ -139,7 +219,7 @@ func (t *CerberusPersonAccounts) queryRequests(stub shim.ChaincodeStubInterface,
 		return t.queryRequestsPublicIDs(stub, queryArgs)
 
 	default:
-		return shim.Error("Query type not found")
+		return invalidArgument("Query type not found")
 	}
 }
 
@@ -163,14 +243,14 @@ func (t *CerberusPersonAccounts) queryRequestsObjects(stub shim.ChaincodeStubInt
 		queryString = fmt.Sprintf("{\"selector\":{\"docType\":\"persAccntsRequest\",\"%s\":\"%s\"}}", selectorKey, selectorValue)
 
 	default:
-		return shim.Error("Unknown request type")
+		return invalidArgument("Unknown request type")
 	}
 
 	// obtain records
 	queryResults, err := getQueryResultForQueryString(stub, queryString)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end queryRequestsObjects by: " + selectorKey + ": " + string(queryResults))
@@ -197,7 +277,7 @@ func (t *CerberusPersonAccounts) queryRequestsPublicIDs(stub shim.ChaincodeStubI
 		queryString = fmt.Sprintf("{\"selector\":{\"docType\":\"persAccntsRequest\",\"%s\":\"%s\"},\"fields\":[\"publicId\"]}", selectorKey, selectorValue)
 
 	default:
-		return shim.Error("Unknown request type")
+		return invalidArgument("Unknown request type")
 
 	}
 
@@ -205,7 +285,7 @@ func (t *CerberusPersonAccounts) queryRequestsPublicIDs(stub shim.ChaincodeStubI
 	queryResults, err := getQueryResultForQueryString(stub, queryString)
 
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	fmt.Println("- end queryRequestsPublicIDs by: " + selectorKey + ": " + string(queryResults))
@@ -215,19 +295,19 @@ func (t *CerberusPersonAccounts) queryRequestsPublicIDs(stub shim.ChaincodeStubI
 func (t *CerberusPersonAccounts) checkRequestImage(stub shim.ChaincodeStubInterface, args []string) ([]byte, string, error) {
 
 	if len(args) < 3 {
-		return nil, "", fmt.Errorf("Not enough arguments provided")
+		return nil, "", newStatusError(statusInvalidArgument, "Not enough arguments provided")
 	}
 
 	if len(args[0]) <= 0 {
-		return nil, "", fmt.Errorf("1st argument must be a non-empty string")
+		return nil, "", newStatusError(statusInvalidArgument, "1st argument must be a non-empty string")
 	}
 
 	if len(args[1]) <= 0 {
-		return nil, "", fmt.Errorf("2nd argument must be a non-empty string")
+		return nil, "", newStatusError(statusInvalidArgument, "2nd argument must be a non-empty string")
 	}
 
 	if len(args[2]) <= 0 {
-		return nil, "", fmt.Errorf("3rd argument must be a non-empty string")
+		return nil, "", newStatusError(statusInvalidArgument, "3rd argument must be a non-empty string")
 	}
 
 	requesterPublicID := args[0]
@@ -253,6 +333,21 @@ func (t *CerberusPersonAccounts) checkRequestImage(stub shim.ChaincodeStubInterf
 			return nil, "", err
 		}
 
+		// pending requests past their expiry are no duplicates, even before a sweep
+		request := &accountDataRequest{}
+		if err = json.Unmarshal(response.Value, request); err != nil {
+			return nil, "", err
+		}
+
+		expired, err := requestExpired(stub, request.CreatedAt, request.ExpiresAt)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if expired {
+			continue
+		}
+
 		resultBytes = response.Value
 	}
 
@@ -0,0 +1,128 @@
+package person
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const rootDirectoryObjectType = "rootDirectory"
+
+var rootDirectoryGroups = map[string]bool{
+	"personAccounts":      true,
+	"institutionAccounts": true,
+}
+
+// group
+func (t *CerberusPersonAccounts) queryRootDirectory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	fmt.Println("Start queryRootDirectory initialization.")
+
+	if len(args) < 1 {
+		return invalidArgument("Incorrect number of arguments. Expecting 1.")
+	}
+
+	if len(args[0]) <= 0 {
+		return invalidArgument("1st argument must be a non-empty string")
+	}
+
+	group := args[0]
+
+	rootAsBytes, err := t.readRootDirectory(stub, group)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// no root directory is registered yet - empty payload
+	fmt.Println("- end queryRootDirectory")
+	return shim.Success(rootAsBytes)
+}
+
+// group, previous root content identifier, new root content identifier
+// the update is applied only when the registered root still matches the previous one
+func (t *CerberusPersonAccounts) updateRootDirectory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	fmt.Println("Start updateRootDirectory initialization.")
+
+	if len(args) < 3 {
+		return invalidArgument("Incorrect number of arguments. Expecting 3.")
+	}
+
+	if len(args[0]) <= 0 {
+		return invalidArgument("1st argument must be a non-empty string")
+	}
+
+	if len(args[2]) <= 0 {
+		return invalidArgument("3rd argument must be a non-empty string")
+	}
+
+	group := args[0]
+	previousRoot := args[1]
+	newRoot := args[2]
+
+	// group roots are registered by the service identity
+	if err := authorizeAdmin(stub, "root directory "+group); err != nil {
+		return unauthorized(err)
+	}
+
+	rootAsBytes, err := t.readRootDirectory(stub, group)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var currentRoot string
+
+	if rootAsBytes != nil {
+		root := &rootDirectory{}
+		if err = json.Unmarshal(rootAsBytes, root); err != nil {
+			return errorResponse(err)
+		}
+
+		currentRoot = root.ContentIdentifier
+	}
+
+	if currentRoot != previousRoot {
+		return conflict("Root directory for group " + group + " has been updated concurrently")
+	}
+
+	root := &rootDirectory{
+		ObjectType:        rootDirectoryObjectType,
+		Group:             group,
+		ContentIdentifier: newRoot,
+		UpdatedAt:         getTime(),
+	}
+
+	rootAsBytes, err = json.Marshal(root)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	key, err := stub.CreateCompositeKey(rootDirectoryObjectType, []string{group})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// ledger invoke operation
+	if err = stub.PutState(key, rootAsBytes); err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end updateRootDirectory: " + group + " -> " + newRoot)
+	return shim.Success(rootAsBytes)
+}
+
+func (t *CerberusPersonAccounts) readRootDirectory(stub shim.ChaincodeStubInterface, group string) ([]byte, error) {
+
+	if !rootDirectoryGroups[group] {
+		return nil, newStatusError(statusInvalidArgument, "Unknown root directory group: "+group)
+	}
+
+	key, err := stub.CreateCompositeKey(rootDirectoryObjectType, []string{group})
+	if err != nil {
+		return nil, err
+	}
+
+	return stub.GetState(key)
+}
@@ -1,4 +1,4 @@
-package main
+package person
 
 import (
 	"fmt"
@@ -7,6 +7,7 @@ import (
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
+// CerberusPersonAccounts is the person accounts chaincode - started by cmd, run in memory by app/person.MemoryLedger
 type CerberusPersonAccounts struct{}
 
 func (t *CerberusPersonAccounts) Init(stub shim.ChaincodeStubInterface) pb.Response {
@@ -24,6 +25,11 @@ func (t *CerberusPersonAccounts) Invoke(stub shim.ChaincodeStubInterface) pb.Res
 	function, args := stub.GetFunctionAndParameters()
 	fmt.Println("Invoke is running " + function)
 
+	// args are kept on the ledger, secrets belong in the transient map
+	if err := checkSecretArgs(stub, function, args); err != nil {
+		return errorResponse(err)
+	}
+
 	// Handle different functions
 	switch function {
 
@@ -37,6 +43,16 @@ func (t *CerberusPersonAccounts) Invoke(stub shim.ChaincodeStubInterface) pb.Res
 	case "queryRecords":
 		return t.queryRecords(stub, args)
 
+	case "queryAccounts":
+		return t.queryAccounts(stub, args)
+
+	// ipfs group root directories: personAccounts, institutionAccounts
+	case "queryRootDirectory":
+		return t.queryRootDirectory(stub, args)
+
+	case "updateRootDirectory":
+		return t.updateRootDirectory(stub, args)
+
 	// updateRecords : updateAccount, updateDocumentRecords
 	case "updateRecords":
 		return t.updateRecords(stub, args)
@@ -63,18 +79,19 @@ func (t *CerberusPersonAccounts) Invoke(stub shim.ChaincodeStubInterface) pb.Res
 	case "queryRequests":
 		return t.queryRequests(stub, args)
 
-	default:
-		return shim.Error("Function name not found.")
-	}
+	// pending requests past their expiry -> expired
+	case "sweepExpiredRequests":
+		return t.sweepExpiredRequests(stub, args)
 
-	return shim.Success(nil)
-}
+	// accepted values in the collection of the recipient org
+	case "queryAcceptedData":
+		return t.queryAcceptedData(stub, args)
 
-func main() {
+	// several invoke operations in one transaction
+	case "batchInvoke":
+		return t.batchInvoke(stub, args)
 
-	err := shim.Start(new(CerberusPersonAccounts))
-
-	if err != nil {
-		fmt.Println("Error starting Person chaincode: %s", err.Error())
+	default:
+		return invalidArgument("Function name not found.")
 	}
 }
@@ -0,0 +1,73 @@
+package person
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// status of the error responses - clients tell the kind of an error by the status, never by the message
+// the values are part of the chaincode interface and must not change, other errors are 500
+const (
+	statusInvalidArgument        = 400
+	statusUnauthorized           = 403
+	statusNotFound               = 404
+	statusAlreadyExists          = 409
+	statusConflict               = 412
+	statusInvalidState           = 422
+	statusPrivateDataUnavailable = 424
+)
+
+// statusError is returned by helpers whose failure has a known kind
+type statusError struct {
+	status  int32
+	message string
+}
+
+func (err *statusError) Error() string {
+
+	return err.message
+}
+
+func newStatusError(status int32, message string) error {
+
+	return &statusError{status: status, message: message}
+}
+
+// errorResponse keeps the status of the error, errors of unknown kind fail with shim.Error
+func errorResponse(err error) pb.Response {
+
+	switch typedErr := err.(type) {
+	case *statusError:
+		return pb.Response{Status: typedErr.status, Message: typedErr.message}
+
+	case *unauthorizedError:
+		return unauthorized(err)
+	}
+
+	return shim.Error(err.Error())
+}
+
+func invalidArgument(message string) pb.Response {
+
+	return pb.Response{Status: statusInvalidArgument, Message: message}
+}
+
+func notFound(message string) pb.Response {
+
+	return pb.Response{Status: statusNotFound, Message: message}
+}
+
+func alreadyExists(message string) pb.Response {
+
+	return pb.Response{Status: statusAlreadyExists, Message: message}
+}
+
+func conflict(message string) pb.Response {
+
+	return pb.Response{Status: statusConflict, Message: message}
+}
+
+func invalidState(message string) pb.Response {
+
+	return pb.Response{Status: statusInvalidState, Message: message}
+}
@@ -0,0 +1,121 @@
+package person
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// secrets - account keys, passphrases, wrapped keys - come in the transient map of the proposal
+// the transient map is not written to the ledger, chaincode args are kept in every block forever
+
+// transient key of the passphrase of an account
+// one key per account, the operations of a batch may update several accounts
+func passphraseTransientKey(publicID string) string {
+
+	return "passphrase/" + publicID
+}
+
+// transient key of a private account record - createAccount, updateDocumentRecords
+func recordTransientKey(publicID string) string {
+
+	return "record/" + publicID
+}
+
+// transient key of the new value of an account field - updateAccount
+func valueTransientKey(publicID string) string {
+
+	return "value/" + publicID
+}
+
+// shorter transient values are not searched for in the args
+const minSecretLength = 8
+
+// getTransientSecret returns the secret stored under the transient key
+func getTransientSecret(stub shim.ChaincodeStubInterface, key string) ([]byte, error) {
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, ok := transientMap[key]
+	if !ok || len(secret) == 0 {
+		return nil, newStatusError(statusInvalidArgument, "Transient field "+key+" must be a non-empty value")
+	}
+
+	return secret, nil
+}
+
+// positional args of the functions which read secrets from the transient map - more args are refused,
+// they come from clients which still pass the secrets as args
+var secretFunctionArgs = map[string]int{
+	"createAccount":         1,
+	"updateAccount":         2,
+	"updateDocumentRecords": 1,
+}
+
+// checkSecretArgs refuses invokes which pass secrets in their args:
+// secret-bearing functions called with more args than they take and args repeating a transient secret
+func checkSecretArgs(stub shim.ChaincodeStubInterface, function string, args []string) error {
+
+	if err := checkSecretArgsShape(function, args); err != nil {
+		return err
+	}
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range transientMap {
+		if len(secret) < minSecretLength {
+			continue
+		}
+
+		for _, arg := range args {
+			if strings.Contains(arg, string(secret)) {
+				return newStatusError(statusInvalidArgument, "Secrets must be passed in the transient map, not as arguments")
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkSecretArgsShape follows updateRecords and the operations of a batch to the secret-bearing functions
+func checkSecretArgsShape(function string, args []string) error {
+
+	switch function {
+
+	case "updateRecords":
+		if len(args) > 0 {
+			return checkSecretArgsShape(args[0], args[1:])
+		}
+
+	case "batchInvoke":
+		if len(args) == 0 {
+			return nil
+		}
+
+		// a malformed batch is refused by batchInvoke
+		var operations []batchOperation
+		if err := json.Unmarshal([]byte(args[0]), &operations); err != nil {
+			return nil
+		}
+
+		for i, operation := range operations {
+			if err := checkSecretArgsShape(operation.Function, operation.Args); err != nil {
+				return newStatusError(statusInvalidArgument, "Operation "+strconv.Itoa(i)+": "+err.Error())
+			}
+		}
+	}
+
+	if maxArgs, ok := secretFunctionArgs[function]; ok && len(args) > maxArgs {
+		return newStatusError(statusInvalidArgument, function+" takes "+strconv.Itoa(maxArgs)+" arguments, secrets must be passed in the transient map")
+	}
+
+	return nil
+}
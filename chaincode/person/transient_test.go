@@ -0,0 +1,50 @@
+package person
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestCheckSecretArgs(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		function  string
+		args      []string
+		transient map[string][]byte
+		refused   bool
+	}{
+		{"create account", "createAccount", []string{"a1"}, nil, false},
+		{"create account with the record as argument", "createAccount", []string{"a1", `{"publicID":"a1"}`}, nil, true},
+		{"update account", "updateRecords", []string{"updateAccount", "a1", "firstName"}, nil, false},
+		{"update account with the passphrase as argument", "updateRecords", []string{"updateAccount", "a1", "passphrase", "firstName", "ann"}, nil, true},
+		{"update document records with the record as argument", "updateRecords", []string{"updateDocumentRecords", "a1", "{}"}, nil, true},
+		{"batch", "batchInvoke", []string{`[{"function":"createAccount","args":["a1"]},{"function":"deleteAccount","args":["a2"]}]`}, nil, false},
+		{"batch with the record as argument", "batchInvoke", []string{`[{"function":"deleteAccount","args":["a2"]},{"function":"createAccount","args":["a1","{}"]}]`}, nil, true},
+		{"argument repeating a transient secret", "queryAccounts", []string{"long passphrase"}, map[string][]byte{"passphrase/a1": []byte("long passphrase")}, true},
+		{"short transient value", "deleteAccount", []string{"a1"}, map[string][]byte{"value/a1": []byte("a1")}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			stub := shim.NewMockStub("person", nil)
+			stub.TransientMap = test.transient
+
+			err := checkSecretArgs(stub, test.function, test.args)
+
+			if !test.refused {
+				if err != nil {
+					t.Fatalf("checkSecretArgs() = %v, want no error", err)
+				}
+				return
+			}
+
+			statusErr, ok := err.(*statusError)
+			if !ok || statusErr.status != statusInvalidArgument {
+				t.Fatalf("checkSecretArgs() = %v, want status %d", err, statusInvalidArgument)
+			}
+		})
+	}
+}
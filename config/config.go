@@ -0,0 +1,477 @@
+// Package config loads the Cerberus configuration
+// the configuration file is YAML or TOML, chosen by the file extension
+// every value can be overridden with a CERBERUS_* environment variable
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// CERBERUS_CONFIG - location of the configuration file used by LoadDefault
+const configFileVariable = "CERBERUS_CONFIG"
+
+type Config struct {
+	Environment string           `yaml:"environment" toml:"environment"`
+	Blockchain  BlockchainConfig `yaml:"blockchain" toml:"blockchain"`
+	Ipfs        IpfsConfig       `yaml:"ipfs" toml:"ipfs"`
+	Storage     StorageConfig    `yaml:"storage" toml:"storage"`
+}
+
+type BlockchainConfig struct {
+	// fabric sdk connection profile
+	SdkConfigFile string `yaml:"sdkConfigFile" toml:"sdkConfigFile"`
+
+	ChannelID   string `yaml:"channelId" toml:"channelId"`
+	ChaincodeID string `yaml:"chaincodeId" toml:"chaincodeId"`
+	Org         string `yaml:"org" toml:"org"`
+	User        string `yaml:"user" toml:"user"`
+
+	// endorsers are picked by service discovery, peers endorse when discovery is off or fails
+	Discovery bool     `yaml:"discovery" toml:"discovery"`
+	Peers     []string `yaml:"peers" toml:"peers"`
+
+	// queries are spread over these peers, peers when empty
+	QueryPeers []string `yaml:"queryPeers" toml:"queryPeers"`
+
+	// private records are read on peers of the org holding the collection, queryPeers when empty
+	PrivatePeers []string `yaml:"privatePeers" toml:"privatePeers"`
+
+	// deadline of a single ledger call
+	RequestTimeout Duration `yaml:"requestTimeout" toml:"requestTimeout"`
+
+	Retry RetryConfig `yaml:"retry" toml:"retry"`
+
+	Identities IdentitiesConfig `yaml:"identities" toml:"identities"`
+}
+
+// IdentitiesConfig of the per account Fabric identities
+type IdentitiesConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// fabric - the CA of the org, standIn - identities issued from a local CA key pair for local networks
+	CA          string `yaml:"ca" toml:"ca"`
+	CAName      string `yaml:"caName" toml:"caName"`
+	Affiliation string `yaml:"affiliation" toml:"affiliation"`
+
+	// standIn only - MSP ID of the org and the PEM files of one of its CAs
+	MSPID         string `yaml:"mspId" toml:"mspId"`
+	StandInCACert string `yaml:"standInCACert" toml:"standInCACert"`
+	StandInCAKey  string `yaml:"standInCAKey" toml:"standInCAKey"`
+
+	// enrollments are kept in memory when empty
+	WalletPath string `yaml:"walletPath" toml:"walletPath"`
+}
+
+// RetryConfig of transient endorsement and ordering failures
+type RetryConfig struct {
+	Attempts       int      `yaml:"attempts" toml:"attempts"`
+	InitialBackoff Duration `yaml:"initialBackoff" toml:"initialBackoff"`
+	MaxBackoff     Duration `yaml:"maxBackoff" toml:"maxBackoff"`
+	BackoffFactor  float64  `yaml:"backoffFactor" toml:"backoffFactor"`
+}
+
+type IpfsConfig struct {
+	ApiEndpoint string `yaml:"apiEndpoint" toml:"apiEndpoint"`
+
+	// replication of new document versions
+	Replicas          []string `yaml:"replicas" toml:"replicas"`
+	Cluster           string   `yaml:"cluster" toml:"cluster"`
+	ReplicationQuorum int      `yaml:"replicationQuorum" toml:"replicationQuorum"`
+
+	// key for the derived link names, must not change once accounts exist
+	LinkKey string `yaml:"linkKey" toml:"linkKey"`
+
+	// IPNS keys from the node keystore the group roots are published under
+	PersonAccountsIpnsKey      string `yaml:"personAccountsIpnsKey" toml:"personAccountsIpnsKey"`
+	InstitutionAccountsIpnsKey string `yaml:"institutionAccountsIpnsKey" toml:"institutionAccountsIpnsKey"`
+
+	ContentCacheBytes int64    `yaml:"contentCacheBytes" toml:"contentCacheBytes"`
+	ContentCacheTTL   Duration `yaml:"contentCacheTtl" toml:"contentCacheTtl"`
+}
+
+type StorageConfig struct {
+	TempRoot                    string `yaml:"tempRoot" toml:"tempRoot"`
+	UploadJournalPath           string `yaml:"uploadJournalPath" toml:"uploadJournalPath"`
+	GarbageCollectorJournalPath string `yaml:"garbageCollectorJournalPath" toml:"garbageCollectorJournalPath"`
+	RootJournalPath             string `yaml:"rootJournalPath" toml:"rootJournalPath"`
+	ReferenceIndexPath          string `yaml:"referenceIndexPath" toml:"referenceIndexPath"`
+	ReplicationJournalPath      string `yaml:"replicationJournalPath" toml:"replicationJournalPath"`
+
+	TempTTL           Duration `yaml:"tempTtl" toml:"tempTtl"`
+	TempSweepInterval Duration `yaml:"tempSweepInterval" toml:"tempSweepInterval"`
+	TempAccountQuota  int64    `yaml:"tempAccountQuota" toml:"tempAccountQuota"`
+}
+
+// Duration is written as a Go duration string - "10m", "1h30m"
+type Duration time.Duration
+
+func (duration Duration) Duration() time.Duration {
+
+	return time.Duration(duration)
+}
+
+func (duration *Duration) UnmarshalText(text []byte) error {
+
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*duration = Duration(parsed)
+
+	return nil
+}
+
+func (duration *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return err
+	}
+
+	return duration.UnmarshalText([]byte(text))
+}
+
+func Default() *Config {
+
+	cerberusPath := os.Getenv("GOPATH") + "/src/cerberus"
+
+	return &Config{
+		Environment: "development",
+		Blockchain: BlockchainConfig{
+			SdkConfigFile:  cerberusPath + "/hl/config.yaml",
+			ChannelID:      "persaccntschannel",
+			ChaincodeID:    "persaccntschannelcc",
+			Org:            "Sipher",
+			User:           "User1",
+			Discovery:      true,
+			Peers:          []string{"anchorpr.sipher.cerberus.dev", "anchorpr.whitebox.cerberus.dev"},
+			QueryPeers:     []string{"anchorpr.sipher.cerberus.dev", "anchorpr.whitebox.cerberus.dev"},
+			PrivatePeers:   []string{"anchorpr.sipher.cerberus.dev"},
+			RequestTimeout: Duration(30 * time.Second),
+			Retry: RetryConfig{
+				Attempts:       3,
+				InitialBackoff: Duration(500 * time.Millisecond),
+				MaxBackoff:     Duration(5 * time.Second),
+				BackoffFactor:  2,
+			},
+			Identities: IdentitiesConfig{
+				CA:          "fabric",
+				Affiliation: "sipher.department1",
+				WalletPath:  cerberusPath + "/wallet",
+			},
+		},
+		Ipfs: IpfsConfig{
+			ApiEndpoint:       "localhost:5001",
+			ContentCacheBytes: 64 * 1024 * 1024,
+			ContentCacheTTL:   Duration(10 * time.Minute),
+		},
+		Storage: StorageConfig{
+			TempRoot:                    cerberusPath + "/ipfs",
+			UploadJournalPath:           cerberusPath + "/ipfs/uploads",
+			GarbageCollectorJournalPath: cerberusPath + "/ipfs/gc-journal.json",
+			RootJournalPath:             cerberusPath + "/ipfs/root-journal.json",
+			ReferenceIndexPath:          cerberusPath + "/ipfs/reference-index.json",
+			ReplicationJournalPath:      cerberusPath + "/ipfs/replication-journal.json",
+			TempTTL:                     Duration(time.Hour),
+			TempSweepInterval:           Duration(10 * time.Minute),
+			TempAccountQuota:            512 * 1024 * 1024,
+		},
+	}
+}
+
+// LoadDefault loads the file named by CERBERUS_CONFIG, or only the defaults and the environment
+func LoadDefault() (*Config, error) {
+
+	return Load(os.Getenv(configFileVariable))
+}
+
+// Load reads the file over the defaults, applies the environment overrides and validates the result
+// an empty path skips the file
+func Load(path string) (*Config, error) {
+
+	config := Default()
+
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.applyEnvironment(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (config *Config) readFile(path string) error {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, config)
+
+	case ".toml":
+		_, err = toml.Decode(string(data), config)
+
+	default:
+		return errors.New("Unsupported configuration file format " + filepath.Ext(path))
+	}
+
+	if err != nil {
+		return fmt.Errorf("Unable to read configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (config *Config) applyEnvironment() error {
+
+	texts := map[string]*string{
+		"CERBERUS_ENVIRONMENT":                   &config.Environment,
+		"CERBERUS_SDK_CONFIG_FILE":               &config.Blockchain.SdkConfigFile,
+		"CERBERUS_CHANNEL_ID":                    &config.Blockchain.ChannelID,
+		"CERBERUS_CHAINCODE_ID":                  &config.Blockchain.ChaincodeID,
+		"CERBERUS_ORG":                           &config.Blockchain.Org,
+		"CERBERUS_USER":                          &config.Blockchain.User,
+		"CERBERUS_IPFS_API":                      &config.Ipfs.ApiEndpoint,
+		"CERBERUS_IPFS_CLUSTER":                  &config.Ipfs.Cluster,
+		"CERBERUS_IPFS_LINK_KEY":                 &config.Ipfs.LinkKey,
+		"CERBERUS_PERSON_ACCOUNTS_IPNS_KEY":      &config.Ipfs.PersonAccountsIpnsKey,
+		"CERBERUS_INSTITUTION_ACCOUNTS_IPNS_KEY": &config.Ipfs.InstitutionAccountsIpnsKey,
+		"CERBERUS_CA":                            &config.Blockchain.Identities.CA,
+		"CERBERUS_CA_NAME":                       &config.Blockchain.Identities.CAName,
+		"CERBERUS_CA_AFFILIATION":                &config.Blockchain.Identities.Affiliation,
+		"CERBERUS_WALLET_PATH":                   &config.Blockchain.Identities.WalletPath,
+		"CERBERUS_MSP_ID":                        &config.Blockchain.Identities.MSPID,
+		"CERBERUS_STAND_IN_CA_CERT":              &config.Blockchain.Identities.StandInCACert,
+		"CERBERUS_STAND_IN_CA_KEY":               &config.Blockchain.Identities.StandInCAKey,
+		"CERBERUS_TEMP_ROOT":                     &config.Storage.TempRoot,
+		"CERBERUS_UPLOAD_JOURNAL_PATH":           &config.Storage.UploadJournalPath,
+		"CERBERUS_GC_JOURNAL_PATH":               &config.Storage.GarbageCollectorJournalPath,
+		"CERBERUS_ROOT_JOURNAL_PATH":             &config.Storage.RootJournalPath,
+		"CERBERUS_REFERENCE_INDEX_PATH":          &config.Storage.ReferenceIndexPath,
+		"CERBERUS_REPLICATION_JOURNAL_PATH":      &config.Storage.ReplicationJournalPath,
+	}
+
+	for variable, value := range texts {
+		if environmentValue, ok := os.LookupEnv(variable); ok {
+			*value = environmentValue
+		}
+	}
+
+	lists := map[string]*[]string{
+		"CERBERUS_PEERS":         &config.Blockchain.Peers,
+		"CERBERUS_QUERY_PEERS":   &config.Blockchain.QueryPeers,
+		"CERBERUS_PRIVATE_PEERS": &config.Blockchain.PrivatePeers,
+		"CERBERUS_IPFS_REPLICAS": &config.Ipfs.Replicas,
+	}
+
+	for variable, value := range lists {
+		if environmentValue, ok := os.LookupEnv(variable); ok {
+			*value = splitList(environmentValue)
+		}
+	}
+
+	numbers := map[string]*int64{
+		"CERBERUS_IPFS_CACHE_BYTES":   &config.Ipfs.ContentCacheBytes,
+		"CERBERUS_TEMP_ACCOUNT_QUOTA": &config.Storage.TempAccountQuota,
+	}
+
+	for variable, value := range numbers {
+		if environmentValue, ok := os.LookupEnv(variable); ok {
+			parsed, err := strconv.ParseInt(environmentValue, 10, 64)
+			if err != nil {
+				return errors.New(variable + " must be a number")
+			}
+
+			*value = parsed
+		}
+	}
+
+	integers := map[string]*int{
+		"CERBERUS_IPFS_REPLICATION_QUORUM": &config.Ipfs.ReplicationQuorum,
+		"CERBERUS_RETRY_ATTEMPTS":          &config.Blockchain.Retry.Attempts,
+	}
+
+	for variable, value := range integers {
+		if environmentValue, ok := os.LookupEnv(variable); ok {
+			parsed, err := strconv.Atoi(environmentValue)
+			if err != nil {
+				return errors.New(variable + " must be a number")
+			}
+
+			*value = parsed
+		}
+	}
+
+	switches := map[string]*bool{
+		"CERBERUS_DISCOVERY":          &config.Blockchain.Discovery,
+		"CERBERUS_ACCOUNT_IDENTITIES": &config.Blockchain.Identities.Enabled,
+	}
+
+	for variable, value := range switches {
+		if environmentValue, ok := os.LookupEnv(variable); ok {
+			parsed, err := strconv.ParseBool(environmentValue)
+			if err != nil {
+				return errors.New(variable + " must be true or false")
+			}
+
+			*value = parsed
+		}
+	}
+
+	if environmentValue, ok := os.LookupEnv("CERBERUS_RETRY_BACKOFF_FACTOR"); ok {
+		parsed, err := strconv.ParseFloat(environmentValue, 64)
+		if err != nil {
+			return errors.New("CERBERUS_RETRY_BACKOFF_FACTOR must be a number")
+		}
+
+		config.Blockchain.Retry.BackoffFactor = parsed
+	}
+
+	durations := map[string]*Duration{
+		"CERBERUS_REQUEST_TIMEOUT":       &config.Blockchain.RequestTimeout,
+		"CERBERUS_RETRY_INITIAL_BACKOFF": &config.Blockchain.Retry.InitialBackoff,
+		"CERBERUS_RETRY_MAX_BACKOFF":     &config.Blockchain.Retry.MaxBackoff,
+		"CERBERUS_IPFS_CACHE_TTL":        &config.Ipfs.ContentCacheTTL,
+		"CERBERUS_TEMP_TTL":              &config.Storage.TempTTL,
+		"CERBERUS_TEMP_SWEEP_INTERVAL":   &config.Storage.TempSweepInterval,
+	}
+
+	for variable, value := range durations {
+		if environmentValue, ok := os.LookupEnv(variable); ok {
+			if err := value.UnmarshalText([]byte(environmentValue)); err != nil {
+				return errors.New(variable + " must be a duration")
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate returns every problem found in the configuration as one error
+func (config *Config) Validate() error {
+
+	var problems []string
+
+	// ipfs.linkKey in every environment - link names cannot be derived without it
+	required := map[string]string{
+		"blockchain.sdkConfigFile":            config.Blockchain.SdkConfigFile,
+		"blockchain.channelId":                config.Blockchain.ChannelID,
+		"blockchain.chaincodeId":              config.Blockchain.ChaincodeID,
+		"blockchain.org":                      config.Blockchain.Org,
+		"blockchain.user":                     config.Blockchain.User,
+		"ipfs.apiEndpoint":                    config.Ipfs.ApiEndpoint,
+		"ipfs.linkKey":                        config.Ipfs.LinkKey,
+		"storage.tempRoot":                    config.Storage.TempRoot,
+		"storage.uploadJournalPath":           config.Storage.UploadJournalPath,
+		"storage.garbageCollectorJournalPath": config.Storage.GarbageCollectorJournalPath,
+		"storage.rootJournalPath":             config.Storage.RootJournalPath,
+		"storage.referenceIndexPath":          config.Storage.ReferenceIndexPath,
+		"storage.replicationJournalPath":      config.Storage.ReplicationJournalPath,
+	}
+
+	for name, value := range required {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, name+" cannot be an empty string")
+		}
+	}
+
+	if len(config.Blockchain.Peers) == 0 {
+		problems = append(problems, "blockchain.peers must contain at least one peer")
+	}
+
+	replicas := len(config.Ipfs.Replicas)
+	if config.Ipfs.Cluster != "" {
+		replicas++
+	}
+
+	if config.Ipfs.ReplicationQuorum < 0 || config.Ipfs.ReplicationQuorum > replicas {
+		problems = append(problems, "ipfs.replicationQuorum must be between 0 and "+strconv.Itoa(replicas))
+	}
+
+	if config.Ipfs.ContentCacheBytes < 0 {
+		problems = append(problems, "ipfs.contentCacheBytes cannot be negative")
+	}
+
+	if config.Storage.TempAccountQuota < 0 {
+		problems = append(problems, "storage.tempAccountQuota cannot be negative")
+	}
+
+	if config.Blockchain.RequestTimeout <= 0 {
+		problems = append(problems, "blockchain.requestTimeout must be positive")
+	}
+
+	if config.Blockchain.Identities.CA != "fabric" && config.Blockchain.Identities.CA != "standIn" {
+		problems = append(problems, "blockchain.identities.ca must be fabric or standIn")
+	}
+
+	// the stand-in CA keeps registrations in memory and publishes no revocations
+	if config.Environment == "production" && config.Blockchain.Identities.Enabled && config.Blockchain.Identities.CA != "fabric" {
+		problems = append(problems, "blockchain.identities.ca must be fabric in production")
+	}
+
+	if config.Blockchain.Identities.Enabled && config.Blockchain.Identities.CA == "standIn" {
+		identities := config.Blockchain.Identities
+		if identities.MSPID == "" || identities.StandInCACert == "" || identities.StandInCAKey == "" {
+			problems = append(problems, "blockchain.identities.mspId, standInCACert and standInCAKey are required by the standIn ca")
+		}
+	}
+
+	if config.Blockchain.Retry.Attempts < 0 {
+		problems = append(problems, "blockchain.retry.attempts cannot be negative")
+	}
+
+	if config.Blockchain.Retry.BackoffFactor < 1 {
+		problems = append(problems, "blockchain.retry.backoffFactor must be at least 1")
+	}
+
+	if config.Blockchain.Retry.InitialBackoff < 0 || config.Blockchain.Retry.MaxBackoff < config.Blockchain.Retry.InitialBackoff {
+		problems = append(problems, "blockchain.retry.maxBackoff must not be shorter than blockchain.retry.initialBackoff")
+	}
+
+	if config.Storage.TempTTL < 0 || config.Storage.TempSweepInterval < 0 || config.Ipfs.ContentCacheTTL < 0 {
+		problems = append(problems, "durations cannot be negative")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New("Invalid configuration: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func splitList(value string) []string {
+
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
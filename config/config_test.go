@@ -0,0 +1,160 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+
+	config := Default()
+	config.Ipfs.LinkKey = "0123456789abcdef0123456789abcdef"
+
+	return config
+}
+
+func TestValidate(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		change  func(config *Config)
+		wantErr string
+	}{
+		{
+			name:   "defaults with a link key",
+			change: func(config *Config) {},
+		},
+		{
+			name:    "link key missing in development",
+			change:  func(config *Config) { config.Ipfs.LinkKey = "" },
+			wantErr: "ipfs.linkKey cannot be an empty string",
+		},
+		{
+			name: "link key missing in production",
+			change: func(config *Config) {
+				config.Environment = "production"
+				config.Ipfs.LinkKey = " "
+			},
+			wantErr: "ipfs.linkKey cannot be an empty string",
+		},
+		{
+			name:    "required value missing",
+			change:  func(config *Config) { config.Blockchain.ChannelID = "" },
+			wantErr: "blockchain.channelId cannot be an empty string",
+		},
+		{
+			name:    "no peers",
+			change:  func(config *Config) { config.Blockchain.Peers = nil },
+			wantErr: "blockchain.peers must contain at least one peer",
+		},
+		{
+			name: "quorum above the replicas",
+			change: func(config *Config) {
+				config.Ipfs.Replicas = []string{"replica:5001"}
+				config.Ipfs.ReplicationQuorum = 2
+			},
+			wantErr: "ipfs.replicationQuorum must be between 0 and 1",
+		},
+		{
+			name: "quorum counts the cluster",
+			change: func(config *Config) {
+				config.Ipfs.Replicas = []string{"replica:5001"}
+				config.Ipfs.Cluster = "cluster:9094"
+				config.Ipfs.ReplicationQuorum = 2
+			},
+		},
+		{
+			name:    "unknown identity ca",
+			change:  func(config *Config) { config.Blockchain.Identities.CA = "other" },
+			wantErr: "blockchain.identities.ca must be fabric or standIn",
+		},
+		{
+			name: "stand-in ca in production",
+			change: func(config *Config) {
+				config.Environment = "production"
+				config.Blockchain.Identities.Enabled = true
+				config.Blockchain.Identities.CA = "standIn"
+			},
+			wantErr: "blockchain.identities.ca must be fabric in production",
+		},
+		{
+			name: "stand-in ca without its key pair",
+			change: func(config *Config) {
+				config.Blockchain.Identities.Enabled = true
+				config.Blockchain.Identities.CA = "standIn"
+				config.Blockchain.Identities.MSPID = "SipherMSP"
+			},
+			wantErr: "blockchain.identities.mspId, standInCACert and standInCAKey are required by the standIn ca",
+		},
+		{
+			name: "stand-in ca with its key pair",
+			change: func(config *Config) {
+				config.Blockchain.Identities.Enabled = true
+				config.Blockchain.Identities.CA = "standIn"
+				config.Blockchain.Identities.MSPID = "SipherMSP"
+				config.Blockchain.Identities.StandInCACert = "ca/ca.sipher.cerberus.dev-cert.pem"
+				config.Blockchain.Identities.StandInCAKey = "ca/priv_sk"
+			},
+		},
+		{
+			name:    "backoff factor below one",
+			change:  func(config *Config) { config.Blockchain.Retry.BackoffFactor = 0.5 },
+			wantErr: "blockchain.retry.backoffFactor must be at least 1",
+		},
+		{
+			name:    "max backoff shorter than initial backoff",
+			change:  func(config *Config) { config.Blockchain.Retry.MaxBackoff = Duration(time.Millisecond) },
+			wantErr: "blockchain.retry.maxBackoff must not be shorter than blockchain.retry.initialBackoff",
+		},
+		{
+			name:    "negative duration",
+			change:  func(config *Config) { config.Storage.TempTTL = Duration(-time.Second) },
+			wantErr: "durations cannot be negative",
+		},
+		{
+			name:    "non positive request timeout",
+			change:  func(config *Config) { config.Blockchain.RequestTimeout = 0 },
+			wantErr: "blockchain.requestTimeout must be positive",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			config := validConfig()
+			test.change(config)
+
+			err := config.Validate()
+
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Validate() = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+
+	config := validConfig()
+	config.Ipfs.LinkKey = ""
+	config.Ipfs.ApiEndpoint = ""
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want an error")
+	}
+
+	for _, problem := range []string{"ipfs.apiEndpoint", "ipfs.linkKey"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Fatalf("Validate() = %v, missing %s", err, problem)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package crypto
 import (
 	"crypto/rand"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -12,35 +11,14 @@ import (
 func createCipherKey() ([]byte, error) {
 	key := make([]byte, 32)
 	if _, err := rand.Read(key); err != nil {
-		fmt.Println(err.Error())
-		return nil, err
+		return nil, errors.New("Unable to create a cipher key: " + err.Error())
 	}
 
 	return key, nil
 }
 
-func SaveCipherKey(cipherKey []byte, path, version string) error {
-
-	cipherKeyPath := filepath.Join(path, version)
-	if err := os.MkdirAll(cipherKeyPath, 0755); err != nil {
-		return err
-	}
-
-	file, err := os.Create(cipherKeyPath + "/cipher")
-	if err != nil {
-		return err
-	}
-
-	if _, err = file.Write(cipherKey); err != nil {
-		return err
-	}
-
-	if err = file.Close(); err != nil {
-		return err
-	}
-
-	return nil
-}
+// CipherKeyFile is the name of the encrypted cipher key file inside a version directory
+const CipherKeyFile = "cipher"
 
 func DeleteCipherKeyFile(path, version string) error {
 
@@ -6,11 +6,19 @@ import (
 	"os"
 )
 
-func EncryptDocument(filename, passphrase, rsaPath, rsaFile string) ([]byte, []byte, error) {
+// EncryptDocument pads the document according to the policy before the encryption
+// the policy must be recorded with the document version and provided to DecryptDocument
+func EncryptDocument(filename, passphrase, rsaPath, rsaFile string, policy PaddingPolicy) ([]byte, []byte, error) {
 	// encrypt document with AESGCM
 	filebytes := readImage(filename)
-	// encrypt document content with AES256-GCM
 
+	// pad to the size bucket so the encrypted size does not reveal the document type
+	filebytes, err := padDocument(filebytes, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// encrypt document content with AES256-GCM
 	encrData, err := EncAESGCM(filebytes, passphrase)
 	if err != nil {
 		return nil, nil, err
@@ -33,7 +41,7 @@ func EncryptDocument(filename, passphrase, rsaPath, rsaFile string) ([]byte, []b
 	return []byte(encryptedData), cipherKey, nil
 }
 
-func DecryptDocument(data, encryptedCipherKey []byte, rsaPath string) ([]byte, error) {
+func DecryptDocument(data, encryptedCipherKey []byte, rsaPath string, policy PaddingPolicy) ([]byte, error) {
 
 	// decrypt cipherKey with private key
 	cipherKey, err := decryptWitPrivateKey(encryptedCipherKey, rsaPath)
@@ -45,8 +53,12 @@ func DecryptDocument(data, encryptedCipherKey []byte, rsaPath string) ([]byte, e
 	// what if the rsa key pair is lost after the encryption?
 	// symmetric decryption
 	dataAsBytes, err := aesDecrypt(data, cipherKey)
+	if err != nil {
+		return nil, err
+	}
 
-	return dataAsBytes, nil
+	// the padding frame was authenticated together with the document
+	return unpadDocument(dataAsBytes, policy)
 }
 
 func readImage(filename string) []byte {
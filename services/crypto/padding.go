@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// PaddingPolicy decides the size bucket of an encrypted document
+// padded documents of different types end up with the same size on ipfs
+type PaddingPolicy string
+
+const (
+	// documents encrypted before padding was introduced
+	PaddingNone PaddingPolicy = "none"
+
+	// round up to the next power of two, at least paddingMinimumSize
+	PaddingPowerOfTwo PaddingPolicy = "powerOfTwo"
+
+	// round up to the next multiple of paddingStepSize
+	PaddingFixedStep PaddingPolicy = "fixedStep"
+)
+
+const (
+	paddingMinimumSize = 64 * 1024
+	paddingStepSize    = 256 * 1024
+
+	// frame: policy (1 byte) | document length (8 bytes) | document | zero padding
+	// the frame is encrypted together with the document so the length is authenticated
+	paddingHeaderSize = 9
+)
+
+// DefaultPaddingPolicy is used for new document versions
+var DefaultPaddingPolicy = PaddingPowerOfTwo
+
+var ErrInvalidPadding = errors.New("Document padding is invalid")
+
+var paddingPolicyIds = map[PaddingPolicy]byte{
+	PaddingPowerOfTwo: 1,
+	PaddingFixedStep:  2,
+}
+
+func (policy PaddingPolicy) IsValid() bool {
+
+	if policy == PaddingNone || policy == "" {
+		return true
+	}
+
+	_, ok := paddingPolicyIds[policy]
+
+	return ok
+}
+
+// paddedSize returns the size of the frame holding length bytes of document data
+func (policy PaddingPolicy) paddedSize(length int) (int, error) {
+
+	framed := length + paddingHeaderSize
+
+	switch policy {
+	case PaddingPowerOfTwo:
+		size := paddingMinimumSize
+		for size < framed {
+			size *= 2
+		}
+
+		return size, nil
+
+	case PaddingFixedStep:
+		steps := (framed + paddingStepSize - 1) / paddingStepSize
+		if steps == 0 {
+			steps = 1
+		}
+
+		return steps * paddingStepSize, nil
+	}
+
+	return 0, errors.New("Unknown padding policy " + string(policy))
+}
+
+// padDocument frames the document and pads it to the size bucket of the policy
+func padDocument(data []byte, policy PaddingPolicy) ([]byte, error) {
+
+	if policy == PaddingNone || policy == "" {
+		return data, nil
+	}
+
+	size, err := policy.paddedSize(len(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// zero filled, only the header and the document are written
+	framed := make([]byte, size)
+	framed[0] = paddingPolicyIds[policy]
+	binary.BigEndian.PutUint64(framed[1:paddingHeaderSize], uint64(len(data)))
+	copy(framed[paddingHeaderSize:], data)
+
+	return framed, nil
+}
+
+// unpadDocument strips the padding from decrypted data
+// the frame must match the policy recorded for the document version
+func unpadDocument(framed []byte, policy PaddingPolicy) ([]byte, error) {
+
+	if policy == PaddingNone || policy == "" {
+		return framed, nil
+	}
+
+	policyId, ok := paddingPolicyIds[policy]
+	if !ok {
+		return nil, errors.New("Unknown padding policy " + string(policy))
+	}
+
+	if len(framed) < paddingHeaderSize || framed[0] != policyId {
+		return nil, ErrInvalidPadding
+	}
+
+	length := binary.BigEndian.Uint64(framed[1:paddingHeaderSize])
+	if length > uint64(len(framed)-paddingHeaderSize) {
+		return nil, ErrInvalidPadding
+	}
+
+	expectedSize, err := policy.paddedSize(int(length))
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedSize != len(framed) {
+		return nil, ErrInvalidPadding
+	}
+
+	end := paddingHeaderSize + int(length)
+	for _, value := range framed[end:] {
+		if value != 0 {
+			return nil, ErrInvalidPadding
+		}
+	}
+
+	return framed[paddingHeaderSize:end], nil
+}
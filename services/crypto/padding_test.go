@@ -0,0 +1,123 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPadDocument(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		policy   PaddingPolicy
+		length   int
+		wantSize int
+	}{
+		{name: "none keeps the document", policy: PaddingNone, length: 100, wantSize: 100},
+		{name: "empty policy keeps the document", policy: "", length: 100, wantSize: 100},
+		{name: "power of two minimum", policy: PaddingPowerOfTwo, length: 0, wantSize: paddingMinimumSize},
+		{name: "power of two fits the header", policy: PaddingPowerOfTwo, length: paddingMinimumSize - paddingHeaderSize, wantSize: paddingMinimumSize},
+		{name: "power of two rounds up", policy: PaddingPowerOfTwo, length: paddingMinimumSize - paddingHeaderSize + 1, wantSize: 2 * paddingMinimumSize},
+		{name: "fixed step minimum", policy: PaddingFixedStep, length: 0, wantSize: paddingStepSize},
+		{name: "fixed step rounds up", policy: PaddingFixedStep, length: paddingStepSize, wantSize: 2 * paddingStepSize},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			document := bytes.Repeat([]byte{0xab}, test.length)
+
+			framed, err := padDocument(document, test.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(framed) != test.wantSize {
+				t.Fatalf("padded size = %d, want %d", len(framed), test.wantSize)
+			}
+
+			unpadded, err := unpadDocument(framed, test.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(unpadded, document) {
+				t.Fatal("unpadded document does not match the original")
+			}
+		})
+	}
+}
+
+func TestPadDocumentUnknownPolicy(t *testing.T) {
+
+	if _, err := padDocument([]byte("document"), PaddingPolicy("unknown")); err == nil {
+		t.Fatal("padDocument() with an unknown policy succeeded")
+	}
+}
+
+func TestUnpadDocumentRejectsInvalidFrames(t *testing.T) {
+
+	valid, err := padDocument([]byte("document"), PaddingPowerOfTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamper := func(change func(framed []byte) []byte) []byte {
+		framed := append([]byte(nil), valid...)
+		return change(framed)
+	}
+
+	tests := []struct {
+		name   string
+		policy PaddingPolicy
+		framed []byte
+	}{
+		{name: "shorter than the header", policy: PaddingPowerOfTwo, framed: valid[:paddingHeaderSize-1]},
+		{name: "other policy", policy: PaddingFixedStep, framed: valid},
+		{name: "unknown policy", policy: PaddingPolicy("unknown"), framed: valid},
+		{
+			name:   "length past the frame",
+			policy: PaddingPowerOfTwo,
+			framed: tamper(func(framed []byte) []byte { framed[1] = 0xff; return framed }),
+		},
+		{
+			name:   "length of another size bucket",
+			policy: PaddingPowerOfTwo,
+			framed: tamper(func(framed []byte) []byte { return append(framed, make([]byte, len(framed))...) }),
+		},
+		{
+			name:   "non zero padding",
+			policy: PaddingPowerOfTwo,
+			framed: tamper(func(framed []byte) []byte { framed[len(framed)-1] = 1; return framed }),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			if _, err := unpadDocument(test.framed, test.policy); err == nil {
+				t.Fatal("unpadDocument() accepted an invalid frame")
+			}
+		})
+	}
+}
+
+func TestPaddingPolicyIsValid(t *testing.T) {
+
+	tests := []struct {
+		policy PaddingPolicy
+		want   bool
+	}{
+		{policy: PaddingNone, want: true},
+		{policy: "", want: true},
+		{policy: PaddingPowerOfTwo, want: true},
+		{policy: PaddingFixedStep, want: true},
+		{policy: PaddingPolicy("unknown"), want: false},
+	}
+
+	for _, test := range tests {
+		if valid := test.policy.IsValid(); valid != test.want {
+			t.Fatalf("PaddingPolicy(%q).IsValid() = %v, want %v", test.policy, valid, test.want)
+		}
+	}
+}
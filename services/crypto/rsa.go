@@ -49,28 +49,18 @@ func decryptWitPrivateKey(encryptedCipherKey []byte, rsaKeysPath string) ([]byte
 	return decryptedCipherKey, nil
 }
 
-func GenerateRSAKeyPair(rsaPath string) (string, error) {
+// RSAKeyFile is the name of the rsa key pair file inside an rsa directory
+const RSAKeyFile = "rsa_key.pem"
 
-	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
-	if err != nil {
-		return "", err
-	}
+// GenerateRSAKeyPair returns a PEM encoded key pair, the caller decides where it is stored
+func GenerateRSAKeyPair() ([]byte, error) {
 
-	privateFilePem, err := os.Create(rsaPath + "/rsa_key.pem")
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
 	if err != nil {
-		return "", err
-	}
-
-	privateKeyPem := exportRsaPrivateBlock(privateKey)
-	if err = pem.Encode(privateFilePem, privateKeyPem); err != nil {
-		return "", err
-	}
-
-	if err = privateFilePem.Close(); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return filepath.Join(rsaPath, "rsa_key.pem"), nil
+	return pem.EncodeToMemory(exportRsaPrivateBlock(privateKey)), nil
 }
 
 func exportRsaPrivateBlock(privateKey *rsa.PrivateKey) *pem.Block {
@@ -167,7 +157,7 @@ func saveHashcode(cipherKey []byte, hashPath, documentName string) {
 	hashCode := fnv.New128()
 	hashCode.Write(cipherKey)
 
-	if err := ioutil.WriteFile(hashPath, []byte(hashCode.Sum([]byte(cipherKey))), 0644); err != nil {
+	if err := ioutil.WriteFile(hashPath, []byte(hashCode.Sum([]byte(cipherKey))), 0600); err != nil {
 		fmt.Println(err)
 	}
 }
@@ -176,18 +166,23 @@ func getRsaKeyFromUpload(rsaPath, filename string) error {
 
 	// check if path exists
 	if _, err := os.Stat(rsaPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(rsaPath, 0755); err != nil {
+		if err := os.MkdirAll(rsaPath, 0700); err != nil {
 			return err
 		}
 	}
 
+	// the key pair may already be stored at the destination
+	if filepath.Clean(filename) == filepath.Join(rsaPath, RSAKeyFile) {
+		return nil
+	}
+
 	// copy file to path
 	source, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 
-	destination, err := os.OpenFile(rsaPath+"/rsa_key.pem", os.O_RDWR|os.O_CREATE, 0666)
+	destination, err := os.OpenFile(rsaPath+"/rsa_key.pem", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,192 @@
+package ipfs
+
+import (
+	"container/list"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	defaultContentCacheSize = 64 * 1024 * 1024
+	defaultContentCacheTTL  = 10 * time.Minute
+)
+
+// content fetched from ipfs is cached by CID
+// only encrypted document content goes through catFromIpfs - decrypted data is never cached
+var (
+	contentCache      = newCiphertextCache(defaultContentCacheSize, defaultContentCacheTTL)
+	contentCacheMutex sync.RWMutex
+)
+
+type CacheMetrics struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// ciphertextCache is a size bounded LRU cache with a per entry time to live
+type ciphertextCache struct {
+	mutex    sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List // front - most recently used
+	metrics  CacheMetrics
+}
+
+type ciphertextCacheEntry struct {
+	cid      string
+	data     []byte
+	storedAt time.Time
+}
+
+// ConfigureContentCache replaces the cache in front of ipfs cat calls - call it on startup
+// maxBytes <= 0 disables caching
+func ConfigureContentCache(maxBytes int64, ttl time.Duration) {
+
+	cache := newCiphertextCache(maxBytes, ttl)
+
+	contentCacheMutex.Lock()
+	contentCache = cache
+	contentCacheMutex.Unlock()
+}
+
+func ContentCacheMetrics() CacheMetrics {
+
+	return currentContentCache().Metrics()
+}
+
+func currentContentCache() *ciphertextCache {
+
+	contentCacheMutex.RLock()
+	defer contentCacheMutex.RUnlock()
+
+	return contentCache
+}
+
+func newCiphertextCache(maxBytes int64, ttl time.Duration) *ciphertextCache {
+
+	return &ciphertextCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns a copy of the cached content - callers may modify it
+func (cache *ciphertextCache) Get(cid string) ([]byte, bool) {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[cid]
+	if !ok {
+		cache.metrics.Misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*ciphertextCacheEntry)
+
+	if cache.ttl > 0 && time.Since(entry.storedAt) > cache.ttl {
+		cache.remove(element)
+		cache.metrics.Expirations++
+		cache.metrics.Misses++
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+	cache.metrics.Hits++
+
+	return append([]byte(nil), entry.data...), true
+}
+
+// Put stores a copy of data
+func (cache *ciphertextCache) Put(cid string, data []byte) {
+
+	size := int64(len(data))
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// entries larger than the whole cache are not stored
+	if cache.maxBytes <= 0 || size > cache.maxBytes {
+		return
+	}
+
+	if element, ok := cache.entries[cid]; ok {
+		cache.remove(element)
+	}
+
+	element := cache.order.PushFront(&ciphertextCacheEntry{
+		cid:      cid,
+		data:     append([]byte(nil), data...),
+		storedAt: time.Now(),
+	})
+
+	cache.entries[cid] = element
+	cache.size += size
+
+	for cache.size > cache.maxBytes {
+		oldest := cache.order.Back()
+		if oldest == nil {
+			break
+		}
+
+		cache.remove(oldest)
+		cache.metrics.Evictions++
+	}
+}
+
+func (cache *ciphertextCache) Metrics() CacheMetrics {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	metrics := cache.metrics
+	metrics.Entries = len(cache.entries)
+	metrics.Bytes = cache.size
+
+	return metrics
+}
+
+func (cache *ciphertextCache) remove(element *list.Element) {
+
+	entry := element.Value.(*ciphertextCacheEntry)
+
+	cache.order.Remove(element)
+	delete(cache.entries, entry.cid)
+	cache.size -= int64(len(entry.data))
+}
+
+// catFromIpfs returns the content of the provided CID, from the cache when possible
+func catFromIpfs(cid string) ([]byte, error) {
+
+	cache := currentContentCache()
+
+	if data, ok := cache.Get(cid); ok {
+		return data, nil
+	}
+
+	runShellInstance()
+
+	reader, err := sh.Cat(cid)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Put(cid, data)
+
+	return data, nil
+}
@@ -0,0 +1,98 @@
+package ipfs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCiphertextCache(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		ttl      time.Duration
+		puts     []string
+		wait     time.Duration
+		get      string
+		wantHit  bool
+		metrics  CacheMetrics
+	}{
+		{
+			name:     "hit",
+			maxBytes: 16,
+			puts:     []string{"a"},
+			get:      "a",
+			wantHit:  true,
+			metrics:  CacheMetrics{Hits: 1, Entries: 1, Bytes: 4},
+		},
+		{
+			name:     "miss",
+			maxBytes: 16,
+			puts:     []string{"a"},
+			get:      "b",
+			metrics:  CacheMetrics{Misses: 1, Entries: 1, Bytes: 4},
+		},
+		{
+			name:     "least recently used entry is evicted",
+			maxBytes: 8,
+			puts:     []string{"a", "b", "c"},
+			get:      "a",
+			metrics:  CacheMetrics{Misses: 1, Evictions: 1, Entries: 2, Bytes: 8},
+		},
+		{
+			name:     "expired entry is removed",
+			maxBytes: 16,
+			ttl:      time.Millisecond,
+			puts:     []string{"a"},
+			wait:     5 * time.Millisecond,
+			get:      "a",
+			metrics:  CacheMetrics{Misses: 1, Expirations: 1},
+		},
+		{
+			name:     "disabled cache stores nothing",
+			maxBytes: 0,
+			puts:     []string{"a"},
+			get:      "a",
+			metrics:  CacheMetrics{Misses: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			cache := newCiphertextCache(test.maxBytes, test.ttl)
+
+			for _, cid := range test.puts {
+				cache.Put(cid, []byte(cid+cid+cid+cid))
+			}
+
+			time.Sleep(test.wait)
+
+			_, hit := cache.Get(test.get)
+			if hit != test.wantHit {
+				t.Fatalf("Get(%q) hit = %v, want %v", test.get, hit, test.wantHit)
+			}
+
+			if metrics := cache.Metrics(); metrics != test.metrics {
+				t.Fatalf("Metrics() = %+v, want %+v", metrics, test.metrics)
+			}
+		})
+	}
+}
+
+func TestCiphertextCacheCopies(t *testing.T) {
+
+	cache := newCiphertextCache(16, 0)
+
+	data := []byte("content")
+	cache.Put("a", data)
+	data[0] = 'X'
+
+	cached, _ := cache.Get("a")
+	cached[1] = 'X'
+
+	if cached, _ = cache.Get("a"); !bytes.Equal(cached, []byte("content")) {
+		t.Fatalf("Get() = %q, cached content was modified", cached)
+	}
+}
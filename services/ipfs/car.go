@@ -0,0 +1,313 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-ipfs-api"
+)
+
+// AccountTreeResolver returns the ipfs objects recorded on the ledger for the account
+type AccountTreeResolver func(accountPublicID string) (*AccountTree, error)
+
+// archive directory layout:
+// account                     -> account directory
+// links                       -> account link object
+// documents/<link>            -> document directory
+// documents/<link>.links      -> document link object
+// versions/<link>/<link>      -> encrypted version content
+// publicID                    -> account public ID
+// links are the derived link names recorded for the account
+const (
+	archiveAccountLink   = "account"
+	archiveLinksLink     = "links"
+	archiveDocumentsLink = "documents"
+	archiveVersionsLink  = "versions"
+	archivePublicIDLink  = "publicID"
+)
+
+type dagImportResult struct {
+	Root *struct {
+		Cid struct {
+			Hash string `json:"/"`
+		} `json:"Cid"`
+	} `json:"Root"`
+}
+
+// ExportAccountCAR writes a CAR archive with the account directory, every document directory
+// and every encrypted document version of the account; returns the archive root
+func ExportAccountCAR(accountPublicID string, resolve AccountTreeResolver, w io.Writer) (string, error) {
+
+	tree, err := resolve(accountPublicID)
+	if err != nil {
+		return "", err
+	}
+
+	archiveRoot, err := createAccountArchiveDirectory(tree)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := sh.Request("dag/export", archiveRoot).Send(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer response.Close()
+
+	if response.Error != nil {
+		return "", response.Error
+	}
+
+	if _, err = io.Copy(w, response.Output); err != nil {
+		return "", err
+	}
+
+	return archiveRoot, nil
+}
+
+// AccountArchive is an account archive imported into the local ipfs node
+// its root stays unpinned until Verify checks it against the ledger record
+type AccountArchive struct {
+	Root     string
+	PublicID string
+}
+
+// ImportAccountCAR imports a CAR archive created by ExportAccountCAR without pinning it
+// and reads the public ID of the archived account
+func ImportAccountCAR(reader io.Reader) (*AccountArchive, error) {
+
+	runShellInstance()
+
+	// unverified objects are left to the ipfs garbage collector
+	response, err := sh.Request("dag/import").Option("pin-roots", false).FileBody(reader).Send(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Close()
+
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	var roots []string
+
+	decoder := json.NewDecoder(response.Output)
+	for {
+		result := &dagImportResult{}
+
+		err = decoder.Decode(result)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Root == nil {
+			continue
+		}
+
+		roots = append(roots, result.Root.Cid.Hash)
+	}
+
+	if len(roots) != 1 {
+		return nil, errors.New("Account archive must contain exactly one root")
+	}
+
+	archive := &AccountArchive{Root: roots[0]}
+
+	links, err := readAccountArchiveLinks(archive.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	if links[archivePublicIDLink] == "" {
+		return nil, errors.New("Archive " + archive.Root + " does not contain an account public ID")
+	}
+
+	publicID, err := catFromIpfs(links[archivePublicIDLink])
+	if err != nil {
+		return nil, err
+	}
+
+	archive.PublicID = string(publicID)
+
+	return archive, nil
+}
+
+// Verify checks every archived object against the ipfs objects recorded on the ledger
+// for the account and pins the archive root when all of them match
+func (archive *AccountArchive) Verify(tree *AccountTree) error {
+
+	if tree.PublicID != archive.PublicID {
+		return errors.New("Archive " + archive.Root + " belongs to account " + archive.PublicID + ", not " + tree.PublicID)
+	}
+
+	expectedLinks, err := accountArchiveLinks(tree)
+	if err != nil {
+		return err
+	}
+
+	links, err := readAccountArchiveLinks(archive.Root)
+	if err != nil {
+		return err
+	}
+
+	for path, hash := range expectedLinks {
+
+		if links[path] == "" {
+			return errors.New("Archive " + archive.Root + " does not contain " + path)
+		}
+
+		if links[path] != hash {
+			return errors.New("Archived object " + path + " " + links[path] + " does not match ledger record " + hash)
+		}
+	}
+
+	for path := range links {
+
+		if path == archivePublicIDLink {
+			continue
+		}
+
+		if expectedLinks[path] == "" {
+			return errors.New("Archived object " + path + " is not recorded on the ledger")
+		}
+	}
+
+	return sh.Pin(archive.Root)
+}
+
+// accountArchiveLinks returns the archive path -> ipfs object of every object recorded for the account
+func accountArchiveLinks(tree *AccountTree) (map[string]string, error) {
+
+	if tree.Account == nil || tree.Account.ObjectHash == "" {
+		return nil, errors.New("Account " + tree.PublicID + " has no ipfs directory recorded")
+	}
+
+	links := map[string]string{
+		archiveAccountLink: tree.Account.ObjectHash,
+		archiveLinksLink:   tree.Account.LinkObjectHash,
+	}
+
+	for documentName, directory := range tree.Documents {
+
+		if directory == nil {
+			continue
+		}
+
+		links[archiveDocumentsLink+"/"+documentName] = directory.ObjectHash
+		links[archiveDocumentsLink+"/"+documentName+".links"] = directory.LinkObjectHash
+	}
+
+	for _, version := range tree.Versions {
+
+		if version == nil {
+			continue
+		}
+
+		// reference = document link name/version link name
+		links[archiveVersionsLink+"/"+strings.Trim(version.ParentDirectoryReference, "/")] = version.ContentIdentifier
+	}
+
+	for path, hash := range links {
+
+		if hash == "" {
+			delete(links, path)
+		}
+	}
+
+	return links, nil
+}
+
+// readAccountArchiveLinks flattens the archive directory into archive path -> ipfs object
+func readAccountArchiveLinks(archiveRoot string) (map[string]string, error) {
+
+	archive, err := sh.ObjectGet(archiveRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make(map[string]string)
+
+	for _, link := range archive.Links {
+
+		switch link.Name {
+		case archiveDocumentsLink:
+			documents, err := sh.ObjectGet(link.Hash)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, document := range documents.Links {
+				links[archiveDocumentsLink+"/"+document.Name] = document.Hash
+			}
+
+		case archiveVersionsLink:
+			documents, err := sh.ObjectGet(link.Hash)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, document := range documents.Links {
+				versions, err := sh.ObjectGet(document.Hash)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, version := range versions.Links {
+					links[archiveVersionsLink+"/"+document.Name+"/"+version.Name] = version.Hash
+				}
+			}
+
+		default:
+			links[link.Name] = link.Hash
+		}
+	}
+
+	return links, nil
+}
+
+func createAccountArchiveDirectory(tree *AccountTree) (string, error) {
+
+	links, err := accountArchiveLinks(tree)
+	if err != nil {
+		return "", err
+	}
+
+	runShellInstance()
+
+	// the public ID lets ImportAccountCAR find the ledger record of the archive
+	links[archivePublicIDLink], err = sh.Add(strings.NewReader(tree.PublicID), shell.Pin(false))
+	if err != nil {
+		return "", err
+	}
+
+	archiveRoot, err := createNewEmptyDirectory()
+	if err != nil {
+		return "", err
+	}
+
+	// sorted so the same account always produces the same archive root
+	paths := make([]string, 0, len(links))
+	for path := range links {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+
+		// arguments: root, path, childhash, create
+		archiveRoot, err = sh.PatchLink(archiveRoot, path, links[path], true)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return archiveRoot, nil
+}
@@ -0,0 +1,76 @@
+package ipfs
+
+import (
+	"errors"
+	"time"
+)
+
+// Settings holds everything the ipfs package reads from the configuration
+type Settings struct {
+	ApiEndpoint string
+
+	Replicas          []string
+	Cluster           string
+	ReplicationQuorum int
+
+	LinkKey []byte
+
+	ContentCacheBytes int64
+	ContentCacheTTL   time.Duration
+
+	TempRoot                    string
+	TempStoreOptions            TempStoreOptions
+	UploadJournalPath           string
+	GarbageCollectorJournalPath string
+	RootJournalPath             string
+	ReferenceIndexPath          string
+	ReplicationJournalPath      string
+}
+
+// Configure replaces the package defaults - call it once on startup
+func Configure(settings Settings) error {
+
+	if settings.ApiEndpoint == "" {
+		return errors.New("IPFS api endpoint cannot be an empty string")
+	}
+
+	if settings.ReplicationJournalPath != "" {
+		replicationJournalPath = settings.ReplicationJournalPath
+	}
+
+	newReplicator, err := newConfiguredReplicator(settings.Replicas, settings.Cluster, settings.ReplicationQuorum, replicationJournalPath)
+	if err != nil {
+		return err
+	}
+
+	if replicator != nil {
+		replicator.StopRepairer()
+	}
+
+	apiEndpoint = settings.ApiEndpoint
+	SetReplicator(newReplicator)
+	SetLinkNameKey(settings.LinkKey)
+	ConfigureContentCache(settings.ContentCacheBytes, settings.ContentCacheTTL)
+
+	if settings.TempRoot != "" {
+		ConfigureTempStores(settings.TempRoot, settings.TempStoreOptions)
+	}
+
+	if settings.UploadJournalPath != "" {
+		uploadJournalPath = settings.UploadJournalPath
+	}
+
+	if settings.GarbageCollectorJournalPath != "" {
+		garbageCollectorJournalPath = settings.GarbageCollectorJournalPath
+	}
+
+	if settings.RootJournalPath != "" {
+		rootJournalPath = settings.RootJournalPath
+	}
+
+	if settings.ReferenceIndexPath != "" {
+		referenceIndexPath = settings.ReferenceIndexPath
+	}
+
+	return nil
+}
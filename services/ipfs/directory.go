@@ -51,7 +51,8 @@ func CreateGroupAccountsIpfsDirectory(directoryName string) (string, error) {
 }
 
 // creates additional object - function according to documentations
-// parentDirectory = "personAccounts"
+// parentDirectory = "personAccounts" root, recorded only - the account directory is built on its own empty
+// directory, the group root links it under directoryName without embedding a snapshot of the other accounts
 func CreateIpfsAccountDirectory(directoryName, parentDirectory string) (*IpfsDirectoryData, string, error) {
 
 	runShellInstance()
@@ -70,13 +71,19 @@ func CreateIpfsAccountDirectory(directoryName, parentDirectory string) (*IpfsDir
 		return nil, "", err
 	}
 
+	recordPinnedObject(object)
+
 	// arguments: root, path, childhash, create
-	newObject, err := sh.PatchLink(parentDirectory, directoryName, object, true)
+	newObject, err := sh.PatchLink(currentDirectory, directoryName, object, true)
+
+	if err != nil {
+		return nil, "", err
+	}
 
 	currentDirectory = newObject
 
 	// create link object
-	linkObject, err := sh.Patch(parentDirectory, "add-link", "links", currentDirectory)
+	linkObject, err := sh.Patch(newEmptyDirectory, "add-link", "links", currentDirectory)
 
 	if err != nil {
 		return nil, "", err
@@ -114,6 +121,8 @@ func CreateIpfsDocumentDirectory(directoryName, parentDirectoryHash, parentLinkO
 		return nil, "", newEmptyDirectory, err
 	}
 
+	recordPinnedObject(object)
+
 	newObject, err := sh.PatchLink(parentDirectoryHash, directoryName, object, true)
 
 	if err != nil {
@@ -8,11 +8,14 @@ import (
 	"image"
 	"image/png"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+// decrypted files exported to disk are removed after this period
+var exportedFileLifetime = 5 * time.Minute
+
 // creates additional object - function according to documentations
 // parentDirectory = "document1"
 // fileName = linkName = x
@@ -31,6 +34,21 @@ func UploadFileToIpfs(documentData []byte, documentName, parentDirectoryReferenc
 		return nil, updatedParentLinkObject, err
 	}
 
+	recordPinnedObject(contentIdentifier)
+
+	// the version is committed only after enough replicas confirmed the pin
+	if err = replicateContent(contentIdentifier); err != nil {
+		return nil, updatedParentLinkObject, err
+	}
+
+	return linkFileToDocumentDirectory(contentIdentifier, documentName, parentDirectoryReference, parentDirectoryHash, parentLinkObject)
+}
+
+// links uploaded content under the document directory and updates the directory link object
+func linkFileToDocumentDirectory(contentIdentifier, documentName, parentDirectoryReference, parentDirectoryHash, parentLinkObject string) (*IpfsDocumentVersionData, string, error) {
+
+	var updatedParentLinkObject string
+
 	documentObjectHash, err := sh.PatchLink(parentDirectoryHash, documentName, contentIdentifier, true)
 
 	if err != nil {
@@ -103,43 +121,69 @@ func DeleteDocumentObjectFromIpfs(document *IpfsDocumentVersionData) {
 	sh.Request("rm", document.ObjectHash)
 }
 
-func ExportFileFromIpfs(objectHash, documentVersionName, destinationPath string, cipherKey []byte) (string, error) {
+// ExportFileToWriter decrypts the document version and writes it as png to w
+// linkName is the version link name recorded in the account - IpfsDocumentVersionData.Reference
+// neither the encrypted nor the decrypted content is written to disk
+func ExportFileToWriter(objectHash, linkName, rsaKeyFile string, cipherKey []byte, padding crypto.PaddingPolicy, w io.Writer) error {
 
-	runShellInstance()
+	fileDataEncrypted, err := readFileFromIpfs(objectHash, linkName)
 
-	fileData, err := readFileFromIpfs(objectHash, documentVersionName, destinationPath)
+	if err != nil {
+		return err
+	}
+
+	// decrypt process
+	fileDataAsBytes, err := crypto.DecryptDocument(fileDataEncrypted, cipherKey, rsaKeyFile, padding)
 
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// read file data form file
-	fileDataEncrypted, err := ioutil.ReadFile(fileData)
+	return encodePng(fileDataAsBytes, w)
+}
+
+// ExportFileFromIpfs writes the decrypted document version as a png file readable only by the owner
+// the file is removed automatically after exportedFileLifetime
+func ExportFileFromIpfs(objectHash, linkName, documentVersionName, destinationPath string, cipherKey []byte, padding crypto.PaddingPolicy) (string, error) {
 
-	// decrypt process
 	rsaPath := filepath.Join(destinationPath, documentVersionName, "rsa")
 	rsa := rsaPath + "/rsa_key.pem"
-	fileDataAsBytes, err := crypto.DecryptDocument(fileDataEncrypted, cipherKey, rsa)
+
+	filePath := destinationPath + "/" + documentVersionName + ".png"
+
+	// a previous export of the same version may still be waiting for removal
+	os.Remove(filePath)
+
+	out, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
 
 	if err != nil {
 		return "", err
 	}
 
-	// save file data as png
-	filePath, err := convertToPng(fileDataAsBytes, destinationPath+"/"+documentVersionName+".png")
+	err = ExportFileToWriter(objectHash, linkName, rsa, cipherKey, padding, out)
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
 
 	if err != nil {
+		os.Remove(filePath)
 		return "", err
 	}
 
-	if err = os.Remove(fileData); err != nil {
-		return "", err
+	// a plaintext export nobody removes is never left behind
+	if err = expireTempFile(filePath, exportedFileLifetime); err != nil {
+		os.Remove(filePath)
+		return "", errors.New("Unable to schedule removal of exported file " + filePath + ": " + err.Error())
 	}
 
 	return filePath, nil
 }
 
-func getFileFromIpfs(objectHash, documentVersionName string) (string, error) {
+// getFileFromIpfs resolves the link of the document object
+// versions are linked under derived link names, the plain version name only matches
+// documents uploaded before link names were derived
+func getFileFromIpfs(objectHash, linkName string) (string, error) {
 
 	runShellInstance()
 
@@ -155,55 +199,39 @@ func getFileFromIpfs(objectHash, documentVersionName string) (string, error) {
 
 	for _, value := range documentLinks {
 
-		if value.Name == documentVersionName {
+		if value.Name == linkName {
 			documentLocation = value.Hash
 		}
 	}
 
 	if documentLocation == "" {
-		return documentLocation, errors.New("Provided document name: " + documentVersionName + " does not match any links for object: " + objectHash)
+		return documentLocation, errors.New("Provided link name: " + linkName + " does not match any links for object: " + objectHash)
 	}
 
 	return documentLocation, nil
 }
 
-func readFileFromIpfs(cid, documentVersionName, destinationDirectory string) (string, error) {
+func readFileFromIpfs(cid, linkName string) ([]byte, error) {
 
-	documentLocation, err := getFileFromIpfs(cid, documentVersionName)
-
-	// obtain document data
-	reader, err := sh.Cat(documentLocation)
+	runShellInstance()
 
-	defer reader.Close()
+	documentLocation, err := getFileFromIpfs(cid, linkName)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	fileLocation := filepath.Join(destinationDirectory, documentVersionName)
-
-	outputFile, err := os.Create(fileLocation)
-
-	_, err = io.Copy(outputFile, reader)
-
-	return fileLocation, nil
+	// obtain document data - encrypted content is served from the cache when possible
+	return catFromIpfs(documentLocation)
 }
 
-func convertToPng(fileDataAdBytes []byte, filePath string) (string, error) {
-
-	img, _, _ := image.Decode(bytes.NewReader(fileDataAdBytes))
+func encodePng(fileDataAsBytes []byte, w io.Writer) error {
 
-	out, err := os.Create(filePath) // create png extension file
+	img, _, err := image.Decode(bytes.NewReader(fileDataAsBytes))
 
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	err = png.Encode(out, img)
-
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return png.Encode(w, img)
 }
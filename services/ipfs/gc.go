@@ -0,0 +1,285 @@
+package ipfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+var garbageCollectorJournalPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/gc-journal.json"
+
+const defaultGarbageCollectorGracePeriod = 24 * time.Hour
+
+// AccountTree holds every ipfs object recorded on the ledger for one account
+// it is built by the application layer from the account record
+type AccountTree struct {
+	PublicID  string
+	Account   *IpfsDirectoryData
+	Documents map[string]*IpfsDirectoryData
+	Versions  []*IpfsDocumentVersionData
+}
+
+type GarbageCollectorOptions struct {
+	// orphaned objects are removed only after they stay unreferenced for this long
+	GracePeriod time.Duration
+
+	// report orphaned objects without unpinning them or updating the journal
+	DryRun bool
+
+	// location of the file which keeps the time each orphan was first seen
+	JournalPath string
+
+	// objects which are not referenced by any account but must be kept - group root directories
+	ProtectedObjects []string
+}
+
+type GarbageCollectionReport struct {
+	Referenced int      `json:"referenced"`
+	Orphaned   []string `json:"orphaned"`
+	Pending    []string `json:"pending"`
+	Removed    []string `json:"removed"`
+	DryRun     bool     `json:"dryRun"`
+}
+
+// hash -> time the object was first found unreferenced
+type garbageCollectorJournal map[string]string
+
+func NewGarbageCollectorOptions() *GarbageCollectorOptions {
+
+	return &GarbageCollectorOptions{
+		GracePeriod: defaultGarbageCollectorGracePeriod,
+		JournalPath: garbageCollectorJournalPath,
+	}
+}
+
+func (tree *AccountTree) roots() []string {
+
+	var roots []string
+
+	addDirectory := func(directory *IpfsDirectoryData) {
+		if directory == nil {
+			return
+		}
+
+		roots = append(roots, directory.ContentIdentifier, directory.ObjectHash, directory.LinkObjectHash)
+	}
+
+	addDirectory(tree.Account)
+
+	for _, directory := range tree.Documents {
+		addDirectory(directory)
+	}
+
+	for _, version := range tree.Versions {
+		if version == nil {
+			continue
+		}
+
+		roots = append(roots, version.ContentIdentifier, version.ObjectHash)
+	}
+
+	return roots
+}
+
+// WalkAccountTrees returns every object reachable from the hashes recorded in the provided trees
+func WalkAccountTrees(trees []*AccountTree) (map[string]bool, error) {
+
+	var roots []string
+	for _, tree := range trees {
+		roots = append(roots, tree.roots()...)
+	}
+
+	return walkObjects(roots)
+}
+
+// walkObjects returns every object reachable from roots, an object which cannot be read fails the walk
+func walkObjects(roots []string) (map[string]bool, error) {
+
+	runShellInstance()
+
+	referenced := make(map[string]bool)
+
+	queue := append([]string(nil), roots...)
+
+	for len(queue) > 0 {
+
+		hash := queue[0]
+		queue = queue[1:]
+
+		if hash == "" || referenced[hash] {
+			continue
+		}
+
+		object, err := sh.ObjectGet(hash)
+		if err != nil {
+			return nil, errors.New("Unable to walk object " + hash + ": " + err.Error())
+		}
+
+		referenced[hash] = true
+
+		for _, link := range object.Links {
+			if !referenced[link.Hash] {
+				queue = append(queue, link.Hash)
+			}
+		}
+	}
+
+	return referenced, nil
+}
+
+// CollectGarbage unpins the objects this deployment pinned which no committed account record of its org references
+// and which stayed unreferenced longer than the grace period
+// the references come from the reference index - while an account update is unconfirmed it returns
+// ErrReferenceIndexIncomplete, and an object of the index which cannot be walked stops the collection
+func CollectGarbage(options *GarbageCollectorOptions) (*GarbageCollectionReport, error) {
+
+	if options == nil {
+		options = NewGarbageCollectorOptions()
+	}
+
+	roots, pinned, err := completeReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced, err := walkObjects(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	report, unpinned, err := collectOrphanedObjects(referenced, pinned, options)
+	if err != nil {
+		return report, err
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	return report, forgetPinnedObjects(unpinned)
+}
+
+// collectOrphanedObjects unpins every directly pinned object of pinned which is not contained in referenced
+// and has stayed unreferenced longer than the grace period, pins of other deployments are never touched
+// it returns the objects of pinned which are no longer pinned
+func collectOrphanedObjects(referenced, pinned map[string]bool, options *GarbageCollectorOptions) (*GarbageCollectionReport, []string, error) {
+
+	if options.JournalPath == "" {
+		options.JournalPath = garbageCollectorJournalPath
+	}
+
+	runShellInstance()
+
+	pins, err := sh.Pins()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unpinned []string
+	for hash := range pinned {
+		if _, ok := pins[hash]; !ok {
+			unpinned = append(unpinned, hash)
+		}
+	}
+
+	journal, err := readGarbageCollectorJournal(options.JournalPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	protected := make(map[string]bool)
+	for _, hash := range options.ProtectedObjects {
+		protected[hash] = true
+	}
+
+	report := &GarbageCollectionReport{
+		Referenced: len(referenced),
+		DryRun:     options.DryRun,
+	}
+
+	now := time.Now()
+	orphans := make(map[string]bool)
+
+	for hash, info := range pins {
+
+		// indirect pins are children of another pin and go away together with it
+		if info.Type == "indirect" {
+			continue
+		}
+
+		if !pinned[hash] || referenced[hash] || protected[hash] {
+			continue
+		}
+
+		orphans[hash] = true
+		report.Orphaned = append(report.Orphaned, hash)
+
+		if _, ok := journal[hash]; !ok {
+			journal[hash] = now.Format(time.RFC3339)
+		}
+
+		firstSeen, err := time.Parse(time.RFC3339, journal[hash])
+		if err != nil {
+			journal[hash] = now.Format(time.RFC3339)
+			firstSeen = now
+		}
+
+		if now.Sub(firstSeen) < options.GracePeriod {
+			report.Pending = append(report.Pending, hash)
+			continue
+		}
+
+		if options.DryRun {
+			report.Removed = append(report.Removed, hash)
+			continue
+		}
+
+		if err = sh.Unpin(hash); err != nil {
+			fmt.Println("Unable to unpin orphaned object " + hash + ": " + err.Error())
+			continue
+		}
+
+		delete(journal, hash)
+		report.Removed = append(report.Removed, hash)
+		unpinned = append(unpinned, hash)
+	}
+
+	// entries which got referenced again or are no longer pinned are forgotten
+	for hash := range journal {
+		if !orphans[hash] {
+			delete(journal, hash)
+		}
+	}
+
+	sort.Strings(report.Orphaned)
+	sort.Strings(report.Pending)
+	sort.Strings(report.Removed)
+
+	if options.DryRun {
+		return report, unpinned, nil
+	}
+
+	if err = writeGarbageCollectorJournal(options.JournalPath, journal); err != nil {
+		return report, unpinned, err
+	}
+
+	return report, unpinned, nil
+}
+
+func readGarbageCollectorJournal(path string) (garbageCollectorJournal, error) {
+
+	journal := make(garbageCollectorJournal)
+
+	if err := readJournal(path, &journal); err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+func writeGarbageCollectorJournal(path string, journal garbageCollectorJournal) error {
+
+	return writeJournal(path, journal)
+}
@@ -64,6 +64,9 @@ type personAccount struct {
 var sh     *shell.Shell
 var ncalls int
 
+// api endpoint of the local node, set by Configure
+var apiEndpoint = "localhost:5001"
+
 var _ = time.ANSIC
 
 func sleep() {
@@ -72,7 +75,7 @@ func sleep() {
 
 func runShellInstance() {
 
-	sh = shell.NewShell("localhost:5001")
+	sh = shell.NewShell(apiEndpoint)
 
 	//for i := 0; i < 200; i++ {
 	//	_, err := makeRandomObject()
@@ -0,0 +1,46 @@
+package ipfs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// journals keep state which must survive a restart as JSON files
+
+// readJournal decodes the journal into value, a missing journal leaves value as it is
+func readJournal(path string, value interface{}) error {
+
+	journalAsBytes, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(journalAsBytes, value)
+}
+
+func writeJournal(path string, value interface{}) error {
+
+	journalAsBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// write next to the journal and rename so a crash never leaves a truncated file
+	temporaryPath := path + ".tmp"
+	if err = ioutil.WriteFile(temporaryPath, journalAsBytes, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, path)
+}
@@ -0,0 +1,54 @@
+package ipfs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// link names in the public DAG are derived with a keyed HMAC so they do not reveal
+// account IDs, document names or the number of versions
+// the mapping back to the plain names is kept only in the encrypted account record
+// the key is set by Configure and must not change once accounts exist
+var linkNameKey []byte
+
+var ErrLinkKeyMissing = errors.New("IPFS link name key is not configured")
+
+// SetLinkNameKey replaces the key used for link name derivation - call it on startup
+func SetLinkNameKey(key []byte) {
+
+	linkNameKey = key
+}
+
+func AccountLinkName(accountPublicID string) (string, error) {
+
+	return deriveLinkName("account", accountPublicID)
+}
+
+func DocumentLinkName(accountPublicID, documentName string) (string, error) {
+
+	return deriveLinkName("document", accountPublicID, documentName)
+}
+
+func DocumentVersionLinkName(accountPublicID, documentName, documentVersion string) (string, error) {
+
+	return deriveLinkName("version", accountPublicID, documentName, documentVersion)
+}
+
+func deriveLinkName(elements ...string) (string, error) {
+
+	if len(linkNameKey) == 0 {
+		return "", ErrLinkKeyMissing
+	}
+
+	mac := hmac.New(sha256.New, linkNameKey)
+
+	// every element is terminated so ("ab", "c") and ("a", "bc") never collide
+	for _, element := range elements {
+		mac.Write([]byte(element))
+		mac.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,117 @@
+package ipfs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func withLinkNameKey(t *testing.T, key []byte) {
+
+	previous := linkNameKey
+	SetLinkNameKey(key)
+
+	t.Cleanup(func() { SetLinkNameKey(previous) })
+}
+
+func TestDeriveLinkName(t *testing.T) {
+
+	withLinkNameKey(t, []byte("link name key"))
+
+	expected := func(message string) string {
+		mac := hmac.New(sha256.New, []byte("link name key"))
+		mac.Write([]byte(message))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name   string
+		derive func() (string, error)
+		want   string
+	}{
+		{
+			name:   "account",
+			derive: func() (string, error) { return AccountLinkName("account1") },
+			want:   expected("account\x00account1\x00"),
+		},
+		{
+			name:   "document",
+			derive: func() (string, error) { return DocumentLinkName("account1", "passport") },
+			want:   expected("document\x00account1\x00passport\x00"),
+		},
+		{
+			name:   "version",
+			derive: func() (string, error) { return DocumentVersionLinkName("account1", "passport", "2") },
+			want:   expected("version\x00account1\x00passport\x002\x00"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			linkName, err := test.derive()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if linkName != test.want {
+				t.Fatalf("link name = %s, want %s", linkName, test.want)
+			}
+		})
+	}
+}
+
+func TestDeriveLinkNameSeparatesElements(t *testing.T) {
+
+	withLinkNameKey(t, []byte("link name key"))
+
+	tests := []struct {
+		name  string
+		left  []string
+		right []string
+	}{
+		{name: "element boundaries", left: []string{"ab", "c"}, right: []string{"a", "bc"}},
+		{name: "link types", left: []string{"account", "x"}, right: []string{"document", "x"}},
+		{name: "versions", left: []string{"version", "a", "d", "1"}, right: []string{"version", "a", "d", "2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			left, err := deriveLinkName(test.left...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			right, err := deriveLinkName(test.right...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if left == right {
+				t.Fatalf("%v and %v derive the same link name", test.left, test.right)
+			}
+		})
+	}
+}
+
+func TestDeriveLinkNameKey(t *testing.T) {
+
+	withLinkNameKey(t, nil)
+
+	if _, err := AccountLinkName("account1"); !errors.Is(err, ErrLinkKeyMissing) {
+		t.Fatalf("AccountLinkName() without a key error = %v, want ErrLinkKeyMissing", err)
+	}
+
+	SetLinkNameKey([]byte("first key"))
+	first, _ := AccountLinkName("account1")
+
+	SetLinkNameKey([]byte("second key"))
+	second, _ := AccountLinkName("account1")
+
+	if first == second {
+		t.Fatal("different keys derive the same link name")
+	}
+}
@@ -0,0 +1,243 @@
+package ipfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// the reference index keeps the objects this deployment pinned and the objects the committed account records
+// of its org reference - the garbage collector reads it instead of the ledger, so it needs neither the account keys
+// nor the records of other orgs, which the peers of this org cannot read
+// the index holds hashes and public IDs only, both are on the network and the ledger in the clear anyway
+var referenceIndexPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/reference-index.json"
+
+// ErrReferenceIndexIncomplete is returned by the garbage collector while an account update is not confirmed -
+// the record on the ledger may reference objects the index does not know yet
+var ErrReferenceIndexIncomplete = errors.New("Reference index has unconfirmed account updates")
+
+type referenceIndex struct {
+	// objects pinned by this deployment - the only objects the garbage collector may unpin
+	Pinned map[string]bool `json:"pinned"`
+
+	// account public ID -> objects referenced by its committed record
+	Accounts map[string][]string `json:"accounts"`
+
+	// account public ID -> ledger writes started and not confirmed yet
+	Pending map[string]int `json:"pending"`
+}
+
+var referenceIndexMutex sync.Mutex
+
+func readReferenceIndex() (*referenceIndex, error) {
+
+	index := &referenceIndex{}
+
+	if err := readJournal(referenceIndexPath, index); err != nil {
+		return nil, err
+	}
+
+	if index.Pinned == nil {
+		index.Pinned = make(map[string]bool)
+	}
+
+	if index.Accounts == nil {
+		index.Accounts = make(map[string][]string)
+	}
+
+	if index.Pending == nil {
+		index.Pending = make(map[string]int)
+	}
+
+	return index, nil
+}
+
+// updateReferenceIndex applies update to the stored index
+func updateReferenceIndex(update func(index *referenceIndex)) error {
+
+	referenceIndexMutex.Lock()
+	defer referenceIndexMutex.Unlock()
+
+	index, err := readReferenceIndex()
+	if err != nil {
+		return err
+	}
+
+	update(index)
+
+	return writeJournal(referenceIndexPath, index)
+}
+
+// loadReferenceIndex returns a copy of the stored index
+func loadReferenceIndex() (*referenceIndex, error) {
+
+	referenceIndexMutex.Lock()
+	defer referenceIndexMutex.Unlock()
+
+	return readReferenceIndex()
+}
+
+// recordPinnedObject adds an object pinned by this deployment to the index
+// an object missing from the index is never collected - a failed update leaks it and is logged only
+func recordPinnedObject(hash string) {
+
+	err := updateReferenceIndex(func(index *referenceIndex) {
+		index.Pinned[hash] = true
+	})
+
+	if err != nil {
+		fmt.Println("Unable to record pinned object " + hash + " in the reference index: " + err.Error())
+	}
+}
+
+// BeginAccountReferences marks the account before its record is written to the ledger
+// the garbage collector does not run until the write is committed, aborted or resolved
+func BeginAccountReferences(accountPublicID string) error {
+
+	if accountPublicID == "" {
+		return errors.New("Account public ID cannot be an empty string")
+	}
+
+	return updateReferenceIndex(func(index *referenceIndex) {
+		index.Pending[accountPublicID]++
+	})
+}
+
+// CommitAccountReferences records the objects of the account record committed to the ledger
+func CommitAccountReferences(tree *AccountTree) error {
+
+	if tree == nil || tree.PublicID == "" {
+		return errors.New("Account tree without a public ID cannot be committed")
+	}
+
+	roots := tree.committedRoots()
+
+	return updateReferenceIndex(func(index *referenceIndex) {
+		index.Accounts[tree.PublicID] = roots
+		releasePendingAccount(index, tree.PublicID)
+	})
+}
+
+// AbortAccountReferences ends a write the ledger refused - the committed record and its objects are unchanged
+// a write which may have reached the ledger must stay pending, ResolvePendingAccount settles it
+func AbortAccountReferences(accountPublicID string) error {
+
+	return updateReferenceIndex(func(index *referenceIndex) {
+		releasePendingAccount(index, accountPublicID)
+	})
+}
+
+// RemoveAccountReferences forgets the objects of an account deleted from the ledger
+func RemoveAccountReferences(accountPublicID string) error {
+
+	return updateReferenceIndex(func(index *referenceIndex) {
+		delete(index.Accounts, accountPublicID)
+		delete(index.Pending, accountPublicID)
+	})
+}
+
+// ResolvePendingAccount replaces the references of a pending account with the record read from the ledger,
+// a nil tree - the account does not exist on the ledger - removes them
+func ResolvePendingAccount(accountPublicID string, tree *AccountTree) error {
+
+	if tree == nil {
+		return RemoveAccountReferences(accountPublicID)
+	}
+
+	if tree.PublicID != accountPublicID {
+		return errors.New("Account tree of " + tree.PublicID + " cannot resolve account " + accountPublicID)
+	}
+
+	roots := tree.committedRoots()
+
+	return updateReferenceIndex(func(index *referenceIndex) {
+		index.Accounts[accountPublicID] = roots
+		delete(index.Pending, accountPublicID)
+	})
+}
+
+// PendingAccountReferences returns the accounts with unconfirmed ledger writes
+func PendingAccountReferences() ([]string, error) {
+
+	index, err := loadReferenceIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]string, 0, len(index.Pending))
+	for accountPublicID := range index.Pending {
+		accounts = append(accounts, accountPublicID)
+	}
+
+	sort.Strings(accounts)
+
+	return accounts, nil
+}
+
+// committedRoots returns the recorded hashes of the tree
+func (tree *AccountTree) committedRoots() []string {
+
+	var roots []string
+	for _, hash := range tree.roots() {
+		if hash != "" {
+			roots = append(roots, hash)
+		}
+	}
+
+	return roots
+}
+
+func releasePendingAccount(index *referenceIndex, accountPublicID string) {
+
+	if index.Pending[accountPublicID] <= 1 {
+		delete(index.Pending, accountPublicID)
+		return
+	}
+
+	index.Pending[accountPublicID]--
+}
+
+// completeReferences returns the referenced roots and the pinned objects of the index
+// an index with pending accounts is never returned - a partial reference set would unpin live content
+func completeReferences() ([]string, map[string]bool, error) {
+
+	index, err := loadReferenceIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(index.Pending) > 0 {
+		accounts := make([]string, 0, len(index.Pending))
+		for accountPublicID := range index.Pending {
+			accounts = append(accounts, accountPublicID)
+		}
+
+		sort.Strings(accounts)
+
+		return nil, nil, fmt.Errorf("%w: %s", ErrReferenceIndexIncomplete, strings.Join(accounts, ", "))
+	}
+
+	var roots []string
+	for _, accountRoots := range index.Accounts {
+		roots = append(roots, accountRoots...)
+	}
+
+	return roots, index.Pinned, nil
+}
+
+// forgetPinnedObjects drops objects which are no longer pinned from the index
+func forgetPinnedObjects(hashes []string) error {
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	return updateReferenceIndex(func(index *referenceIndex) {
+		for _, hash := range hashes {
+			delete(index.Pinned, hash)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package ipfs
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func useReferenceIndex(t *testing.T) {
+
+	previousPath := referenceIndexPath
+	referenceIndexPath = filepath.Join(t.TempDir(), "reference-index.json")
+
+	t.Cleanup(func() {
+		referenceIndexPath = previousPath
+	})
+}
+
+func TestReferenceIndexPendingAccounts(t *testing.T) {
+
+	useReferenceIndex(t)
+
+	tree := &AccountTree{
+		PublicID: "a1",
+		Account:  &IpfsDirectoryData{ContentIdentifier: "dir", ObjectHash: "object"},
+		Versions: []*IpfsDocumentVersionData{{ContentIdentifier: "file", ObjectHash: "version"}},
+	}
+
+	recordPinnedObject("file")
+
+	if err := BeginAccountReferences("a1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// an unconfirmed write may have reached the ledger - the references are incomplete until it is settled
+	if _, _, err := completeReferences(); !errors.Is(err, ErrReferenceIndexIncomplete) {
+		t.Fatalf("completeReferences() with a pending account = %v, want ErrReferenceIndexIncomplete", err)
+	}
+
+	if err := CommitAccountReferences(tree); err != nil {
+		t.Fatal(err)
+	}
+
+	roots, pinned, err := completeReferences()
+	if err != nil {
+		t.Fatalf("completeReferences() = %v", err)
+	}
+
+	sort.Strings(roots)
+	if want := []string{"dir", "file", "object", "version"}; !reflect.DeepEqual(roots, want) {
+		t.Fatalf("completeReferences() roots = %v, want %v", roots, want)
+	}
+
+	if !pinned["file"] || len(pinned) != 1 {
+		t.Fatalf("completeReferences() pinned = %v, want file", pinned)
+	}
+
+	// a refused write leaves the committed references as they were
+	if err = BeginAccountReferences("a1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = AbortAccountReferences("a1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if roots, _, err = completeReferences(); err != nil || len(roots) != 4 {
+		t.Fatalf("completeReferences() after an aborted write = %v, %v", roots, err)
+	}
+
+	// two writes in flight - the first commit does not settle the second
+	for i := 0; i < 2; i++ {
+		if err = BeginAccountReferences("a1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = CommitAccountReferences(tree); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := PendingAccountReferences()
+	if err != nil || !reflect.DeepEqual(pending, []string{"a1"}) {
+		t.Fatalf("PendingAccountReferences() = %v, %v, want [a1]", pending, err)
+	}
+
+	// the record on the ledger settles the account
+	if err = ResolvePendingAccount("a1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	roots, _, err = completeReferences()
+	if err != nil || len(roots) != 0 {
+		t.Fatalf("completeReferences() after the account was resolved as deleted = %v, %v", roots, err)
+	}
+}
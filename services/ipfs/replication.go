@@ -0,0 +1,559 @@
+package ipfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-ipfs-api"
+)
+
+const (
+	defaultReplicationTimeout = 2 * time.Minute
+	clusterPinPollInterval    = time.Second
+)
+
+// ErrQuorumNotReached is returned when fewer replicas than the quorum confirmed a pin
+var ErrQuorumNotReached = errors.New("Replication quorum not reached")
+
+// new document versions are replicated when replicas are set by Configure
+var replicator *Replicator
+
+// lagging replicas survive a restart in this journal
+var replicationJournalPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/replication-journal.json"
+
+// cid -> names of the targets still missing the replica
+type replicationJournal map[string][]string
+
+// PinTarget is a node or a pinning service which keeps a replica of uploaded content
+type PinTarget interface {
+	Name() string
+	Pin(cid string) error
+	IsPinned(cid string) (bool, error)
+}
+
+// Replicator pins new content on a set of targets
+// content counts as committed once quorum targets confirmed the pin,
+// lagging targets are brought up to date by the repairer
+type Replicator struct {
+	targets []PinTarget
+	quorum  int
+	timeout time.Duration
+
+	mutex       sync.Mutex
+	lagging     map[string]map[string]PinTarget // cid -> target name -> target
+	journalPath string
+	stop        chan struct{}
+}
+
+func NewReplicator(targets []PinTarget, quorum int) (*Replicator, error) {
+
+	if len(targets) == 0 {
+		return nil, errors.New("At least one replication target is required")
+	}
+
+	if quorum <= 0 || quorum > len(targets) {
+		return nil, errors.New("Replication quorum must be between 1 and " + strconv.Itoa(len(targets)))
+	}
+
+	return &Replicator{
+		targets: targets,
+		quorum:  quorum,
+		timeout: defaultReplicationTimeout,
+		lagging: make(map[string]map[string]PinTarget),
+	}, nil
+}
+
+// LoadJournal restores the lagging replicas recorded in the journal and keeps it up to date from now on
+// replicas of targets which are no longer configured are dropped
+func (replicator *Replicator) LoadJournal(path string) error {
+
+	journal := make(replicationJournal)
+	if err := readJournal(path, &journal); err != nil {
+		return err
+	}
+
+	targets := make(map[string]PinTarget)
+	for _, target := range replicator.targets {
+		targets[target.Name()] = target
+	}
+
+	replicator.mutex.Lock()
+	defer replicator.mutex.Unlock()
+
+	replicator.journalPath = path
+
+	for cid, names := range journal {
+		for _, name := range names {
+
+			target, ok := targets[name]
+			if !ok {
+				continue
+			}
+
+			if _, ok := replicator.lagging[cid]; !ok {
+				replicator.lagging[cid] = make(map[string]PinTarget)
+			}
+
+			replicator.lagging[cid][name] = target
+		}
+	}
+
+	return replicator.writeJournal()
+}
+
+// SetReplicator replaces the replicator used for uploads, nil disables replication
+func SetReplicator(newReplicator *Replicator) {
+
+	replicator = newReplicator
+}
+
+// Replicate pins cid on every target and waits until quorum targets confirmed it
+func (replicator *Replicator) Replicate(cid string) error {
+
+	type pinResult struct {
+		target PinTarget
+		err    error
+	}
+
+	results := make(chan pinResult, len(replicator.targets))
+
+	// every target lags until it confirms - the journal keeps them if the process stops midway
+	replicator.Track(cid)
+
+	for _, target := range replicator.targets {
+		go func(target PinTarget) {
+			results <- pinResult{target: target, err: target.Pin(cid)}
+		}(target)
+	}
+
+	timeout := time.After(replicator.timeout)
+	confirmed := 0
+
+	for received := 0; received < len(replicator.targets); received++ {
+
+		select {
+		case result := <-results:
+			if result.err != nil {
+				fmt.Println("Unable to pin " + cid + " on " + result.target.Name() + ": " + result.err.Error())
+				continue
+			}
+
+			replicator.markReplicated(cid, result.target.Name())
+			confirmed++
+
+		case <-timeout:
+			received = len(replicator.targets)
+		}
+
+		if confirmed >= replicator.quorum {
+			break
+		}
+	}
+
+	// targets which have not confirmed yet are left to the repairer
+	if confirmed < replicator.quorum {
+		return fmt.Errorf("%w for %s: %d of %d", ErrQuorumNotReached, cid, confirmed, replicator.quorum)
+	}
+
+	return nil
+}
+
+// Track hands existing content to the repairer so every target gets a replica
+func (replicator *Replicator) Track(cid string) {
+
+	pending := make(map[string]PinTarget)
+	for _, target := range replicator.targets {
+		pending[target.Name()] = target
+	}
+
+	replicator.markLagging(cid, pending)
+}
+
+// StartRepairer periodically pins lagging content on the targets which missed it
+func (replicator *Replicator) StartRepairer(interval time.Duration) {
+
+	replicator.mutex.Lock()
+	if replicator.stop != nil {
+		replicator.mutex.Unlock()
+		return
+	}
+
+	stop := make(chan struct{})
+	replicator.stop = stop
+	replicator.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				replicator.Repair()
+
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (replicator *Replicator) StopRepairer() {
+
+	replicator.mutex.Lock()
+	defer replicator.mutex.Unlock()
+
+	if replicator.stop != nil {
+		close(replicator.stop)
+		replicator.stop = nil
+	}
+}
+
+// Repair runs one repair pass and returns the number of replicas still lagging
+func (replicator *Replicator) Repair() int {
+
+	replicator.mutex.Lock()
+	work := make(map[string][]PinTarget)
+	for cid, targets := range replicator.lagging {
+		for _, target := range targets {
+			work[cid] = append(work[cid], target)
+		}
+	}
+	replicator.mutex.Unlock()
+
+	for cid, targets := range work {
+		for _, target := range targets {
+
+			pinned, err := target.IsPinned(cid)
+			if err == nil && !pinned {
+				err = target.Pin(cid)
+				pinned = err == nil
+			}
+
+			if err != nil {
+				fmt.Println("Unable to repair " + cid + " on " + target.Name() + ": " + err.Error())
+				continue
+			}
+
+			if pinned {
+				replicator.markReplicated(cid, target.Name())
+			}
+		}
+	}
+
+	return replicator.Lagging()
+}
+
+// Lagging returns the number of replicas waiting for repair
+func (replicator *Replicator) Lagging() int {
+
+	replicator.mutex.Lock()
+	defer replicator.mutex.Unlock()
+
+	count := 0
+	for _, targets := range replicator.lagging {
+		count += len(targets)
+	}
+
+	return count
+}
+
+func (replicator *Replicator) markLagging(cid string, targets map[string]PinTarget) {
+
+	if len(targets) == 0 {
+		return
+	}
+
+	replicator.mutex.Lock()
+	defer replicator.mutex.Unlock()
+
+	if _, ok := replicator.lagging[cid]; !ok {
+		replicator.lagging[cid] = make(map[string]PinTarget)
+	}
+
+	for name, target := range targets {
+		replicator.lagging[cid][name] = target
+	}
+
+	if err := replicator.writeJournal(); err != nil {
+		fmt.Println("Unable to write replication journal: " + err.Error())
+	}
+}
+
+func (replicator *Replicator) markReplicated(cid, targetName string) {
+
+	replicator.mutex.Lock()
+	defer replicator.mutex.Unlock()
+
+	delete(replicator.lagging[cid], targetName)
+
+	if len(replicator.lagging[cid]) == 0 {
+		delete(replicator.lagging, cid)
+	}
+
+	if err := replicator.writeJournal(); err != nil {
+		fmt.Println("Unable to write replication journal: " + err.Error())
+	}
+}
+
+// writeJournal is called with the mutex held, replicators without a journal keep lagging replicas in memory only
+func (replicator *Replicator) writeJournal() error {
+
+	if replicator.journalPath == "" {
+		return nil
+	}
+
+	journal := make(replicationJournal)
+	for cid, targets := range replicator.lagging {
+		for name := range targets {
+			journal[cid] = append(journal[cid], name)
+		}
+	}
+
+	return writeJournal(replicator.journalPath, journal)
+}
+
+// replicateContent is called after content is added to the local node
+func replicateContent(cid string) error {
+
+	if replicator == nil {
+		return nil
+	}
+
+	return replicator.Replicate(cid)
+}
+
+// newConfiguredReplicator returns nil when no replicas are configured
+// quorum 0 requires every replica
+func newConfiguredReplicator(replicas []string, cluster string, quorum int, journalPath string) (*Replicator, error) {
+
+	var targets []PinTarget
+
+	for _, endpoint := range replicas {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			targets = append(targets, NewNodePinTarget(endpoint))
+		}
+	}
+
+	if endpoint := strings.TrimSpace(cluster); endpoint != "" {
+		targets = append(targets, NewClusterPinTarget(endpoint))
+	}
+
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	if quorum == 0 {
+		quorum = len(targets)
+	}
+
+	newReplicator, err := NewReplicator(targets, quorum)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = newReplicator.LoadJournal(journalPath); err != nil {
+		return nil, err
+	}
+
+	newReplicator.StartRepairer(time.Minute)
+
+	return newReplicator, nil
+}
+
+// nodePinTarget pins through the api of another ipfs node
+type nodePinTarget struct {
+	endpoint string
+	shell    *shell.Shell
+}
+
+func NewNodePinTarget(endpoint string) PinTarget {
+
+	return &nodePinTarget{
+		endpoint: endpoint,
+		shell:    shell.NewShell(endpoint),
+	}
+}
+
+func (target *nodePinTarget) Name() string {
+
+	return target.endpoint
+}
+
+func (target *nodePinTarget) Pin(cid string) error {
+
+	return target.shell.Pin(cid)
+}
+
+func (target *nodePinTarget) IsPinned(cid string) (bool, error) {
+
+	pins, err := target.shell.Pins()
+	if err != nil {
+		return false, err
+	}
+
+	info, ok := pins[cid]
+
+	return ok && info.Type != "indirect", nil
+}
+
+// clusterPinTarget pins through the ipfs cluster rest api
+// the cluster distributes the replicas between its own peers
+type clusterPinTarget struct {
+	endpoint       string
+	client         *http.Client
+	pollInterval   time.Duration
+	confirmTimeout time.Duration
+}
+
+type clusterPinStatus struct {
+	PeerMap map[string]struct {
+		Status string `json:"status"`
+	} `json:"peer_map"`
+}
+
+func NewClusterPinTarget(endpoint string) PinTarget {
+
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "http://" + endpoint
+	}
+
+	return &clusterPinTarget{
+		endpoint:       strings.TrimRight(endpoint, "/"),
+		client:         &http.Client{Timeout: defaultReplicationTimeout},
+		pollInterval:   clusterPinPollInterval,
+		confirmTimeout: defaultReplicationTimeout,
+	}
+}
+
+func (target *clusterPinTarget) Name() string {
+
+	return target.endpoint
+}
+
+func (target *clusterPinTarget) Pin(cid string) error {
+
+	response, err := target.client.Post(target.endpoint+"/pins/"+cid, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return errors.New("Cluster pin request failed with status " + response.Status)
+	}
+
+	// the cluster only queues an accepted pin - wait until one of its peers holds the replica
+	deadline := time.Now().Add(target.confirmTimeout)
+
+	for {
+		pinned, err := target.IsPinned(cid)
+		if err != nil {
+			return err
+		}
+
+		if pinned {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New("Cluster did not confirm the pin of " + cid + " within " + target.confirmTimeout.String())
+		}
+
+		time.Sleep(target.pollInterval)
+	}
+}
+
+func (target *clusterPinTarget) IsPinned(cid string) (bool, error) {
+
+	response, err := target.client.Get(target.endpoint + "/pins/" + cid)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if response.StatusCode >= 300 {
+		return false, errors.New("Cluster status request failed with status " + response.Status)
+	}
+
+	status := &clusterPinStatus{}
+	if err = json.NewDecoder(response.Body).Decode(status); err != nil {
+		return false, err
+	}
+
+	for _, peer := range status.PeerMap {
+		if peer.Status == "pinned" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// MemoryPinTarget keeps pins in memory - used to run replication locally without extra nodes
+type MemoryPinTarget struct {
+	name string
+
+	mutex       sync.Mutex
+	pins        map[string]bool
+	unavailable bool
+}
+
+func NewMemoryPinTarget(name string) *MemoryPinTarget {
+
+	return &MemoryPinTarget{
+		name: name,
+		pins: make(map[string]bool),
+	}
+}
+
+// SetUnavailable makes every request fail until it is called with false
+func (target *MemoryPinTarget) SetUnavailable(unavailable bool) {
+
+	target.mutex.Lock()
+	defer target.mutex.Unlock()
+
+	target.unavailable = unavailable
+}
+
+func (target *MemoryPinTarget) Name() string {
+
+	return target.name
+}
+
+func (target *MemoryPinTarget) Pin(cid string) error {
+
+	target.mutex.Lock()
+	defer target.mutex.Unlock()
+
+	if target.unavailable {
+		return errors.New("Pin target " + target.name + " is unavailable")
+	}
+
+	target.pins[cid] = true
+
+	return nil
+}
+
+func (target *MemoryPinTarget) IsPinned(cid string) (bool, error) {
+
+	target.mutex.Lock()
+	defer target.mutex.Unlock()
+
+	if target.unavailable {
+		return false, errors.New("Pin target " + target.name + " is unavailable")
+	}
+
+	return target.pins[cid], nil
+}
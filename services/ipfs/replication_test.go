@@ -0,0 +1,202 @@
+package ipfs
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newMemoryTargets(names ...string) ([]PinTarget, []*MemoryPinTarget) {
+
+	var targets []PinTarget
+	var memoryTargets []*MemoryPinTarget
+
+	for _, name := range names {
+		target := NewMemoryPinTarget(name)
+		targets = append(targets, target)
+		memoryTargets = append(memoryTargets, target)
+	}
+
+	return targets, memoryTargets
+}
+
+func TestReplicatorQuorum(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		targets     int
+		quorum      int
+		unavailable int
+		wantErr     bool
+		wantLagging int
+	}{
+		{name: "every target confirms", targets: 3, quorum: 3},
+		{name: "quorum reached with a lagging target", targets: 3, quorum: 2, unavailable: 1, wantLagging: 1},
+		{name: "quorum not reached", targets: 3, quorum: 2, unavailable: 2, wantErr: true, wantLagging: 2},
+		{name: "single target unavailable", targets: 1, quorum: 1, unavailable: 1, wantErr: true, wantLagging: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			names := []string{"a", "b", "c"}[:test.targets]
+			targets, memoryTargets := newMemoryTargets(names...)
+
+			for _, target := range memoryTargets[:test.unavailable] {
+				target.SetUnavailable(true)
+			}
+
+			replicator, err := NewReplicator(targets, test.quorum)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = replicator.Replicate("cid")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Replicate() error = %v, want error %v", err, test.wantErr)
+			}
+
+			if test.wantErr && !errors.Is(err, ErrQuorumNotReached) {
+				t.Fatalf("Replicate() error = %v, want ErrQuorumNotReached", err)
+			}
+
+			// late confirmations are cleared by the repairer
+			if lagging := replicator.Repair(); lagging != test.wantLagging {
+				t.Fatalf("Repair() = %d lagging, want %d", lagging, test.wantLagging)
+			}
+
+			for _, target := range memoryTargets[:test.unavailable] {
+				target.SetUnavailable(false)
+			}
+
+			if lagging := replicator.Repair(); lagging != 0 {
+				t.Fatalf("Repair() after recovery = %d lagging, want 0", lagging)
+			}
+
+			for _, target := range memoryTargets {
+				if pinned, _ := target.IsPinned("cid"); !pinned {
+					t.Fatalf("%s has no replica after repair", target.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestNewReplicatorRejectsQuorum(t *testing.T) {
+
+	targets, _ := newMemoryTargets("a", "b")
+
+	for _, quorum := range []int{-1, 0, 3} {
+		if _, err := NewReplicator(targets, quorum); err == nil {
+			t.Fatalf("NewReplicator() with quorum %d succeeded", quorum)
+		}
+	}
+}
+
+func TestReplicatorJournal(t *testing.T) {
+
+	journalPath := filepath.Join(t.TempDir(), "replication-journal.json")
+
+	targets, memoryTargets := newMemoryTargets("a", "b")
+	memoryTargets[1].SetUnavailable(true)
+
+	replicator, err := NewReplicator(targets, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = replicator.LoadJournal(journalPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = replicator.Replicate("cid"); err != nil {
+		t.Fatal(err)
+	}
+
+	replicator.Repair()
+
+	// a restarted replicator picks the lagging replica up from the journal
+	restartedTargets, restartedMemoryTargets := newMemoryTargets("a", "b", "c")
+
+	restarted, err := NewReplicator(restartedTargets, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = restarted.LoadJournal(journalPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if lagging := restarted.Lagging(); lagging != 1 {
+		t.Fatalf("Lagging() after restart = %d, want 1", lagging)
+	}
+
+	if lagging := restarted.Repair(); lagging != 0 {
+		t.Fatalf("Repair() after restart = %d lagging, want 0", lagging)
+	}
+
+	if pinned, _ := restartedMemoryTargets[1].IsPinned("cid"); !pinned {
+		t.Fatal("lagging target has no replica after repair")
+	}
+
+	reloaded, err := NewReplicator(restartedTargets, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = reloaded.LoadJournal(journalPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if lagging := reloaded.Lagging(); lagging != 0 {
+		t.Fatalf("Lagging() after repair and restart = %d, want 0", lagging)
+	}
+}
+
+func TestClusterPinTargetWaitsForPin(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		pinnedPoll int32
+		wantErr    bool
+	}{
+		{name: "confirmed after polling", pinnedPoll: 3},
+		{name: "never confirmed", pinnedPoll: -1, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			var polls int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+				if r.Method == http.MethodPost {
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+
+				status := "pin_queued"
+				if poll := atomic.AddInt32(&polls, 1); test.pinnedPoll > 0 && poll >= test.pinnedPoll {
+					status = "pinned"
+				}
+
+				w.Write([]byte(`{"peer_map":{"peer":{"status":"` + status + `"}}}`))
+			}))
+			defer server.Close()
+
+			target := NewClusterPinTarget(server.URL).(*clusterPinTarget)
+			target.pollInterval = time.Millisecond
+			target.confirmTimeout = 50 * time.Millisecond
+
+			err := target.Pin("cid")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Pin() error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package ipfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	PersonAccountsGroup      = "personAccounts"
+	InstitutionAccountsGroup = "institutionAccounts"
+)
+
+const rootUpdateAttempts = 5
+
+// accounts whose link could not be added to the group root - the next root update adds them
+var rootJournalPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/root-journal.json"
+
+// group -> account link name -> account directory
+type rootJournal map[string]map[string]string
+
+// ErrRootConflict is returned by a RootRegistry when the registered root
+// no longer matches the root the update was based on
+var ErrRootConflict = errors.New("Root directory has been updated concurrently")
+
+// RootRegistry stores the current root directory of each accounts group
+type RootRegistry interface {
+	GetRoot(group string) (string, error)
+
+	// SwapRoot replaces previousRoot with newRoot, returns ErrRootConflict if previousRoot is stale
+	SwapRoot(group, previousRoot, newRoot string) error
+}
+
+// RootDirectories keeps the accounts group root directories up to date
+// each added or removed account produces a new root which is swapped in the registry
+type RootDirectories struct {
+	registry RootRegistry
+
+	mutex    sync.Mutex
+	ipnsKeys map[string]string
+}
+
+func NewRootDirectories(registry RootRegistry) *RootDirectories {
+
+	return &RootDirectories{
+		registry: registry,
+		ipnsKeys: make(map[string]string),
+	}
+}
+
+// PublishWithKey publishes every new root of the group under the IPNS name
+// of the provided key from the local node keystore
+func (rootDirectories *RootDirectories) PublishWithKey(group, keyName string) {
+
+	rootDirectories.mutex.Lock()
+	defer rootDirectories.mutex.Unlock()
+
+	if keyName == "" {
+		delete(rootDirectories.ipnsKeys, group)
+		return
+	}
+
+	rootDirectories.ipnsKeys[group] = keyName
+}
+
+// Root returns the current root of the group, an empty directory is registered if none exists
+func (rootDirectories *RootDirectories) Root(group string) (string, error) {
+
+	root, err := rootDirectories.registry.GetRoot(group)
+	if err != nil {
+		return "", err
+	}
+
+	if root != "" {
+		return root, nil
+	}
+
+	return rootDirectories.update(group, func(string) (string, error) {
+		return createNewEmptyDirectory()
+	})
+}
+
+// accounts are linked under their derived link name, never under the public ID
+func (rootDirectories *RootDirectories) AddAccount(group, accountPublicID, accountDirectoryHash string) (string, error) {
+
+	linkName, err := AccountLinkName(accountPublicID)
+	if err != nil {
+		return "", err
+	}
+
+	return rootDirectories.update(group, func(root string) (string, error) {
+		return sh.PatchLink(root, linkName, accountDirectoryHash, true)
+	})
+}
+
+func (rootDirectories *RootDirectories) RemoveAccount(group, accountPublicID string) (string, error) {
+
+	linkName, err := AccountLinkName(accountPublicID)
+	if err != nil {
+		return "", err
+	}
+
+	// a queued link of the account must not come back with the next update
+	if err = rootDirectories.dropPending(group, map[string]string{linkName: ""}); err != nil {
+		return "", err
+	}
+
+	return rootDirectories.update(group, func(root string) (string, error) {
+
+		newRoot, err := sh.Patch(root, "rm-link", linkName)
+		if err != nil {
+			// accounts created before link names were derived are linked under the public ID
+			return sh.Patch(root, "rm-link", accountPublicID)
+		}
+
+		return newRoot, nil
+	})
+}
+
+func (rootDirectories *RootDirectories) update(group string, change func(root string) (string, error)) (string, error) {
+
+	runShellInstance()
+
+	for attempt := 0; attempt < rootUpdateAttempts; attempt++ {
+
+		root, err := rootDirectories.registry.GetRoot(group)
+		if err != nil {
+			return "", err
+		}
+
+		if root == "" {
+			if root, err = createNewEmptyDirectory(); err != nil {
+				return "", err
+			}
+		}
+
+		newRoot, err := change(root)
+		if err != nil {
+			return "", err
+		}
+
+		// accounts queued by earlier failed updates go with this one
+		pending, err := rootDirectories.pendingLinks(group)
+		if err != nil {
+			return "", err
+		}
+
+		for linkName, accountDirectoryHash := range pending {
+			if newRoot, err = sh.PatchLink(newRoot, linkName, accountDirectoryHash, true); err != nil {
+				return "", err
+			}
+		}
+
+		if err = sh.Pin(newRoot); err != nil {
+			return "", err
+		}
+
+		err = rootDirectories.registry.SwapRoot(group, root, newRoot)
+
+		if err == ErrRootConflict {
+			// another account was added meanwhile - start again from the registered root
+			sh.Unpin(newRoot)
+			time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+			continue
+		}
+
+		if err != nil {
+			// the swap may have been ordered before the error - a commit timeout -
+			// the new root stays pinned unless the registry does not hold it
+			registered, getErr := rootDirectories.registry.GetRoot(group)
+			if getErr != nil || registered != newRoot {
+				if getErr == nil {
+					sh.Unpin(newRoot)
+				}
+
+				return "", err
+			}
+		}
+
+		// previous root is no longer referenced
+		sh.Unpin(root)
+
+		if err = rootDirectories.dropPending(group, pending); err != nil {
+			fmt.Println("Unable to update the root journal for group " + group + ": " + err.Error())
+		}
+
+		rootDirectories.publish(group, newRoot)
+
+		return newRoot, nil
+	}
+
+	return "", errors.New("Unable to update root directory for group " + group + ": " + ErrRootConflict.Error())
+}
+
+// QueueAccount records an account whose link could not be added to the group root
+// the link is added with the next update of the group root or by RepairPending
+func (rootDirectories *RootDirectories) QueueAccount(group, accountPublicID, accountDirectoryHash string) error {
+
+	linkName, err := AccountLinkName(accountPublicID)
+	if err != nil {
+		return err
+	}
+
+	rootDirectories.mutex.Lock()
+	defer rootDirectories.mutex.Unlock()
+
+	journal, err := readRootJournal()
+	if err != nil {
+		return err
+	}
+
+	if journal[group] == nil {
+		journal[group] = make(map[string]string)
+	}
+
+	journal[group][linkName] = accountDirectoryHash
+
+	return writeJournal(rootJournalPath, journal)
+}
+
+// RepairPending adds the queued accounts to the group root, nothing is swapped when no account is queued
+func (rootDirectories *RootDirectories) RepairPending(group string) (string, error) {
+
+	pending, err := rootDirectories.pendingLinks(group)
+	if err != nil || len(pending) == 0 {
+		return "", err
+	}
+
+	return rootDirectories.update(group, func(root string) (string, error) {
+		return root, nil
+	})
+}
+
+func (rootDirectories *RootDirectories) pendingLinks(group string) (map[string]string, error) {
+
+	rootDirectories.mutex.Lock()
+	defer rootDirectories.mutex.Unlock()
+
+	journal, err := readRootJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	return journal[group], nil
+}
+
+// dropPending removes the links from the journal, links queued meanwhile with another directory stay
+func (rootDirectories *RootDirectories) dropPending(group string, links map[string]string) error {
+
+	if len(links) == 0 {
+		return nil
+	}
+
+	rootDirectories.mutex.Lock()
+	defer rootDirectories.mutex.Unlock()
+
+	journal, err := readRootJournal()
+	if err != nil {
+		return err
+	}
+
+	if len(journal[group]) == 0 {
+		return nil
+	}
+
+	for linkName, accountDirectoryHash := range links {
+		if accountDirectoryHash == "" || journal[group][linkName] == accountDirectoryHash {
+			delete(journal[group], linkName)
+		}
+	}
+
+	if len(journal[group]) == 0 {
+		delete(journal, group)
+	}
+
+	return writeJournal(rootJournalPath, journal)
+}
+
+func readRootJournal() (rootJournal, error) {
+
+	journal := make(rootJournal)
+
+	if err := readJournal(rootJournalPath, &journal); err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+func (rootDirectories *RootDirectories) publish(group, root string) {
+
+	rootDirectories.mutex.Lock()
+	keyName, ok := rootDirectories.ipnsKeys[group]
+	rootDirectories.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	// the ledger is the source of truth - a failed publish is only reported
+	response, err := sh.PublishWithDetails("/ipfs/"+root, keyName, 0, 0, false)
+	if err != nil {
+		fmt.Println("Unable to publish root directory for group " + group + ": " + err.Error())
+		return
+	}
+
+	fmt.Println("Root directory for group " + group + " published under /ipns/" + response.Name)
+}
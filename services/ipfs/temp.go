@@ -1,89 +1,131 @@
 package ipfs
 
 import (
-	"io/ioutil"
+	"errors"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
-var personAccountsPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/personAccounts"
-var institutionAccountsTempPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/institutionAccounts"
+// temporary data of every group is kept in a separate store below this root
+var tempStoreRoot = os.Getenv("GOPATH") + "/src/cerberus/ipfs"
+var tempStoreOptions = DefaultTempStoreOptions()
 
-func CreateAccountIpfsTempDirectory(path, directoryName string) (string, error) {
+var tempStoresMutex sync.Mutex
+var tempStores = make(map[string]*TempStore) // group -> store
 
-	accountPath := filepath.Join(path, directoryName)
+// ConfigureTempStores changes the root and options of the temporary stores - call it on startup
+func ConfigureTempStores(root string, options TempStoreOptions) {
 
-	err := os.MkdirAll(accountPath, 0755)
+	tempStoresMutex.Lock()
+	defer tempStoresMutex.Unlock()
 
-	if err != nil {
-		return "", err
+	for group, store := range tempStores {
+		store.Close()
+		delete(tempStores, group)
 	}
 
-	return accountPath, nil
+	tempStoreRoot = root
+	tempStoreOptions = options
 }
 
-func GetAccountIpfsTempDirectory(path, directoryName string) (string, error) {
+// GetTempStore returns the temporary store of the group, the store is opened on first use
+func GetTempStore(group string) (*TempStore, error) {
+
+	tempStoresMutex.Lock()
+	defer tempStoresMutex.Unlock()
+
+	if store, ok := tempStores[group]; ok {
+		return store, nil
+	}
 
-	accountPath := filepath.Join(path, directoryName)
+	store, err := NewTempStore(filepath.Join(tempStoreRoot, group), tempStoreOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := os.Stat(accountPath)
+	tempStores[group] = store
 
-	if os.IsNotExist(err) {
-		path, err := CreateAccountIpfsTempDirectory(path, directoryName)
+	return store, nil
+}
 
-		if err != nil {
-			return "", err
-		}
+func CreateAccountIpfsTempDirectory(group, account string) (string, error) {
 
-		accountPath = path
+	store, err := GetTempStore(group)
+	if err != nil {
+		return "", err
 	}
 
-	return accountPath, nil
+	return store.Directory(account)
 }
 
-func CreateDocumentIpfsTempDiretory(path, documentName string) (string, error) {
+func GetAccountIpfsTempDirectory(group, account string) (string, error) {
 
-	documentPath := filepath.Join(path, documentName)
+	return CreateAccountIpfsTempDirectory(group, account)
+}
 
-	err := os.MkdirAll(documentPath, 0755)
+func CreateDocumentIpfsTempDiretory(group, account, documentName string) (string, error) {
 
+	store, err := GetTempStore(group)
 	if err != nil {
 		return "", err
 	}
 
-	rsaPath := filepath.Join(documentPath, "rsa")
-	err = os.MkdirAll(rsaPath, 0755)
+	// new document data is not accepted once the account is over its quota
+	if err = store.CheckQuota(account, 0); err != nil {
+		return "", err
+	}
 
-	if err != nil {
+	if _, err = store.Directory(account, documentName, "rsa"); err != nil {
 		return "", err
 	}
 
-	return documentPath, nil
+	return store.Path(account, documentName)
 }
 
-func GetDocumentIpfsTempDirectory(path, account, documentName string) (string, error) {
+func GetDocumentIpfsTempDirectory(group, account, documentName string) (string, error) {
+
+	return CreateDocumentIpfsTempDiretory(group, account, documentName)
+}
+
+// SaveDocumentKeyFile writes key data below the document directory of the account
+// key files count against the account quota and are kept until the document is deleted
+func SaveDocumentKeyFile(group, account, documentName string, data []byte, elements ...string) (string, error) {
+
+	store, err := GetTempStore(group)
+	if err != nil {
+		return "", err
+	}
+
+	return store.WriteFile(account, data, KeepUntilRemoved, append([]string{documentName}, elements...)...)
+}
 
-	accountPath, err := GetAccountIpfsTempDirectory(path, account)
+// DocumentIpfsTempPath returns the document directory without creating it
+func DocumentIpfsTempPath(group, account, documentName string) (string, error) {
 
+	store, err := GetTempStore(group)
 	if err != nil {
 		return "", err
 	}
 
-	documentPath := filepath.Join(accountPath, documentName)
+	return store.Path(account, documentName)
+}
 
-	_, err = os.Stat(documentPath)
+// expireTempFile schedules removal of a file kept in one of the opened stores
+// the expiry survives a restart, unlike a timer - a file outside every store is an error, nothing would remove it
+func expireTempFile(path string, ttl time.Duration) error {
 
-	if os.IsNotExist(err) {
-		path, err := CreateDocumentIpfsTempDiretory(accountPath, documentName)
+	tempStoresMutex.Lock()
+	defer tempStoresMutex.Unlock()
 
-		if err != nil {
-			return "", err
+	for _, store := range tempStores {
+		if _, err := store.relative(path); err == nil {
+			return store.Expire(path, ttl)
 		}
-
-		documentPath = path
 	}
 
-	return documentPath, nil
+	return errors.New("No temporary store holds " + path)
 }
 
 func deleteFile(fileName string) error {
@@ -97,36 +139,21 @@ func deleteFile(fileName string) error {
 	return nil
 }
 
-func DeleteDocumentIpfsTempDirectory(path, account, documentName string) (string, error) {
-
-	accountPath, err := GetAccountIpfsTempDirectory(path, account)
+func DeleteDocumentIpfsTempDirectory(group, account, documentName string) (string, error) {
 
+	store, err := GetTempStore(group)
 	if err != nil {
-		return "", nil
-	}
-
-	documentPath := filepath.Join(accountPath, documentName)
-
-	_, err = os.Stat(documentPath)
-
-	if os.IsNotExist(err) {
-
-		if err != nil {
-			return "", nil
-		}
+		return "", err
 	}
 
-	directory, err := ioutil.ReadDir(documentPath)
-
+	documentPath, err := store.Path(account, documentName)
 	if err != nil {
 		return "", err
 	}
 
-	for _, item := range directory {
-		os.RemoveAll(filepath.Join([]string{documentPath, item.Name()}...))
+	if err = store.Remove(documentPath); err != nil {
+		return "", err
 	}
 
-	os.Remove(documentPath)
-
-	return "", nil
+	return documentPath, nil
 }
@@ -0,0 +1,481 @@
+package ipfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tempStoreIndexFile = ".entries.json"
+
+	// index of earlier versions, it did not record kept entries
+	tempStoreLegacyIndexFile = ".expiry.json"
+)
+
+// KeepUntilRemoved as a ttl keeps the entry until Remove is called
+const KeepUntilRemoved time.Duration = -1
+
+var ErrTempQuotaExceeded = errors.New("Temporary storage quota exceeded for account")
+
+type TempStoreOptions struct {
+	// lifetime of files written with ttl 0, files missing from the index
+	// are removed by Sweep once they are older than this
+	DefaultTTL time.Duration
+
+	// how often expired entries are removed, 0 disables the background sweeper
+	SweepInterval time.Duration
+
+	// maximum bytes kept for a single account, 0 disables the quota
+	AccountQuota int64
+}
+
+// TempStore keeps temporary account data - rsa keys, cipher keys, exported files
+// directories are created with 0700 and files with 0600
+// expiries are saved in an index inside the root so entries left behind by a crash
+// are removed on the next start
+type TempStore struct {
+	root    string
+	options TempStoreOptions
+
+	mutex    sync.Mutex
+	expiries map[string]time.Time // path relative to root -> expiry, zero for kept entries
+	stop     chan struct{}
+}
+
+func DefaultTempStoreOptions() TempStoreOptions {
+
+	return TempStoreOptions{
+		DefaultTTL:    time.Hour,
+		SweepInterval: 10 * time.Minute,
+		AccountQuota:  512 * 1024 * 1024,
+	}
+}
+
+func NewTempStore(root string, options TempStoreOptions) (*TempStore, error) {
+
+	if root == "" {
+		return nil, errors.New("Temporary storage root cannot be an empty string")
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+
+	// directories created by previous versions were world readable
+	if err = os.Chmod(root, 0700); err != nil {
+		return nil, err
+	}
+
+	store := &TempStore{
+		root:     root,
+		options:  options,
+		expiries: make(map[string]time.Time),
+	}
+
+	if err = store.readIndex(); err != nil {
+		return nil, err
+	}
+
+	// leftovers from a crash
+	if _, err = store.Sweep(); err != nil {
+		return nil, err
+	}
+
+	if options.SweepInterval > 0 {
+		store.startSweeper(options.SweepInterval)
+	}
+
+	return store, nil
+}
+
+func (store *TempStore) Root() string {
+
+	return store.root
+}
+
+// Path returns the location of the provided elements inside the store without creating it
+func (store *TempStore) Path(elements ...string) (string, error) {
+
+	path := filepath.Join(append([]string{store.root}, elements...)...)
+
+	relativePath, err := filepath.Rel(store.root, path)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", errors.New("Path " + path + " is outside the temporary storage")
+	}
+
+	return path, nil
+}
+
+// Directory creates the directory if it does not exist and returns its location
+func (store *TempStore) Directory(elements ...string) (string, error) {
+
+	path, err := store.Path(elements...)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(path, 0700); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// WriteFile writes data for the account, ttl 0 uses the default lifetime
+// and KeepUntilRemoved keeps the file until it is removed
+func (store *TempStore) WriteFile(account string, data []byte, ttl time.Duration, elements ...string) (string, error) {
+
+	if err := store.CheckQuota(account, int64(len(data))); err != nil {
+		return "", err
+	}
+
+	path, err := store.Path(append([]string{account}, elements...)...)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	if ttl == 0 {
+		ttl = store.options.DefaultTTL
+	}
+
+	if err = store.Expire(path, ttl); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// CheckQuota returns ErrTempQuotaExceeded if the account cannot store additional bytes
+func (store *TempStore) CheckQuota(account string, additional int64) error {
+
+	if store.options.AccountQuota <= 0 {
+		return nil
+	}
+
+	usage, err := store.Usage(account)
+	if err != nil {
+		return err
+	}
+
+	if usage+additional > store.options.AccountQuota {
+		return fmt.Errorf("%w %s", ErrTempQuotaExceeded, account)
+	}
+
+	return nil
+}
+
+// Usage returns the number of bytes kept for the account
+func (store *TempStore) Usage(account string) (int64, error) {
+
+	accountPath, err := store.Path(account)
+	if err != nil {
+		return 0, err
+	}
+
+	var usage int64
+
+	err = filepath.Walk(accountPath, func(path string, info os.FileInfo, err error) error {
+
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			usage += info.Size()
+		}
+
+		return nil
+	})
+
+	return usage, err
+}
+
+// Expire schedules removal of the entry after ttl, a negative ttl keeps the entry until it is removed
+func (store *TempStore) Expire(path string, ttl time.Duration) error {
+
+	relativePath, err := store.relative(path)
+	if err != nil {
+		return err
+	}
+
+	var expiry time.Time
+	if ttl >= 0 {
+		expiry = time.Now().Add(ttl)
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.expiries[relativePath] = expiry
+
+	return store.writeIndex()
+}
+
+// Remove deletes the entry and everything below it
+func (store *TempStore) Remove(path string) error {
+
+	relativePath, err := store.relative(path)
+	if err != nil {
+		return err
+	}
+
+	if relativePath == "." {
+		return errors.New("Temporary storage root cannot be removed")
+	}
+
+	if err = os.RemoveAll(path); err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	prefix := relativePath + string(filepath.Separator)
+	for entry := range store.expiries {
+		if entry == relativePath || strings.HasPrefix(entry, prefix) {
+			delete(store.expiries, entry)
+		}
+	}
+
+	return store.writeIndex()
+}
+
+// Sweep removes every expired entry and every file missing from the index which is older
+// than the default lifetime, returns the number of removed entries
+func (store *TempStore) Sweep() (int, error) {
+
+	now := time.Now()
+
+	store.mutex.Lock()
+	var expired []string
+	for entry, expiry := range store.expiries {
+		if !expiry.IsZero() && now.After(expiry) {
+			expired = append(expired, entry)
+		}
+	}
+	store.mutex.Unlock()
+
+	// files written outside WriteFile, or left behind by a crash before their expiry was saved
+	if store.options.DefaultTTL > 0 {
+		unindexed, err := store.unindexedFiles()
+		if err != nil {
+			return 0, err
+		}
+
+		for entry, modified := range unindexed {
+			if now.Sub(modified) > store.options.DefaultTTL {
+				expired = append(expired, entry)
+			}
+		}
+	}
+
+	removed := 0
+	for _, entry := range expired {
+
+		if err := store.Remove(filepath.Join(store.root, entry)); err != nil {
+			return removed, err
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+func (store *TempStore) Close() {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.stop != nil {
+		close(store.stop)
+		store.stop = nil
+	}
+}
+
+func (store *TempStore) startSweeper(interval time.Duration) {
+
+	stop := make(chan struct{})
+	store.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := store.Sweep(); err != nil {
+					fmt.Println("Temporary storage sweep failed: " + err.Error())
+				}
+
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// unindexedFiles returns the files which are not covered by an index entry with their modification time
+func (store *TempStore) unindexedFiles() (map[string]time.Time, error) {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	unindexed := make(map[string]time.Time)
+
+	err := filepath.Walk(store.root, func(path string, info os.FileInfo, err error) error {
+
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(store.root, path)
+		if err != nil {
+			return err
+		}
+
+		if relativePath == tempStoreIndexFile || relativePath == tempStoreIndexFile+".tmp" || relativePath == tempStoreLegacyIndexFile {
+			return nil
+		}
+
+		if !store.indexed(relativePath) {
+			unindexed[relativePath] = info.ModTime()
+		}
+
+		return nil
+	})
+
+	return unindexed, err
+}
+
+// indexed reports whether the path or one of its parents has an index entry, must be called with the mutex held
+func (store *TempStore) indexed(relativePath string) bool {
+
+	for path := relativePath; path != "." && path != string(filepath.Separator); path = filepath.Dir(path) {
+		if _, ok := store.expiries[path]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (store *TempStore) relative(path string) (string, error) {
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	relativePath, err := filepath.Rel(store.root, absolutePath)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", errors.New("Path " + path + " is outside the temporary storage")
+	}
+
+	return relativePath, nil
+}
+
+func (store *TempStore) readIndex() error {
+
+	indexAsBytes, err := ioutil.ReadFile(filepath.Join(store.root, tempStoreIndexFile))
+
+	if os.IsNotExist(err) {
+		return store.adoptFiles()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(indexAsBytes, &store.expiries)
+}
+
+// adoptFiles creates the index of a store opened for the first time
+// files already in the store are kept - key files were written without an index entry before
+func (store *TempStore) adoptFiles() error {
+
+	legacyIndex := filepath.Join(store.root, tempStoreLegacyIndexFile)
+
+	indexAsBytes, err := ioutil.ReadFile(legacyIndex)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil {
+		if err = json.Unmarshal(indexAsBytes, &store.expiries); err != nil {
+			return err
+		}
+	}
+
+	unindexed, err := store.unindexedFiles()
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for entry := range unindexed {
+		store.expiries[entry] = time.Time{}
+	}
+
+	if err = store.writeIndex(); err != nil {
+		return err
+	}
+
+	if err = os.Remove(legacyIndex); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// must be called with the mutex held
+func (store *TempStore) writeIndex() error {
+
+	indexAsBytes, err := json.Marshal(store.expiries)
+	if err != nil {
+		return err
+	}
+
+	index := filepath.Join(store.root, tempStoreIndexFile)
+
+	if err = ioutil.WriteFile(index+".tmp", indexAsBytes, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(index+".tmp", index)
+}
@@ -0,0 +1,168 @@
+package ipfs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestTempStore(t *testing.T, options TempStoreOptions) *TempStore {
+
+	store, err := NewTempStore(t.TempDir(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func TestTempStoreQuota(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		quota   int64
+		writes  []int
+		wantErr bool
+	}{
+		{name: "within quota", quota: 10, writes: []int{4, 6}},
+		{name: "over quota", quota: 10, writes: []int{4, 7}, wantErr: true},
+		{name: "quota disabled", quota: 0, writes: []int{1024, 1024}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			store := newTestTempStore(t, TempStoreOptions{AccountQuota: test.quota})
+
+			var err error
+			for i, size := range test.writes {
+				_, err = store.WriteFile("account", make([]byte, size), KeepUntilRemoved, "file"+string(rune('a'+i)))
+				if err != nil {
+					break
+				}
+			}
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("WriteFile() error = %v, want error %v", err, test.wantErr)
+			}
+
+			if test.wantErr && !errors.Is(err, ErrTempQuotaExceeded) {
+				t.Fatalf("WriteFile() error = %v, want ErrTempQuotaExceeded", err)
+			}
+
+			// other accounts have their own quota
+			if _, err = store.WriteFile("other", make([]byte, 1), KeepUntilRemoved, "file"); err != nil {
+				t.Fatalf("WriteFile() for another account: %v", err)
+			}
+		})
+	}
+}
+
+func TestTempStoreSweep(t *testing.T) {
+
+	old := time.Now().Add(-2 * time.Hour)
+
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		unindexed   bool
+		modified    time.Time
+		wantRemoved bool
+	}{
+		{name: "expired entry", ttl: time.Nanosecond, wantRemoved: true},
+		{name: "entry before its expiry", ttl: time.Hour},
+		{name: "default lifetime", ttl: 0},
+		{name: "kept entry", ttl: KeepUntilRemoved},
+		{name: "old unindexed file", unindexed: true, modified: old, wantRemoved: true},
+		{name: "recent unindexed file", unindexed: true, modified: time.Now()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			store := newTestTempStore(t, TempStoreOptions{DefaultTTL: time.Hour})
+
+			var path string
+			var err error
+
+			if test.unindexed {
+				path, err = store.Path("account", "file")
+				if err == nil {
+					err = os.MkdirAll(filepath.Dir(path), 0700)
+				}
+				if err == nil {
+					err = ioutil.WriteFile(path, []byte("data"), 0600)
+				}
+				if err == nil {
+					err = os.Chtimes(path, test.modified, test.modified)
+				}
+			} else {
+				path, err = store.WriteFile("account", []byte("data"), test.ttl, "file")
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(time.Millisecond)
+
+			if _, err = store.Sweep(); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = os.Stat(path)
+			if removed := os.IsNotExist(err); removed != test.wantRemoved {
+				t.Fatalf("Sweep() removed file = %v, want %v", removed, test.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestTempStoreAdoptsExistingFiles(t *testing.T) {
+
+	root := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+
+	keyPath := filepath.Join(root, "account", "document", "1", "rsa", "rsa_key.pem")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(keyPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewTempStore(root, TempStoreOptions{DefaultTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if _, err = store.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(keyPath); err != nil {
+		t.Fatalf("key file written before the index was removed: %v", err)
+	}
+}
+
+func TestTempStorePathOutsideRoot(t *testing.T) {
+
+	store := newTestTempStore(t, TempStoreOptions{})
+
+	for _, elements := range [][]string{{".."}, {"account", "..", ".."}, {"..", "other"}} {
+		if _, err := store.Path(elements...); err == nil {
+			t.Fatalf("Path(%v) succeeded", elements)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-ipfs-api"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const defaultUploadChunkSize = 256 * 1024
+
+// interrupted upload sessions are resumed from journals saved in this directory
+var uploadJournalPath = os.Getenv("GOPATH") + "/src/cerberus/ipfs/uploads"
+
+// uploaded chunks are assembled inside the node mutable file system
+const uploadFilesRoot = "/cerberus-uploads"
+
+type UploadProgress struct {
+	SessionID      string
+	BytesProcessed int64
+	TotalBytes     int64
+}
+
+type UploadProgressFunc func(progress UploadProgress)
+
+// UploadSession uploads a document version in chunks
+// every written chunk is recorded in a local journal so an interrupted upload
+// continues from the last written offset instead of starting over
+type UploadSession struct {
+	ID                       string `json:"id"`
+	DocumentName             string `json:"documentName"`
+	ParentDirectoryReference string `json:"parentDirectoryReference"`
+	ParentDirectoryHash      string `json:"parentDirectoryHash"`
+	ParentLinkObject         string `json:"parentLinkObject"`
+	TotalBytes               int64  `json:"totalBytes"`
+	ChunkSize                int64  `json:"chunkSize"`
+	Offset                   int64  `json:"offset"`
+	FilePath                 string `json:"filePath"`
+	CreatedAt                string `json:"createdAt"`
+	UpdatedAt                string `json:"updatedAt"`
+
+	progress UploadProgressFunc
+	updates  chan UploadProgress
+}
+
+func NewUploadSession(documentName, parentDirectoryReference, parentDirectoryHash, parentLinkObject string, totalBytes int64) (*UploadSession, error) {
+
+	if totalBytes <= 0 {
+		return nil, errors.New("Upload size must be a positive number")
+	}
+
+	id := bson.NewObjectId().Hex()
+
+	session := &UploadSession{
+		ID:                       id,
+		DocumentName:             documentName,
+		ParentDirectoryReference: parentDirectoryReference,
+		ParentDirectoryHash:      parentDirectoryHash,
+		ParentLinkObject:         parentLinkObject,
+		TotalBytes:               totalBytes,
+		ChunkSize:                defaultUploadChunkSize,
+		FilePath:                 uploadFilesRoot + "/" + id,
+		CreatedAt:                time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	if err := session.save(); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ResumeUploadSession loads an interrupted session from its journal
+// the offset is reconciled with the size of the data the node actually holds
+func ResumeUploadSession(sessionID string) (*UploadSession, error) {
+
+	journalAsBytes, err := ioutil.ReadFile(uploadSessionJournal(sessionID))
+
+	if os.IsNotExist(err) {
+		return nil, errors.New("Upload session " + sessionID + " does not exist")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	session := &UploadSession{}
+	if err = json.Unmarshal(journalAsBytes, session); err != nil {
+		return nil, err
+	}
+
+	runShellInstance()
+
+	stat, err := sh.FilesStat(context.Background(), session.FilePath)
+	if err != nil {
+		// nothing reached the node before the interruption
+		session.Offset = 0
+		return session, session.save()
+	}
+
+	// a chunk may have been written after the journal was last saved
+	written := int64(stat.Size)
+	if written > session.TotalBytes {
+		return nil, errors.New("Upload session " + sessionID + " holds more data than expected")
+	}
+
+	session.Offset = written
+
+	return session, session.save()
+}
+
+// OnProgress registers a callback called after every written chunk
+func (session *UploadSession) OnProgress(progress UploadProgressFunc) {
+
+	session.progress = progress
+}
+
+// Progress returns a channel which receives the progress after every written chunk
+// the channel is closed by Finish or Abort
+func (session *UploadSession) Progress() <-chan UploadProgress {
+
+	if session.updates == nil {
+		session.updates = make(chan UploadProgress, 16)
+	}
+
+	return session.updates
+}
+
+// Write appends the chunk at the current session offset
+func (session *UploadSession) Write(chunk []byte) error {
+
+	if session.Offset+int64(len(chunk)) > session.TotalBytes {
+		return errors.New("Chunk exceeds the declared upload size")
+	}
+
+	runShellInstance()
+
+	err := sh.FilesWrite(context.Background(), session.FilePath, bytes.NewReader(chunk),
+		shell.FilesWrite.Offset(session.Offset),
+		shell.FilesWrite.Create(true),
+		shell.FilesWrite.Parents(true),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	session.Offset += int64(len(chunk))
+	session.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+
+	if err = session.save(); err != nil {
+		return err
+	}
+
+	session.reportProgress(session.Offset)
+
+	return nil
+}
+
+// Upload writes the source starting from the session offset
+// the same source must be provided again when a session is resumed
+func (session *UploadSession) Upload(source io.ReadSeeker) error {
+
+	if _, err := source.Seek(session.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, session.ChunkSize)
+
+	for session.Offset < session.TotalBytes {
+
+		n, err := io.ReadFull(source, chunk)
+
+		if n > 0 {
+			if err := session.Write(chunk[:n]); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if session.Offset != session.TotalBytes {
+		return errors.New("Upload source ended after " + strconv.FormatInt(session.Offset, 10) + " of " + strconv.FormatInt(session.TotalBytes, 10) + " bytes")
+	}
+
+	return nil
+}
+
+// Finish links the assembled content into the document directory the same way UploadFileToIpfs does
+func (session *UploadSession) Finish() (*IpfsDocumentVersionData, string, error) {
+
+	if session.Offset != session.TotalBytes {
+		return nil, "", errors.New("Upload session " + session.ID + " is not complete")
+	}
+
+	runShellInstance()
+
+	stat, err := sh.FilesStat(context.Background(), session.FilePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// keep the content once it is removed from the mutable file system
+	if err = sh.Pin(stat.Hash); err != nil {
+		return nil, "", err
+	}
+
+	// the version is committed only after enough replicas confirmed the pin
+	if err = replicateContent(stat.Hash); err != nil {
+		return nil, "", err
+	}
+
+	documentVersion, updatedParentLinkObject, err := linkFileToDocumentDirectory(stat.Hash, session.DocumentName, session.ParentDirectoryReference, session.ParentDirectoryHash, session.ParentLinkObject)
+	if err != nil {
+		return nil, "", err
+	}
+
+	session.cleanup()
+
+	return documentVersion, updatedParentLinkObject, nil
+}
+
+// Abort discards the uploaded chunks and the journal
+func (session *UploadSession) Abort() {
+
+	runShellInstance()
+
+	session.cleanup()
+}
+
+func (session *UploadSession) cleanup() {
+
+	sh.FilesRm(context.Background(), session.FilePath, true)
+	os.Remove(uploadSessionJournal(session.ID))
+
+	if session.updates != nil {
+		close(session.updates)
+		session.updates = nil
+	}
+}
+
+func (session *UploadSession) reportProgress(bytesProcessed int64) {
+
+	progress := UploadProgress{
+		SessionID:      session.ID,
+		BytesProcessed: bytesProcessed,
+		TotalBytes:     session.TotalBytes,
+	}
+
+	if session.progress != nil {
+		session.progress(progress)
+	}
+
+	// never block the upload on a slow reader
+	if session.updates != nil {
+		select {
+		case session.updates <- progress:
+		default:
+		}
+	}
+}
+
+func (session *UploadSession) save() error {
+
+	if err := os.MkdirAll(uploadJournalPath, 0700); err != nil {
+		return err
+	}
+
+	journalAsBytes, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	journal := uploadSessionJournal(session.ID)
+
+	// write next to the journal and rename so a crash never leaves a truncated file
+	if err = ioutil.WriteFile(journal+".tmp", journalAsBytes, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(journal+".tmp", journal)
+}
+
+func uploadSessionJournal(sessionID string) string {
+
+	return filepath.Join(uploadJournalPath, sessionID+".json")
+}